@@ -10,6 +10,9 @@ import (
 	"github.com/jeromelesaux/martine/common"
 	"github.com/jeromelesaux/martine/config"
 	"github.com/jeromelesaux/martine/constants"
+	"github.com/jeromelesaux/martine/convert/address"
+	ci "github.com/jeromelesaux/martine/convert/image"
+	"github.com/jeromelesaux/martine/export"
 	"github.com/jeromelesaux/martine/export/compression"
 )
 
@@ -107,12 +110,32 @@ func ExportHandler() (*config.MartineConfig, constants.Size) {
 	cfg.Rotation3DType = *rotate3dType
 	cfg.Rotation3DX0 = *rotate3dX0
 	cfg.Rotation3DY0 = *rotate3dY0
+	cfg.Rotation3DAxis = *rotate3dAxis
+	cfg.Rotation3DDistance = *rotate3dDistance
+	cfg.Rotation3DBilinear = *rotate3dBilinear
+	cfg.Rotation3DKeys = *rotate3dKeys
 	cfg.M4Host = *m4Host
 	cfg.M4RemotePath = *m4RemotePath
 	cfg.M4Autoexec = *m4Autoexec
 	cfg.ResizingAlgo = resizeAlgo
 	cfg.DitheringMultiplier = *ditheringMultiplier
 	cfg.DitheringWithQuantification = *withQuantization
+	cfg.DitheringPaletteAware = *paletteAware
+	cfg.DitheringSerpentine = *serpentine
+	cfg.StableDithering = *stableDithering
+	cfg.StableThreshold = *stableThreshold
+	cfg.DitheringErrorClamp = *errorClamp
+	if *channelWeights != "" {
+		weights := strings.Split(*channelWeights, ",")
+		for i := 0; i < len(weights) && i < 3; i++ {
+			v, err := strconv.ParseFloat(strings.TrimSpace(weights[i]), 64)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Cannot parse the channel weight (%s) :%v\n", weights[i], err)
+				continue
+			}
+			cfg.DitheringWeights[i] = v
+		}
+	}
 	cfg.PalettePath = *palettePath
 	cfg.InkPath = *inkPath
 	cfg.KitPath = *kitPath
@@ -126,9 +149,62 @@ func ExportHandler() (*config.MartineConfig, constants.Size) {
 	cfg.RotationLostlowBit = *lostlow
 	cfg.RotationIterations = *iterations
 	cfg.Flash = *flash
+	cfg.FlashFrames = *flashFrames
+	cfg.FlashMaxFlicker = *maxFlicker
 	cfg.Sna = *sna
 	cfg.SpriteHard = *spriteHard
 	cfg.SplitRaster = *splitRasters
+	cfg.MixedModeBands = *mixedModeBands
+	cfg.PlusMaxColors = *plusMaxColors
+	cfg.PlusChangesByLine = *plusChanges
+	cfg.DmaRaster = *dmaRaster
+	cfg.DmaPen = *dmaPen
+	cfg.DmaLine = *dmaLine
+	cfg.DmaKeyframes = *dmaKeyframes
+	cfg.BackgroundRaster = *backgroundRaster
+	cfg.PreShift = *preShift
+	cfg.PreShiftMask = *preShiftMask
+	cfg.PreShiftBank = *preShiftBank
+	cfg.Transparency = *transparent
+	if *transparentKey != "" {
+		key, err := ci.ParseKeyColor(*transparentKey)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Cannot parse transparentkey option with error [%s]\n", err)
+			os.Exit(-1)
+		}
+		cfg.Transparency = true
+		cfg.TransparentKey = key
+	}
+	switch *collision {
+	case "":
+	case "pixel":
+		cfg.Collision = true
+		cfg.CollisionPixelMask = true
+	case "byte":
+		cfg.Collision = true
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown collision option [%s], expected pixel or byte\n", *collision)
+		os.Exit(-1)
+	}
+	if *hitboxes != "" {
+		boxes, err := export.ParseHitboxes(*hitboxes)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Cannot parse hitboxes option with error [%s]\n", err)
+			os.Exit(-1)
+		}
+		cfg.CollisionHitboxes = boxes
+	}
+	switch *transparentMask {
+	case "":
+	case "interleaved":
+		cfg.TransparentMask = true
+		cfg.TransparentInterleaved = true
+	case "separate":
+		cfg.TransparentMask = true
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown transparentmask option [%s], expected interleaved or separate\n", *transparentMask)
+		os.Exit(-1)
+	}
 	cfg.ZigZag = *zigzag
 	cfg.Animate = *doAnimation
 	cfg.Reducer = *reducer
@@ -165,6 +241,19 @@ func ExportHandler() (*config.MartineConfig, constants.Size) {
 			os.Exit(-1)
 		}
 	}
+	if *crtc != "" {
+		g, err := address.ParseGeometry(*crtc)
+		if err == nil {
+			err = cfg.SetGeometry(g)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Cannot parse crtc option with error [%s]\n", err)
+			os.Exit(-1)
+		}
+		if !cfg.CustomDimension {
+			size = g.Size(uint8(*mode))
+		}
+	}
 
 	if *maskSprite != "" {
 
@@ -13,11 +13,13 @@ import (
 	"fyne.io/fyne/v2/app"
 	"github.com/jeromelesaux/martine/common"
 	"github.com/jeromelesaux/martine/constants"
+	"github.com/jeromelesaux/martine/convert/address"
 	"github.com/jeromelesaux/martine/convert/screen"
 	covs "github.com/jeromelesaux/martine/convert/screen/overscan"
 	"github.com/jeromelesaux/martine/convert/sprite"
 	"github.com/jeromelesaux/martine/export/ascii"
 	"github.com/jeromelesaux/martine/export/diskimage"
+	"github.com/jeromelesaux/martine/export/impdraw/mixedmode"
 	ovs "github.com/jeromelesaux/martine/export/impdraw/overscan"
 	impPalette "github.com/jeromelesaux/martine/export/impdraw/palette"
 	"github.com/jeromelesaux/martine/export/impdraw/tile"
@@ -84,18 +86,34 @@ var (
 	m4RemotePath        = flag.String("remotepath", "", "Remote path on your M4 where you want to copy your files.")
 	m4Autoexec          = flag.Bool("autoexec", false, "Execute on your remote CPC the screen file or basic file.")
 	rotate3dMode        = flag.Bool("rotate3d", false, "Allow 3d rotation on the input image, the input image must be a square (width equals height)")
-	rotate3dType        = flag.Int("rotate3dtype", 0, "Rotation type :\n\t1 rotate on X axis\n\t2 rotate on Y axis\n\t3 rotate reverse X axis\n\t4 rotate left to right on Y axis\n\t5 diagonal rotation on X axis\n\t6 diagonal rotation on Y axis\n")
+	rotate3dType        = flag.Int("rotate3dtype", 0, "Rotation type :\n\t1 rotate on X axis\n\t2 rotate on Y axis\n\t3 rotate reverse X axis\n\t4 rotate left to right on Y axis\n\t5 diagonal rotation on X axis\n\t6 diagonal rotation on Y axis\n\t7 perspective projection around the -rotate3daxis axis\n")
 	rotate3dX0          = flag.Int("rotate3dx0", -1, "X0 coordinate to apply in 3d rotation (default width of the image/2)")
 	rotate3dY0          = flag.Int("rotate3dy0", -1, "Y0 coordinate to apply in 3d rotation (default height of the image/2)")
+	rotate3dAxis        = flag.String("rotate3daxis", "0,1,0", "Axis x,y,z of the 3d rotation type 7 (x to the right, y to the bottom, z to the back).")
+	rotate3dDistance    = flag.Float64("rotate3ddistance", 2, "Distance of the camera in image heights of the 3d rotation type 7.")
+	rotate3dBilinear    = flag.Bool("rotate3dbilinear", false, "Bilinear sampling of the image with the 3d rotation type 7 (nearest by default).")
+	rotate3dKeys        = flag.String("rotate3dkeys", "", "Keyframes frame:angle of the 3d rotation type 7, the angles are interpolated between the keyframes (ex: -iter 24 -rotate3dkeys 0:0,12:180,23:360). A full turn by default.")
 	initProcess         = flag.String("initprocess", "", "Create a new empty process file.")
 	processFile         = flag.String("processfile", "", "Process file path to apply.")
+	initProject         = flag.String("initproject", "", "Create a new project file (all the assets of a production, use then : martine build project.json).")
+	initWatch           = flag.String("initwatch", "", "Create a new watch process file (folder process file with globs and per file overrides).")
+	watchFile           = flag.String("watch", "", "Watch process file path, will rebuild the changed images, palettes and dsk/sna each time a file is saved.\n\t(ex: -initwatch assets/watch.json then -watch assets/watch.json)")
 	deltaMode           = flag.Bool("delta", false, "Delta mode: compute delta between two files (prefixed by the argument -df)\n\t(ex: -delta -df file1.SCR -df file2.SCR -df file3.SCR).\n\t(ex with wildcard: -delta -df file\\?.SCR or -delta file\\*.SCR")
-	ditheringAlgo       = flag.Int("dithering", -1, "Dithering algorithm to apply on input image\nAlgorithms available:\n\t0: FloydSteinberg\n\t1: JarvisJudiceNinke\n\t2: Stucki\n\t3: Atkinson\n\t4: Sierra\n\t5: SierraLite\n\t6: Sierra3\n\t7: Bayer2\n\t8: Bayer3\n\t9: Bayer4\n\t10: Bayer8\n")
+	ditheringAlgo       = flag.Int("dithering", -1, "Dithering algorithm to apply on input image\nAlgorithms available:\n\t0: FloydSteinberg\n\t1: JarvisJudiceNinke\n\t2: Stucki\n\t3: Atkinson\n\t4: Sierra\n\t5: SierraLite\n\t6: Sierra3\n\t7: Bayer2\n\t8: Bayer3\n\t9: Bayer4\n\t10: Bayer8\n\t11: BlueNoise16\n\t12: VoidAndCluster16\n")
+	ditheringPattern    = flag.Int("pattern", 0, "Positional dithering algorithm used with the ordered dithering matrices\nAlgorithms available:\n\t0: Yliluoma1\n\t1: Yliluoma2\n\t2: Yliluoma3\n\t3: Knoll\n")
 	ditheringMultiplier = flag.Float64("multiplier", 1.18, "Error dithering multiplier.")
 	withQuantization    = flag.Bool("quantization", false, "Use additionnal quantization for dithering.")
+	stableDithering     = flag.Bool("stable", false, "Stable dithering for the animations (delta packing), the pixels barely changed keep the inks of the previous image.")
+	stableThreshold     = flag.Int("stablethreshold", 8, "Maximum difference by channel of a pixel between two images to keep its previous ink with the stable dithering.")
+	paletteAware        = flag.Bool("paletteaware", false, "Diffuse the error of the error diffusion dithering against the inks of the palette (2, 4 or 16 inks following the mode).")
+	serpentine          = flag.Bool("serpentine", false, "Serpentine scanning for the error diffusion dithering with the -paletteaware option (odd lines from right to left).")
+	errorClamp          = flag.Float64("errorclamp", 0, "Maximum error diffused by channel for the error diffusion dithering with the -paletteaware option (0 no limit).")
+	channelWeights      = flag.String("channelweights", "", "Red, green and blue weights to find the nearest ink with the error diffusion dithering with the -paletteaware option (ex: -channelweights 3,4,2).")
 	extendedDsk         = flag.Bool("extendeddsk", false, "Export in a Extended DSK 80 tracks, 10 sectors 400 ko per face")
 	reverse             = flag.Bool("reverse", false, "Transform .scr (overscan or not) file with palette (pal or kit file) into png file")
 	flash               = flag.Bool("flash", false, "generate flash animation with two ocp screens.\n\t(ex: -mode 1 -flash -in input.png -out test -dsk)\n\tor\n\t(ex: -mode 1 -flash -i input1.scr -pal input1.pal -mode2 0 -iin2 input2.scr -pal2 input2.pal -out test -dsk )")
+	flashFrames         = flag.Int("flashframes", 0, "Number of frames (2 to 4) of the flash with one screen and a palette by frame, the inks of the frames are mixed into blended colors.\n\t(ex: -mode 0 -flash -flashframes 3 -in input.png -out test -dsk)")
+	maxFlicker          = flag.Float64("maxflicker", 0.3, "Maximum luminance difference (0 to 1) between the inks of two following frames of the flash.")
 	picturePath2        = flag.String("in2", "", "Picture path of the second input file (flash mode)")
 	mode2               = flag.Int("mode2", -1, "Output mode to use :\n\t0 for mode0\n\t1 for mode1\n\t2 for mode2\n\tmode of the second input file (flash mode)")
 	palettePath2        = flag.String("pal2", "", "Apply the input palette to the second image (flash mode)")
@@ -104,6 +122,22 @@ var (
 	sna                 = flag.Bool("sna", false, "Copy files in a new CPC image Sna.")
 	spriteHard          = flag.Bool("spritehard", false, "Generate sprite hard for cpc plus.")
 	splitRasters        = flag.Bool("splitrasters", false, "Create Split rastered image. (Will produce Overscan output file and .SPL with split rasters file)")
+	mixedModeBands      = flag.String("bands", "", "Create a mixed mode screen with horizontal bands, each band has its own mode and palette (lines first-last:mode, ex: -bands 0-175:0,176-199:1). Will produce the screen, the .RST raster routine and the -MIXED.BAS loader.")
+	plusMaxColors       = flag.Bool("plusmax", false, "Convert the image for the cpc plus in mode 0 with pens changed in the 4096 colors at each line and the 16 hardware sprites on the parts with the biggest errors. Will produce the screen, the .SPR sprites, the .DMA list of the palette changes, the .RST routine and the -PLUS.BAS loader.")
+	plusChanges         = flag.Int("pluschanges", 3, "Maximum number of pens changed at each line (1 to 3) with the -plusmax option.")
+	dmaRaster           = flag.Bool("dma", false, "Create a cpc plus raster of the pen with a dma list, the mean color of each row of the input image is the color of a line (or use -dmakeys). Will produce the .DMA list, the .RST routine and the -DMA.BAS loader.\n\t(ex: -mode 0 -dma -dmapen 16 -in sky.png -out test -dsk)")
+	dmaPen              = flag.Int("dmapen", 16, "Pen (0 to 15, 16 for the border) changed by the dma raster.")
+	dmaLine             = flag.Int("dmaline", 0, "First line of the dma raster counted from the start of the vsync.")
+	dmaKeyframes        = flag.String("dmakeys", "", "Keyframes of the dma raster, line:RGB with the cpc plus colors, the colors are interpolated between the keyframes (ex: -dmakeys 40:000,150:F80,270:FF0).")
+	backgroundRaster    = flag.Bool("backraster", false, "Detect the vertical gradient of the background (sky, sunset) and display it with the ink 0 and the border changed at each line, the other inks are used by the foreground. Will produce the screen, the .RAS raster table, the .RST routine and the -RASTER.BAS loader.\n\t(ex: -mode 1 -backraster -in sunset.png -out test -dsk)")
+	preShift            = flag.Bool("preshift", false, "Generate the sprite moved of each pixel in the byte (2 variants in mode 0, 4 in mode 1) with an extra byte column. Will produce one .WIN file with all the variants and the .ASM file with the table of pointers indexed by x&1 or x&3.\n\t(ex: -mode 0 -width 16 -height 16 -preshift -preshiftmask -in hero.png -out test)")
+	preShiftMask        = flag.Bool("preshiftmask", false, "Add the mask of the pen 0 of each variant with the -preshift option.")
+	preShiftBank        = flag.Int("preshiftbank", 1, "Number of sprites side by side in the image with the -preshift option.")
+	transparent         = flag.Bool("transparent", false, "Map the transparent pixels (alpha) to the ink 0 reserved as transparent ink.")
+	transparentKey      = flag.String("transparentkey", "", "Key color (#RRGGBB) of the transparent pixels, implies the -transparent option.")
+	collision           = flag.String("collision", "", "Export the collision data of the sprite (mask, bounding box, hitboxes and outline), mask of 1 bit by pixel or by byte.")
+	hitboxes            = flag.String("hitboxes", "", "Hitboxes of the sprite x,y,width,height in pixels separated by ; (ex 2,0,12,16;4,4,8,8) with the -collision option.")
+	transparentMask     = flag.String("transparentmask", "", "Export the and/or mask tables of the sprite (.MSK), interleaved or separate.")
 	scanlineSequence    = flag.String("scanlinesequence", "", "Scanline sequence to apply on sprite. for instance : \n\tmartine -in myimage.jpg -width 4 -height 4 -scanlinesequence 0,2,1,3 \n\twill generate a sprite stored with lines order 0 2 1 and 3.\n")
 	maskSprite          = flag.String("mask", "", "Mask to apply on each bit of the sprite (to apply an and operation on each pixel with the value #AA [in hexdecimal: #AA or 0xAA, in decimal: 170] ex: martine -in myimage.png -width 40 -height 80 -mask #AA -mode 0 -maskand)")
 	maskOrOperation     = flag.Bool("maskor", false, "Will apply an OR operation on each byte with the mask")
@@ -120,8 +154,19 @@ var (
 	impCatcher          = flag.Bool("imp", false, "Will generate sprites as IMP-Catcher format (Impdraw V2).")
 	inkSwap             = flag.String("inkswap", "", "Swap ink:\n\tfor instance mode 4 (4 inks) : 0=3,1=0,2=1,3=2\n\twill swap in output image index 0 by 3 and 1 by 0 and so on.")
 	lineWidth           = flag.String("linewidth", "#50", "Line width in hexadecimal to compute the screen address in delta mode.")
+	crtc                = flag.String("crtc", "", "Screen geometry of the CRTC registers R1,R6,R9,R12,R13 (ex 48,34,7,#0D,#00) or standard, overscan, square, r9-3, replaces the fixed screen sizes and the -linewidth option.")
+	screenTable         = flag.Bool("screentable", false, "Export the table of the lines addresses and the next_line routine of the screen of the -crtc option (SCRTABLE.BIN and SCRTABLE.ASM).")
 	deltaPacking        = flag.Bool("deltapacking", false, "Will generate all the animation code from the followed gif file.")
 	deltaPacking2       = flag.Bool("deltapacking2", false, "Will generate all the animation code from the followed gif file (and optimize export).")
+	deltaPlayback       = flag.String("deltaplayback", "single", "Playback of the deltapacking animation : single (one screen buffer), double (screens #C000 and #4000 swapped at each frame), pingpong (frames forward then backward) or graph (frames order of the -deltagraph option).")
+	deltaGraph          = flag.String("deltagraph", "", "Frames order of the graph playback (ex: 0,1,2,1,0,3).")
+	deltaDouble         = flag.Bool("deltadouble", false, "Use the screens #C000 and #4000 with the pingpong and graph playbacks.")
+	deltaCost           = flag.Bool("deltacost", false, "Encode each span of changed bytes of the deltapacking animation as the cheapest of a bytes patch, a run copy, a zx0 block or a zx0 keyframe of the whole screen.")
+	deltaCostBytes      = flag.Float64("deltacostbytes", transformation.DefaultDeltaCost.Bytes, "Weight of the size in bytes of the chunks with the -deltacost option.")
+	deltaCostNops       = flag.Float64("deltacostnops", transformation.DefaultDeltaCost.Nops, "Weight of the duration in nops of the chunks with the -deltacost option.")
+	deltaMotif          = flag.Bool("deltamotif", false, "Encode the deltapacking animation with a dictionary of motifs learned over the frames and compare it with the delta packing V1 and V2.")
+	deltaMotifSize      = flag.Int("deltamotifsize", 0, "Maximum number of motifs of the dictionary with the -deltamotif option (0 for no limit).")
+	deltaCues           = flag.String("deltacues", "", "Cue points of the frames of the deltapacking animation (ex: 0:intro,12:drop), the player exposes them as cue_name for its jump_cue hook.")
 	filloutGif          = flag.Bool("fillout", false, "Fill out the gif frames needed some case with deltapacking")
 	saturationPal       = flag.Float64("contrast", 0., "apply contrast on the color of the palette on amstrad plus screen. (max value 100 and only on CPC PLUS).")
 	brightnessPal       = flag.Float64("brightness", 0., "apply brightness on the color of the palette on amstrad plus screen. (max value 100 and only on CPC PLUS).")
@@ -167,6 +212,13 @@ func main() {
 		app.Run()
 		os.Exit(0)
 	}
+	if len(flag.Args()) > 1 && flag.Args()[0] == "build" {
+		if err := BuildProject(flag.Args()[1]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error while building project (%s) error :%v\n", flag.Args()[1], err)
+			os.Exit(-1)
+		}
+		os.Exit(0)
+	}
 	if len(flag.Args()) > 0 {
 		firstArg := flag.Args()[0]
 		if firstArg[0] != '-' {
@@ -213,6 +265,32 @@ func main() {
 		os.Exit(0)
 	}
 
+	if *initProject != "" {
+		_, err := InitProject(*initProject)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error while creating (%s) project file error :%v\n", *initProject, err)
+			os.Exit(-1)
+		}
+		os.Exit(0)
+	}
+
+	if *initWatch != "" {
+		_, err := InitWatchProcess(*initWatch)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error while creating (%s) watch process file error :%v\n", *initWatch, err)
+			os.Exit(-1)
+		}
+		os.Exit(0)
+	}
+
+	if *watchFile != "" {
+		if err := Watch(*watchFile); err != nil {
+			fmt.Fprintf(os.Stderr, "Error while watching (%s) error :%v\n", *watchFile, err)
+			os.Exit(-1)
+		}
+		os.Exit(0)
+	}
+
 	if *processFile != "" {
 		proc, err := LoadProcessFile(*processFile)
 		if err != nil {
@@ -245,6 +323,22 @@ func main() {
 		os.Exit(0)
 	}
 
+	if *screenTable {
+		g := address.DefaultGeometry
+		if *crtc != "" {
+			var err error
+			if g, err = address.ParseGeometry(*crtc); err != nil {
+				fmt.Fprintf(os.Stderr, "Cannot parse crtc option with error [%s]\n", err)
+				os.Exit(-1)
+			}
+		}
+		if err := exportScreenTable(g, *output); err != nil {
+			fmt.Fprintf(os.Stderr, "Error while exporting the screen table error :%v\n", err)
+			os.Exit(-1)
+		}
+		os.Exit(0)
+	}
+
 	// picture path to convert
 	if *picturePath == "" && !*deltaMode {
 		fmt.Fprintf(os.Stderr, "No picture to compute (option -picturepath or -delta)\n")
@@ -287,7 +381,31 @@ func main() {
 		if *deltaPacking2 {
 			exportVersion = animate.DeltaExportV2
 		}
-		if err := animate.DeltaPacking(cfg.InputPath, cfg, screenAddress, screenMode, exportVersion); err != nil {
+		topology, err := animate.ParseDeltaTopology(*deltaPlayback)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error while parsing the delta playback (%s) error: %v\n", *deltaPlayback, err)
+			os.Exit(-1)
+		}
+		if *deltaMotif {
+			if err := animate.DeltaMotif(cfg.InputPath, cfg, *deltaMotifSize, screenAddress, screenMode); err != nil {
+				fmt.Fprintf(os.Stderr, "Error while deltapacking error: %v\n", err)
+			}
+			os.Exit(0)
+		}
+		if *deltaCost {
+			cost := transformation.DeltaCost{Bytes: *deltaCostBytes, Nops: *deltaCostNops}
+			if err := animate.ChunkPackingGifPlayback(cfg.InputPath, cfg, screenAddress, screenMode, topology, *deltaGraph, *deltaDouble, cost); err != nil {
+				fmt.Fprintf(os.Stderr, "Error while deltapacking error: %v\n", err)
+			}
+			os.Exit(0)
+		}
+		if topology != animate.SingleBuffer || *deltaDouble {
+			if err := animate.DeltaPackingGifPlayback(cfg.InputPath, cfg, screenAddress, screenMode, topology, *deltaGraph, *deltaDouble); err != nil {
+				fmt.Fprintf(os.Stderr, "Error while deltapacking error: %v\n", err)
+			}
+			os.Exit(0)
+		}
+		if err := animate.DeltaPacking(cfg.InputPath, cfg, screenAddress, screenMode, exportVersion, *deltaCues); err != nil {
 			fmt.Fprintf(os.Stderr, "Error while deltapacking error: %v\n", err)
 		}
 		os.Exit(0)
@@ -371,11 +489,20 @@ func main() {
 			cfg.DitheringMatrix = filter.Bayer8
 			cfg.DitheringType = constants.OrderedDither
 			fmt.Fprintf(os.Stdout, "Dither:Bayer8, Type:OrderedDither\n")
+		case 11:
+			cfg.DitheringMatrix = filter.BlueNoise16
+			cfg.DitheringType = constants.OrderedDither
+			fmt.Fprintf(os.Stdout, "Dither:BlueNoise16, Type:OrderedDither\n")
+		case 12:
+			cfg.DitheringMatrix = filter.VoidAndCluster16
+			cfg.DitheringType = constants.OrderedDither
+			fmt.Fprintf(os.Stdout, "Dither:VoidAndCluster16, Type:OrderedDither\n")
 		default:
 			fmt.Fprintf(os.Stderr, "Dithering matrix not available.")
 			os.Exit(-1)
 		}
 	}
+	cfg.DitheringPattern = *ditheringPattern
 	if *impCatcher {
 		if !cfg.CustomDimension {
 			fmt.Fprintf(os.Stderr, "You must set custom width and height.")
@@ -597,7 +724,32 @@ func main() {
 									os.Exit(-1)
 								}
 							} else {
-								if cfg.SplitRaster {
+								if cfg.MixedModeBands != "" {
+									bands, err := mixedmode.ParseBands(cfg.MixedModeBands)
+									if err != nil {
+										fmt.Fprintf(os.Stderr, "Error while parsing the bands (%s) :%v\n", cfg.MixedModeBands, err)
+										os.Exit(-1)
+									}
+									if err := effect.DoMixedMode(in, bands, filename, cfg); err != nil {
+										fmt.Fprintf(os.Stderr, "Error while applying mixed mode on one image :%v\n", err)
+										os.Exit(-1)
+									}
+								} else if cfg.BackgroundRaster {
+									if err := effect.DoBackgroundRaster(in, filename, screenMode, cfg); err != nil {
+										fmt.Fprintf(os.Stderr, "Error while applying background raster on one image :%v\n", err)
+										os.Exit(-1)
+									}
+								} else if cfg.DmaRaster {
+									if err := effect.DoDmaRaster(in, filename, screenMode, cfg); err != nil {
+										fmt.Fprintf(os.Stderr, "Error while creating the dma raster :%v\n", err)
+										os.Exit(-1)
+									}
+								} else if cfg.PlusMaxColors {
+									if err := effect.DoPlusMaxColors(in, filename, cfg); err != nil {
+										fmt.Fprintf(os.Stderr, "Error while applying plus max colors on one image :%v\n", err)
+										os.Exit(-1)
+									}
+								} else if cfg.SplitRaster {
 									if cfg.Overscan {
 										if err := effect.DoSpliteRaster(in, screenMode, filename, cfg); err != nil {
 											fmt.Fprintf(os.Stderr, "Error while applying splitraster on one image :%v\n", err)
@@ -33,7 +33,7 @@ func TestMainBit(t *testing.T) {
 }
 
 func TestNormalScreenMode0(t *testing.T) {
-	args := []string{"run", "main.go", "process.go", "export_handler.go", "-in", "../samples/Batman-Neal-Adams.jpg", "-mode", "0", "-out", "../test"}
+	args := []string{"run", ".", "-in", "../samples/Batman-Neal-Adams.jpg", "-mode", "0", "-out", "../test"}
 	cmd := exec.Command("go", args...)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
@@ -44,7 +44,7 @@ func TestNormalScreenMode0(t *testing.T) {
 }
 
 func TestNormalScreenMode1(t *testing.T) {
-	args := []string{"run", "main.go", "process.go", "export_handler.go", "-in", "../samples/Batman-Neal-Adams.jpg", "-mode", "1", "-out", "../test"}
+	args := []string{"run", ".", "-in", "../samples/Batman-Neal-Adams.jpg", "-mode", "1", "-out", "../test"}
 	cmd := exec.Command("go", args...)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
@@ -55,7 +55,7 @@ func TestNormalScreenMode1(t *testing.T) {
 }
 
 func TestNormalScreenMode1Dsk(t *testing.T) {
-	args := []string{"run", "main.go", "process.go", "export_handler.go", "-in", "../samples/Batman-Neal-Adams.jpg", "-mode", "1", "-out", "../test", "-dsk"}
+	args := []string{"run", ".", "-in", "../samples/Batman-Neal-Adams.jpg", "-mode", "1", "-out", "../test", "-dsk"}
 	cmd := exec.Command("go", args...)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
@@ -66,7 +66,7 @@ func TestNormalScreenMode1Dsk(t *testing.T) {
 }
 
 func TestNormalScreenMode2(t *testing.T) {
-	args := []string{"run", "main.go", "process.go", "export_handler.go", "-in", "../samples/Batman-Neal-Adams.jpg", "-mode", "2", "-out", "../test"}
+	args := []string{"run", ".", "-in", "../samples/Batman-Neal-Adams.jpg", "-mode", "2", "-out", "../test"}
 	cmd := exec.Command("go", args...)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
@@ -77,7 +77,7 @@ func TestNormalScreenMode2(t *testing.T) {
 }
 
 func TestFullScreenMode0(t *testing.T) {
-	args := []string{"run", "main.go", "process.go", "export_handler.go", "-in", "../samples/Batman-Neal-Adams.jpg", "-mode", "0", "-fullscreen", "-out", "../test"}
+	args := []string{"run", ".", "-in", "../samples/Batman-Neal-Adams.jpg", "-mode", "0", "-fullscreen", "-out", "../test"}
 	cmd := exec.Command("go", args...)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
@@ -88,7 +88,7 @@ func TestFullScreenMode0(t *testing.T) {
 }
 
 func TestFullScreenMode1(t *testing.T) {
-	args := []string{"run", "main.go", "process.go", "export_handler.go", "-in", "../samples/Batman-Neal-Adams.jpg", "-mode", "1", "-fullscreen", "-out", "../test"}
+	args := []string{"run", ".", "-in", "../samples/Batman-Neal-Adams.jpg", "-mode", "1", "-fullscreen", "-out", "../test"}
 	cmd := exec.Command("go", args...)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
@@ -99,7 +99,7 @@ func TestFullScreenMode1(t *testing.T) {
 }
 
 func TestFullScreenMode2(t *testing.T) {
-	args := []string{"run", "main.go", "process.go", "export_handler.go", "-in", "../samples/Batman-Neal-Adams.jpg", "-mode", "2", "-fullscreen", "-out", "../test"}
+	args := []string{"run", ".", "-in", "../samples/Batman-Neal-Adams.jpg", "-mode", "2", "-fullscreen", "-out", "../test"}
 	cmd := exec.Command("go", args...)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
@@ -110,7 +110,7 @@ func TestFullScreenMode2(t *testing.T) {
 }
 
 func TestFullScreenPlusMode0(t *testing.T) {
-	args := []string{"run", "main.go", "process.go", "export_handler.go", "-in", "../samples/Batman-Neal-Adams.jpg", "-mode", "0", "-fullscreen", "-plus", "-out", "../test"}
+	args := []string{"run", ".", "-in", "../samples/Batman-Neal-Adams.jpg", "-mode", "0", "-fullscreen", "-plus", "-out", "../test"}
 	cmd := exec.Command("go", args...)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
@@ -121,7 +121,7 @@ func TestFullScreenPlusMode0(t *testing.T) {
 }
 
 func TestFullScreenPlusMode1(t *testing.T) {
-	args := []string{"run", "main.go", "process.go", "export_handler.go", "-in", "../samples/Batman-Neal-Adams.jpg", "-mode", "1", "-fullscreen", "-plus", "-out", "../test"}
+	args := []string{"run", ".", "-in", "../samples/Batman-Neal-Adams.jpg", "-mode", "1", "-fullscreen", "-plus", "-out", "../test"}
 	cmd := exec.Command("go", args...)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
@@ -132,7 +132,7 @@ func TestFullScreenPlusMode1(t *testing.T) {
 }
 
 func TestFullScreenPlusMode2(t *testing.T) {
-	args := []string{"run", "main.go", "process.go", "export_handler.go", "-in", "../samples/Batman-Neal-Adams.jpg", "-mode", "2", "-fullscreen", "-plus", "-out", "../test"}
+	args := []string{"run", ".", "-in", "../samples/Batman-Neal-Adams.jpg", "-mode", "2", "-fullscreen", "-plus", "-out", "../test"}
 	cmd := exec.Command("go", args...)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
@@ -143,7 +143,7 @@ func TestFullScreenPlusMode2(t *testing.T) {
 }
 
 func TestSpriteMode0(t *testing.T) {
-	args := []string{"run", "main.go", "process.go", "export_handler.go", "-in", "../samples/rotate.png", "-mode", "0", "-width", "16", "-height", "16", "-out", "../test"}
+	args := []string{"run", ".", "-in", "../samples/rotate.png", "-mode", "0", "-width", "16", "-height", "16", "-out", "../test"}
 	cmd := exec.Command("go", args...)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
@@ -154,7 +154,7 @@ func TestSpriteMode0(t *testing.T) {
 }
 
 func TestSpriteMode1(t *testing.T) {
-	args := []string{"run", "main.go", "process.go", "export_handler.go", "-in", "../samples/rotate.png", "-mode", "1", "-width", "16", "-height", "16", "-out", "../test"}
+	args := []string{"run", ".", "-in", "../samples/rotate.png", "-mode", "1", "-width", "16", "-height", "16", "-out", "../test"}
 	cmd := exec.Command("go", args...)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
@@ -165,7 +165,7 @@ func TestSpriteMode1(t *testing.T) {
 }
 
 func TestSpriteMode2(t *testing.T) {
-	args := []string{"run", "main.go", "process.go", "export_handler.go", "-in", "../samples/rotate.png", "-mode", "2", "-width", "16", "-height", "16", "-out", "../test"}
+	args := []string{"run", ".", "-in", "../samples/rotate.png", "-mode", "2", "-width", "16", "-height", "16", "-out", "../test"}
 	cmd := exec.Command("go", args...)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
@@ -176,7 +176,7 @@ func TestSpriteMode2(t *testing.T) {
 }
 
 func TestSpritePlusMode0(t *testing.T) {
-	args := []string{"run", "main.go", "process.go", "export_handler.go", "-in", "../samples/rotate.png", "-mode", "0", "-width", "16", "-height", "16", "-plus", "-out", "../test"}
+	args := []string{"run", ".", "-in", "../samples/rotate.png", "-mode", "0", "-width", "16", "-height", "16", "-plus", "-out", "../test"}
 	cmd := exec.Command("go", args...)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
@@ -187,7 +187,7 @@ func TestSpritePlusMode0(t *testing.T) {
 }
 
 func TestSpritePlusMode1(t *testing.T) {
-	args := []string{"run", "main.go", "process.go", "export_handler.go", "-in", "../samples/rotate.png", "-mode", "1", "-width", "16", "-height", "16", "-plus", "-out", "../test"}
+	args := []string{"run", ".", "-in", "../samples/rotate.png", "-mode", "1", "-width", "16", "-height", "16", "-plus", "-out", "../test"}
 	cmd := exec.Command("go", args...)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
@@ -198,7 +198,7 @@ func TestSpritePlusMode1(t *testing.T) {
 }
 
 func TestSpritePlusMode2(t *testing.T) {
-	args := []string{"run", "main.go", "process.go", "export_handler.go", "-in", "../samples/rotate.png", "-mode", "2", "-width", "16", "-height", "16", "-plus", "-out", "../test"}
+	args := []string{"run", ".", "-in", "../samples/rotate.png", "-mode", "2", "-width", "16", "-height", "16", "-plus", "-out", "../test"}
 	cmd := exec.Command("go", args...)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
@@ -209,7 +209,7 @@ func TestSpritePlusMode2(t *testing.T) {
 }
 
 func TestRollRraMode0(t *testing.T) {
-	args := []string{"run", "main.go", "process.go", "export_handler.go", "-in", "../samples/rotate.png", "-mode", "0", "-width", "16", "-height", "16", "-roll", "-iter", "16", "-rra", "1", "-out", "../test"}
+	args := []string{"run", ".", "-in", "../samples/rotate.png", "-mode", "0", "-width", "16", "-height", "16", "-roll", "-iter", "16", "-rra", "1", "-out", "../test"}
 	cmd := exec.Command("go", args...)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
@@ -220,7 +220,7 @@ func TestRollRraMode0(t *testing.T) {
 }
 
 func TestRollRraMode1(t *testing.T) {
-	args := []string{"run", "main.go", "process.go", "export_handler.go", "-in", "../samples/rotate.png", "-mode", "1", "-width", "16", "-height", "16", "-roll", "-iter", "16", "-rra", "1", "-out", "../test"}
+	args := []string{"run", ".", "-in", "../samples/rotate.png", "-mode", "1", "-width", "16", "-height", "16", "-roll", "-iter", "16", "-rra", "1", "-out", "../test"}
 	cmd := exec.Command("go", args...)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
@@ -231,7 +231,7 @@ func TestRollRraMode1(t *testing.T) {
 }
 
 func TestRollRraMode2(t *testing.T) {
-	args := []string{"run", "main.go", "process.go", "export_handler.go", "-in", "../samples/rotate.png", "-mode", "2", "-width", "16", "-height", "16", "-roll", "-iter", "16", "-rra", "1", "-out", "../test"}
+	args := []string{"run", ".", "-in", "../samples/rotate.png", "-mode", "2", "-width", "16", "-height", "16", "-roll", "-iter", "16", "-rra", "1", "-out", "../test"}
 	cmd := exec.Command("go", args...)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
@@ -242,7 +242,7 @@ func TestRollRraMode2(t *testing.T) {
 }
 
 func TestRollRLaMode0(t *testing.T) {
-	args := []string{"run", "main.go", "process.go", "export_handler.go", "-in", "../samples/rotate.png", "-mode", "0", "-width", "16", "-height", "16", "-roll", "-iter", "16", "-rla", "1", "-out", "../test"}
+	args := []string{"run", ".", "-in", "../samples/rotate.png", "-mode", "0", "-width", "16", "-height", "16", "-roll", "-iter", "16", "-rla", "1", "-out", "../test"}
 	cmd := exec.Command("go", args...)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
@@ -253,7 +253,7 @@ func TestRollRLaMode0(t *testing.T) {
 }
 
 func TestRollRLaMode1(t *testing.T) {
-	args := []string{"run", "main.go", "process.go", "export_handler.go", "-in", "../samples/rotate.png", "-mode", "1", "-width", "16", "-height", "16", "-roll", "-iter", "16", "-rla", "1", "-out", "../test"}
+	args := []string{"run", ".", "-in", "../samples/rotate.png", "-mode", "1", "-width", "16", "-height", "16", "-roll", "-iter", "16", "-rla", "1", "-out", "../test"}
 	cmd := exec.Command("go", args...)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
@@ -264,7 +264,7 @@ func TestRollRLaMode1(t *testing.T) {
 }
 
 func TestRollRLaMode2(t *testing.T) {
-	args := []string{"run", "main.go", "process.go", "export_handler.go", "-in", "../samples/rotate.png", "-mode", "2", "-width", "16", "-height", "16", "-roll", "-iter", "16", "-rla", "1", "-out", "../test"}
+	args := []string{"run", ".", "-in", "../samples/rotate.png", "-mode", "2", "-width", "16", "-height", "16", "-roll", "-iter", "16", "-rla", "1", "-out", "../test"}
 	cmd := exec.Command("go", args...)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
@@ -275,7 +275,7 @@ func TestRollRLaMode2(t *testing.T) {
 }
 
 func TestRollKeephighMode0(t *testing.T) {
-	args := []string{"run", "main.go", "process.go", "export_handler.go", "-in", "../samples/rotate.png", "-mode", "0", "-width", "16", "-height", "16", "-roll", "-iter", "16", "-keephigh", "1", "-out", "../test"}
+	args := []string{"run", ".", "-in", "../samples/rotate.png", "-mode", "0", "-width", "16", "-height", "16", "-roll", "-iter", "16", "-keephigh", "1", "-out", "../test"}
 	cmd := exec.Command("go", args...)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
@@ -286,7 +286,7 @@ func TestRollKeephighMode0(t *testing.T) {
 }
 
 func TestRollKeephighMode1(t *testing.T) {
-	args := []string{"run", "main.go", "process.go", "export_handler.go", "-in", "../samples/rotate.png", "-mode", "1", "-width", "16", "-height", "16", "-roll", "-iter", "16", "-keephigh", "1", "-out", "../test"}
+	args := []string{"run", ".", "-in", "../samples/rotate.png", "-mode", "1", "-width", "16", "-height", "16", "-roll", "-iter", "16", "-keephigh", "1", "-out", "../test"}
 	cmd := exec.Command("go", args...)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
@@ -297,7 +297,7 @@ func TestRollKeephighMode1(t *testing.T) {
 }
 
 func TestRollKeephighMode2(t *testing.T) {
-	args := []string{"run", "main.go", "process.go", "export_handler.go", "-in", "../samples/rotate.png", "-mode", "2", "-width", "16", "-height", "16", "-roll", "-iter", "16", "-keephigh", "1", "-out", "../test"}
+	args := []string{"run", ".", "-in", "../samples/rotate.png", "-mode", "2", "-width", "16", "-height", "16", "-roll", "-iter", "16", "-keephigh", "1", "-out", "../test"}
 	cmd := exec.Command("go", args...)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
@@ -308,7 +308,7 @@ func TestRollKeephighMode2(t *testing.T) {
 }
 
 func TestRollKeeplowMode0(t *testing.T) {
-	args := []string{"run", "main.go", "process.go", "export_handler.go", "-in", "../samples/rotate.png", "-mode", "0", "-width", "16", "-height", "16", "-roll", "-iter", "16", "-keeplow", "1", "-out", "../test"}
+	args := []string{"run", ".", "-in", "../samples/rotate.png", "-mode", "0", "-width", "16", "-height", "16", "-roll", "-iter", "16", "-keeplow", "1", "-out", "../test"}
 	cmd := exec.Command("go", args...)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
@@ -319,7 +319,7 @@ func TestRollKeeplowMode0(t *testing.T) {
 }
 
 func TestRollKeeplowMode1(t *testing.T) {
-	args := []string{"run", "main.go", "process.go", "export_handler.go", "-in", "../samples/rotate.png", "-mode", "1", "-width", "16", "-height", "16", "-roll", "-iter", "16", "-keeplow", "1", "-out", "../test"}
+	args := []string{"run", ".", "-in", "../samples/rotate.png", "-mode", "1", "-width", "16", "-height", "16", "-roll", "-iter", "16", "-keeplow", "1", "-out", "../test"}
 	cmd := exec.Command("go", args...)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
@@ -330,7 +330,31 @@ func TestRollKeeplowMode1(t *testing.T) {
 }
 
 func TestRollKeeplowMode2(t *testing.T) {
-	args := []string{"run", "main.go", "process.go", "export_handler.go", "-in", "../samples/rotate.png", "-mode", "2", "-width", "16", "-height", "16", "-roll", "-iter", "16", "-keeplow", "1", "-out", "../test"}
+	args := []string{"run", ".", "-in", "../samples/rotate.png", "-mode", "2", "-width", "16", "-height", "16", "-roll", "-iter", "16", "-keeplow", "1", "-out", "../test"}
+	cmd := exec.Command("go", args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	err := cmd.Run()
+	if err != nil {
+		t.Fatalf("Expected no error and gets :%v", err)
+	}
+}
+
+func TestBuildProject(t *testing.T) {
+	project := `{
+	"name": "batman",
+	"outputPath": "../test/project",
+	"memoryMap": true,
+	"assets": [
+		{ "name": "title", "kind": "screen", "picturePath": "../samples/Batman-Neal-Adams.jpg", "mode": 0 },
+		{ "name": "hero", "kind": "sprite", "picturePath": "../samples/rotate.png", "mode": 0, "width": 16, "height": 16, "usePalette": "title" }
+	],
+	"disks": [ { "name": "batman", "assets": [ "title", "hero" ] } ]
+}`
+	if err := os.WriteFile("../test/project.json", []byte(project), 0644); err != nil {
+		t.Fatal(err)
+	}
+	args := []string{"run", ".", "build", "../test/project.json"}
 	cmd := exec.Command("go", args...)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
@@ -48,21 +48,59 @@ type Process struct {
 	Rotate3dType        int      `json:"rotate3dType"`
 	Rotate3dX0          int      `json:"rotate3dX0"`
 	Rotate3dY0          int      `json:"rotate3dY0"`
+	Rotate3dAxis        string   `json:"rotate3dAxis"`
+	Rotate3dDistance    float64  `json:"rotate3dDistance"`
+	Rotate3dBilinear    bool     `json:"rotate3dBilinear"`
+	Rotate3dKeys        string   `json:"rotate3dKeys"`
 	Data                []int    `json:"data"`
 	Palette             []int    `json:"palette"`
 	Delta               bool     `json:"delta"`
 	DitheringAlgo       int      `json:"ditheringAlgo"`
 	DitheringMultiplier float64  `json:"ditheringMultiplier"`
 	WithQuantization    bool     `json:"withQuantization"`
+	DitheringPattern    int      `json:"ditheringPattern"`
+	PaletteAware        bool     `json:"paletteAware"`
+	Serpentine          bool     `json:"serpentine"`
+	StableDithering     bool     `json:"stableDithering"`
+	StableThreshold     int      `json:"stableThreshold"`
+	ErrorClamp          float64  `json:"errorClamp"`
+	ChannelWeights      string   `json:"channelWeights"`
+	MixedModeBands      string   `json:"mixedModeBands"`
+	PlusMaxColors       bool     `json:"plusMaxColors"`
+	PlusChanges         int      `json:"plusChanges"`
+	DmaRaster           bool     `json:"dmaRaster"`
+	DmaPen              int      `json:"dmaPen"`
+	DmaLine             int      `json:"dmaLine"`
+	DmaKeyframes        string   `json:"dmaKeyframes"`
+	BackgroundRaster    bool     `json:"backgroundRaster"`
+	PreShift            bool     `json:"preShift"`
+	PreShiftMask        bool     `json:"preShiftMask"`
+	PreShiftBank        int      `json:"preShiftBank"`
+	Transparent         bool     `json:"transparent"`
+	TransparentKey      string   `json:"transparentKey"`
+	TransparentMask     string   `json:"transparentMask"`
+	Collision           string   `json:"collision"`
+	Hitboxes            string   `json:"hitboxes"`
+	Crtc                string   `json:"crtc"`
 	ExtendedDsk         bool     `json:"extendedDsk"`
 	Reverse             bool     `json:"reverse"`
 	Flash               bool     `json:"flash"`
+	FlashFrames         int      `json:"flashFrames"`
+	MaxFlicker          float64  `json:"maxFlicker"`
 	PicturePath2        string   `json:"picturePath2"`
 	Mode2               int      `json:"mode2"`
 	PalettePath2        string   `json:"palettePath2"`
 	Egx1                bool     `json:"egx1"`
 	Egx2                bool     `json:"egx2"`
 	DeltaFile           []string `json:"df"`
+	SpriteHard          bool     `json:"spriteHard"`
+	TileMap             bool     `json:"tileMap"`
+	DeltaPacking        bool     `json:"deltaPacking"`
+	Animate             bool     `json:"animate"`
+	Json                bool     `json:"json"`
+	Txt                 bool     `json:"txt"`
+	ZigZag              bool     `json:"zigzag"`
+	InitialAddress      string   `json:"address"`
 }
 
 func NewProcess() *Process {
@@ -89,6 +127,8 @@ func NewProcess() *Process {
 		DitheringAlgo:       -1,
 		DitheringMultiplier: 1.18,
 		WithQuantization:    false,
+		StableThreshold:     8,
+		MaxFlicker:          0.3,
 		ExtendedDsk:         false,
 		Reverse:             false,
 		Flash:               false,
@@ -100,6 +140,7 @@ func NewProcess() *Process {
 		Data:                make([]int, 0),
 		Palette:             make([]int, 0),
 		DeltaFile:           make([]string, 0),
+		InitialAddress:      "0xC000",
 	}
 }
 
@@ -164,18 +205,58 @@ func (p *Process) Apply() {
 	*rotate3dType = p.Rotate3dType
 	*rotate3dX0 = p.Rotate3dX0
 	*rotate3dY0 = p.Rotate3dY0
+	*rotate3dAxis = p.Rotate3dAxis
+	*rotate3dDistance = p.Rotate3dDistance
+	*rotate3dBilinear = p.Rotate3dBilinear
+	*rotate3dKeys = p.Rotate3dKeys
 	*deltaMode = p.Delta
 	*ditheringAlgo = p.DitheringAlgo
 	*ditheringMultiplier = p.DitheringMultiplier
 	*withQuantization = p.WithQuantization
+	*ditheringPattern = p.DitheringPattern
+	*paletteAware = p.PaletteAware
+	*serpentine = p.Serpentine
+	*stableDithering = p.StableDithering
+	*stableThreshold = p.StableThreshold
+	*errorClamp = p.ErrorClamp
+	*channelWeights = p.ChannelWeights
+	*mixedModeBands = p.MixedModeBands
+	*plusMaxColors = p.PlusMaxColors
+	*plusChanges = p.PlusChanges
+	*dmaRaster = p.DmaRaster
+	*dmaPen = p.DmaPen
+	*dmaLine = p.DmaLine
+	*dmaKeyframes = p.DmaKeyframes
+	*backgroundRaster = p.BackgroundRaster
+	*preShift = p.PreShift
+	*preShiftMask = p.PreShiftMask
+	*preShiftBank = p.PreShiftBank
+	*transparent = p.Transparent
+	*transparentKey = p.TransparentKey
+	*transparentMask = p.TransparentMask
+	*collision = p.Collision
+	*hitboxes = p.Hitboxes
+	*crtc = p.Crtc
 	*extendedDsk = p.ExtendedDsk
 	*reverse = p.Reverse
 	*flash = p.Flash
+	*flashFrames = p.FlashFrames
+	*maxFlicker = p.MaxFlicker
 	*picturePath2 = p.PicturePath2
 	*mode2 = p.Mode2
 	*palettePath2 = p.PalettePath2
 	*egx1 = p.Egx1
 	*egx2 = p.Egx2
+	*spriteHard = p.SpriteHard
+	*tileMap = p.TileMap
+	*deltaPacking = p.DeltaPacking
+	*doAnimation = p.Animate
+	*jsonOutput = p.Json
+	*txtOutput = p.Txt
+	*zigzag = p.ZigZag
+	if p.InitialAddress != "" {
+		*initialAddress = p.InitialAddress
+	}
 	for i := 0; i < len(p.DeltaFile); i++ {
 		err := deltaFiles.Set(p.DeltaFile[i])
 		if err != nil {
@@ -0,0 +1,449 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/jeromelesaux/martine/common"
+	"github.com/jeromelesaux/martine/config"
+	"github.com/jeromelesaux/martine/export/diskimage"
+	"github.com/jeromelesaux/martine/export/memory"
+	"github.com/jeromelesaux/martine/export/snapshot"
+	"github.com/jeromelesaux/martine/proc"
+)
+
+var (
+	ErrorAssetNotFound       = errors.New("asset not found")
+	ErrorAssetKindNotFound   = errors.New("asset kind not found")
+	ErrorCyclicDependency    = errors.New("cyclic dependency between assets")
+	ErrorPaletteNotGenerated = errors.New("no palette generated by the asset")
+)
+
+// asset kinds available in a project file
+const (
+	ScreenAsset     = "screen"
+	SpriteAsset     = "sprite"
+	TilesAsset      = "tiles"
+	FontAsset       = "font"
+	TilemapAsset    = "tilemap"
+	AnimationAsset  = "animation"
+	SpriteHardAsset = "spritehard"
+)
+
+// Project describes all the graphical assets of a production, the palettes
+// they share and how they are grouped on the disks.
+// Relative paths are relative to the project file folder.
+type Project struct {
+	Name         string            `json:"name"`
+	Output       string            `json:"outputPath"`
+	Palettes     map[string]string `json:"palettes"`
+	Assets       []*ProjectAsset   `json:"assets"`
+	Disks        []*ProjectDisk    `json:"disks"`
+	MemoryMap    bool              `json:"memoryMap"`
+	Machine      int               `json:"machine"`
+	Screen       string            `json:"screen"`
+	SymbolFormat string            `json:"symbolFormat"`
+
+	projectPath string
+	baseFolder  string
+}
+
+// ProjectAsset is a named asset of the project, the embedded Process gives
+// the conversion options.
+// UsePalette is the name of a shared palette or the name of another asset
+// whose generated palette will be applied.
+// LoadAddress and Bank fix the place of the asset in the memory map (by
+// default the planner chooses), CodeSize reserves the memory of the player
+// code generated with the asset (animation).
+type ProjectAsset struct {
+	Process
+	Name        string   `json:"name"`
+	Kind        string   `json:"kind"`
+	UsePalette  string   `json:"usePalette"`
+	DependsOn   []string `json:"dependsOn"`
+	LoadAddress string   `json:"loadAddress"`
+	Bank        int      `json:"bank"`
+	CodeSize    int      `json:"codeSize"`
+
+	outputs []string
+}
+
+// ProjectDisk groups assets in a dsk (and a sna if needed).
+// If no asset is set, all the assets are copied in the dsk.
+type ProjectDisk struct {
+	Name     string   `json:"name"`
+	Assets   []string `json:"assets"`
+	Extended bool     `json:"extendedDsk"`
+	Sna      bool     `json:"generateSna"`
+}
+
+func NewProject() *Project {
+	return &Project{
+		Palettes:     make(map[string]string),
+		Assets:       make([]*ProjectAsset, 0),
+		Disks:        make([]*ProjectDisk, 0),
+		Machine:      int(memory.Cpc6128),
+		Screen:       ScreenC000,
+		SymbolFormat: string(memory.RasmSymbol),
+	}
+}
+
+func NewProjectAsset(name, kind string) *ProjectAsset {
+	return &ProjectAsset{
+		Process:   *NewProcess(),
+		Name:      name,
+		Kind:      kind,
+		DependsOn: make([]string, 0),
+		Bank:      memory.AnyBank,
+	}
+}
+
+// UnmarshalJSON keeps the default values of the process options not set in
+// the project file.
+func (a *ProjectAsset) UnmarshalJSON(b []byte) error {
+	type asset ProjectAsset
+	v := (*asset)(NewProjectAsset("", ""))
+	if err := json.Unmarshal(b, v); err != nil {
+		return err
+	}
+	*a = ProjectAsset(*v)
+	return nil
+}
+
+func InitProject(filePath string) (*Project, error) {
+	p := NewProject()
+	p.Name = strings.TrimSuffix(filepath.Base(filePath), filepath.Ext(filePath))
+	p.Output = "build"
+	p.MemoryMap = true
+	p.Palettes["game"] = "palettes/game.pal"
+	title := NewProjectAsset("title", ScreenAsset)
+	title.PicturePath = "images/title.png"
+	title.Mode = 0
+	title.Overscan = true
+	hero := NewProjectAsset("hero", SpriteAsset)
+	hero.PicturePath = "images/hero.png"
+	hero.Mode = 0
+	hero.Width = 16
+	hero.Height = 16
+	hero.UsePalette = "title"
+	p.Assets = append(p.Assets, title, hero)
+	p.Disks = append(p.Disks, &ProjectDisk{Name: p.Name, Assets: []string{"title", "hero"}})
+	f, err := os.Create(filePath)
+	if err != nil {
+		return p, err
+	}
+	defer f.Close()
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "\t")
+	err = enc.Encode(p)
+	return p, err
+}
+
+func LoadProjectFile(filePath string) (*Project, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	p := NewProject()
+	if err := json.NewDecoder(f).Decode(p); err != nil {
+		return nil, err
+	}
+	p.projectPath, err = filepath.Abs(filePath)
+	if err != nil {
+		return nil, err
+	}
+	p.baseFolder = filepath.Dir(p.projectPath)
+	if p.Output == "" {
+		p.Output = "build"
+	}
+	p.Output = p.resolve(p.Output)
+	return p, nil
+}
+
+func (p *Project) resolve(s string) string {
+	if s == "" || filepath.IsAbs(s) {
+		return s
+	}
+	return filepath.Join(p.baseFolder, s)
+}
+
+func (p *Project) Asset(name string) *ProjectAsset {
+	for _, v := range p.Assets {
+		if v.Name == name {
+			return v
+		}
+	}
+	return nil
+}
+
+// dependencies returns the assets needed before building the asset.
+func (p *Project) dependencies(a *ProjectAsset) ([]string, error) {
+	deps := make([]string, 0)
+	for _, v := range a.DependsOn {
+		if p.Asset(v) == nil {
+			return deps, fmt.Errorf("%w : (%s) needed by (%s)", ErrorAssetNotFound, v, a.Name)
+		}
+		deps = append(deps, v)
+	}
+	if a.UsePalette != "" {
+		if _, ok := p.Palettes[a.UsePalette]; !ok {
+			if p.Asset(a.UsePalette) == nil {
+				return deps, fmt.Errorf("%w : palette (%s) needed by (%s)", ErrorAssetNotFound, a.UsePalette, a.Name)
+			}
+			deps = append(deps, a.UsePalette)
+		}
+	}
+	return deps, nil
+}
+
+// Levels sorts the assets by dependency levels, the assets of a level only
+// depend on the assets of the previous levels.
+func (p *Project) Levels() ([][]*ProjectAsset, error) {
+	levels := make([][]*ProjectAsset, 0)
+	done := make(map[string]bool)
+	for len(done) < len(p.Assets) {
+		level := make([]*ProjectAsset, 0)
+		for _, a := range p.Assets {
+			if done[a.Name] {
+				continue
+			}
+			deps, err := p.dependencies(a)
+			if err != nil {
+				return levels, err
+			}
+			ready := true
+			for _, d := range deps {
+				if !done[d] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				level = append(level, a)
+			}
+		}
+		if len(level) == 0 {
+			return levels, ErrorCyclicDependency
+		}
+		for _, a := range level {
+			done[a.Name] = true
+		}
+		levels = append(levels, level)
+	}
+	return levels, nil
+}
+
+func (p *Project) check() error {
+	names := make(map[string]bool)
+	for _, a := range p.Assets {
+		if a.Name == "" || names[a.Name] {
+			return fmt.Errorf("asset name (%s) is empty or already used", a.Name)
+		}
+		names[a.Name] = true
+		switch a.Kind {
+		case "", ScreenAsset, SpriteAsset, TilesAsset, FontAsset, TilemapAsset, AnimationAsset, SpriteHardAsset:
+		default:
+			return fmt.Errorf("%w : (%s) for asset (%s)", ErrorAssetKindNotFound, a.Kind, a.Name)
+		}
+	}
+	for _, d := range p.Disks {
+		for _, v := range d.Assets {
+			if !names[v] {
+				return fmt.Errorf("%w : (%s) in disk (%s)", ErrorAssetNotFound, v, d.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// palette returns the palette file to apply on the asset.
+func (p *Project) palette(a *ProjectAsset) (string, error) {
+	if a.UsePalette == "" {
+		return "", nil
+	}
+	if v, ok := p.Palettes[a.UsePalette]; ok {
+		return p.resolve(v), nil
+	}
+	for _, v := range p.Asset(a.UsePalette).outputs {
+		switch filepath.Ext(v) {
+		case ".PAL", ".KIT", ".INK":
+			return v, nil
+		}
+	}
+	return "", fmt.Errorf("%w : (%s) needed by (%s)", ErrorPaletteNotGenerated, a.UsePalette, a.Name)
+}
+
+func (p *Project) assetFolder(a *ProjectAsset) string {
+	return filepath.Join(p.Output, a.Name)
+}
+
+// process returns the process to apply to convert the asset.
+func (p *Project) process(a *ProjectAsset) (*Process, error) {
+	pr := a.Process
+	pr.PicturePath = p.resolve(pr.PicturePath)
+	pr.PicturePath2 = p.resolve(pr.PicturePath2)
+	pr.PalettePath = p.resolve(pr.PalettePath)
+	pr.PalettePath2 = p.resolve(pr.PalettePath2)
+	pr.KitPath = p.resolve(pr.KitPath)
+	pr.InkPath = p.resolve(pr.InkPath)
+	pr.Output = p.assetFolder(a)
+	// disks are done once for all the assets
+	pr.Dsk = false
+	pr.M4Host = ""
+	palette, err := p.palette(a)
+	if err != nil {
+		return nil, err
+	}
+	switch strings.ToUpper(filepath.Ext(palette)) {
+	case "":
+	case ".KIT":
+		pr.KitPath = palette
+	case ".INK":
+		pr.InkPath = palette
+	default:
+		pr.PalettePath = palette
+	}
+	switch a.Kind {
+	case SpriteAsset:
+		if pr.Width == -1 && pr.Height == -1 {
+			return nil, fmt.Errorf("sprite (%s) needs a width or a height", a.Name)
+		}
+	case TilesAsset, FontAsset:
+		pr.TileMode = true
+	case TilemapAsset:
+		pr.TileMap = true
+	case AnimationAsset:
+		pr.DeltaPacking = true
+	case SpriteHardAsset:
+		pr.SpriteHard = true
+	}
+	return &pr, nil
+}
+
+func (p *Project) buildAsset(a *ProjectAsset, tmpFolder string) error {
+	pr, err := p.process(a)
+	if err != nil {
+		return err
+	}
+	// the asset folder only contains the files of the last build
+	if err := os.RemoveAll(pr.Output); err != nil {
+		return err
+	}
+	if err := common.CheckOutput(pr.Output); err != nil {
+		return err
+	}
+	wa := &WatchAsset{Path: pr.PicturePath, Process: pr}
+	a.outputs, err = wa.Build(tmpFolder)
+	return err
+}
+
+// Build converts all the assets level by level, the assets of a same level
+// are converted in parallel, then creates the disks and the memory map.
+func (p *Project) Build() error {
+	if err := p.check(); err != nil {
+		return err
+	}
+	levels, err := p.Levels()
+	if err != nil {
+		return err
+	}
+	if err := common.CheckOutput(p.Output); err != nil {
+		return err
+	}
+	tmpFolder, err := os.MkdirTemp("", "martine-project")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpFolder)
+
+	for i, level := range levels {
+		names := make([]string, len(level))
+		for j, a := range level {
+			names[j] = a.Name
+		}
+		fmt.Fprintf(os.Stdout, "Building level %d : %s\n", i, strings.Join(names, ", "))
+		var mu sync.Mutex
+		errs := make([]string, 0)
+		proc.Parallel(0, len(level), func(c <-chan int) {
+			for j := range c {
+				if err := p.buildAsset(level[j], tmpFolder); err != nil {
+					mu.Lock()
+					errs = append(errs, fmt.Sprintf("(%s) %v", level[j].Name, err))
+					mu.Unlock()
+				}
+			}
+		})
+		if len(errs) > 0 {
+			sort.Strings(errs)
+			return fmt.Errorf("error while building assets %s", strings.Join(errs, ", "))
+		}
+	}
+
+	// the addresses must be patched before copying the files in the disks
+	if p.MemoryMap {
+		if err := p.PlanMemory(); err != nil {
+			return err
+		}
+	}
+	for _, d := range p.Disks {
+		if err := p.buildDisk(d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *Project) buildDisk(d *ProjectDisk) error {
+	cfg := config.NewMartineConfig(filepath.Join(p.Output, d.Name), p.Output)
+	cfg.ExtendedDsk = d.Extended
+	names := d.Assets
+	if len(names) == 0 {
+		for _, a := range p.Assets {
+			names = append(names, a.Name)
+		}
+	}
+	for _, name := range names {
+		for _, v := range p.Asset(name).outputs {
+			if strings.EqualFold(filepath.Ext(v), ".asm") {
+				continue
+			}
+			cfg.AddFile(v)
+		}
+	}
+	if err := diskimage.ImportInDsk(cfg.InputPath, cfg); err != nil {
+		return err
+	}
+	if d.Sna {
+		for _, name := range names {
+			a := p.Asset(name)
+			for _, v := range a.outputs {
+				if filepath.Ext(v) == ".SCR" {
+					snaPath := filepath.Join(p.Output, d.Name+".sna")
+					if err := snapshot.ImportInSna(v, snaPath, uint8(a.Mode)); err != nil {
+						return err
+					}
+					fmt.Fprintf(os.Stdout, "Sna saved in file %s\n", snaPath)
+					return nil
+				}
+			}
+		}
+		fmt.Fprintf(os.Stderr, "No screen file found in disk (%s) to create the sna.\n", d.Name)
+	}
+	return nil
+}
+
+// BuildProject executes all the assets of the project file.
+func BuildProject(filePath string) error {
+	p, err := LoadProjectFile(filePath)
+	if err != nil {
+		return err
+	}
+	return p.Build()
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jeromelesaux/martine/common"
+	"github.com/jeromelesaux/martine/export/amsdos"
+	"github.com/jeromelesaux/martine/export/memory"
+)
+
+// screen areas available in a project file
+const (
+	ScreenC000     = "c000"
+	Screen4000     = "4000"
+	DoubleScreen   = "double"
+	OverscanScreen = "overscan"
+	NoScreen       = "none"
+)
+
+func (p *Project) screenArea() (memory.ScreenArea, error) {
+	switch strings.ToLower(p.Screen) {
+	case "", ScreenC000:
+		return memory.ScreenC000, nil
+	case Screen4000:
+		return memory.Screen4000, nil
+	case DoubleScreen:
+		return memory.DoubleScreen, nil
+	case OverscanScreen:
+		return memory.OverscanScreen, nil
+	case NoScreen:
+		return memory.NoScreen, nil
+	}
+	return memory.NoScreen, fmt.Errorf("screen (%s) not available, choose between %s, %s, %s, %s, %s",
+		p.Screen, ScreenC000, Screen4000, DoubleScreen, OverscanScreen, NoScreen)
+}
+
+// memoryFile is a data file of an asset to place in memory.
+type memoryFile struct {
+	path   string
+	asset  *ProjectAsset
+	item   *memory.Item
+	header bool
+}
+
+// dataFiles returns the files of the asset loaded by the cpc, the palettes,
+// the loaders and the overscan screens are loaded by martine loaders.
+func (a *ProjectAsset) dataFiles() []string {
+	files := make([]string, 0)
+	for _, v := range a.outputs {
+		switch filepath.Ext(v) {
+		case ".TXT", ".BAS", ".PAL", ".KIT", ".INK":
+			continue
+		case ".SCR":
+			if a.Overscan {
+				continue
+			}
+		}
+		if strings.EqualFold(filepath.Ext(v), ".asm") {
+			continue
+		}
+		files = append(files, v)
+	}
+	return files
+}
+
+func (p *Project) memoryItems() ([]*memoryFile, error) {
+	files := make([]*memoryFile, 0)
+	for _, a := range p.Assets {
+		kind := memory.DataItem
+		switch a.Kind {
+		case SpriteAsset, TilesAsset, FontAsset, SpriteHardAsset:
+			kind = memory.SpriteBank
+		}
+		address := -1
+		if a.LoadAddress != "" {
+			v, err := common.ParseHexadecimal16(a.LoadAddress)
+			if err != nil {
+				return files, fmt.Errorf("load address (%s) of asset (%s) error :%v", a.LoadAddress, a.Name, err)
+			}
+			address = int(v)
+		}
+		data := a.dataFiles()
+		for i, v := range data {
+			info, err := os.Stat(v)
+			if err != nil {
+				return files, err
+			}
+			size := int(info.Size())
+			_, err = memory.AmsdosHeader(v)
+			hasHeader := err == nil
+			if hasHeader {
+				size -= 128
+			}
+			name := a.Name
+			if len(data) > 1 {
+				name += "_" + strings.ToLower(strings.TrimPrefix(filepath.Ext(v), "."))
+			}
+			it := memory.NewItem(name, size, kind)
+			it.Bank = a.Bank
+			// only the first file can be fixed, the others follow
+			if i == 0 {
+				it.Address = address
+			}
+			files = append(files, &memoryFile{path: v, asset: a, item: it, header: hasHeader})
+		}
+		if a.CodeSize > 0 {
+			it := memory.NewItem(a.Name+"_code", a.CodeSize, memory.CodeItem)
+			it.Bank = memory.MainBank
+			if len(data) == 0 {
+				it.Address = address
+			}
+			files = append(files, &memoryFile{asset: a, item: it})
+		}
+	}
+	return files, nil
+}
+
+// PlanMemory places the data files of the assets in the memory, patches the
+// amsdos headers and the generated source code with the addresses and saves
+// the symbol file and the memory map.
+func (p *Project) PlanMemory() error {
+	screen, err := p.screenArea()
+	if err != nil {
+		return err
+	}
+	m := memory.NewMap(memory.Machine(p.Machine), screen)
+	files, err := p.memoryItems()
+	if err != nil {
+		return err
+	}
+	items := make([]*memory.Item, len(files))
+	for i, v := range files {
+		items[i] = v.item
+	}
+	if err := m.Place(items); err != nil {
+		return err
+	}
+
+	for _, f := range files {
+		pl := m.Placement(f.item.Name)
+		if f.path != "" && f.header {
+			header, err := memory.AmsdosHeader(f.path)
+			if err != nil {
+				return err
+			}
+			exec := header.Exec
+			if exec == header.Address {
+				exec = pl.Address
+			}
+			if err := memory.PatchAmsdosHeader(f.path, pl.Address, exec); err != nil {
+				return err
+			}
+		}
+		if f.item.Kind == memory.CodeItem {
+			for _, v := range f.asset.outputs {
+				if !strings.EqualFold(filepath.Ext(v), ".asm") {
+					continue
+				}
+				code, err := os.ReadFile(v)
+				if err != nil {
+					return err
+				}
+				patched := memory.PatchAsm(string(code), "loadingaddress", pl.Address)
+				if err := amsdos.SaveStringOSFile(v, patched); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	// the code of the other assets stays at its generated address
+	for _, a := range p.Assets {
+		if a.CodeSize > 0 {
+			continue
+		}
+		for _, v := range a.outputs {
+			if strings.EqualFold(filepath.Ext(v), ".asm") {
+				fmt.Fprintf(os.Stdout, "Source (%s) of asset (%s) not patched, set its code size to place it in memory\n", v, a.Name)
+			}
+		}
+	}
+
+	symbolPath := filepath.Join(p.Output, p.Name+".sym")
+	fmt.Fprintf(os.Stdout, "Saving symbols in file %s\n", symbolPath)
+	if err := amsdos.SaveStringOSFile(symbolPath, m.Symbols(memory.SymbolFormat(p.SymbolFormat))); err != nil {
+		return err
+	}
+	mapPath := filepath.Join(p.Output, p.Name+".map")
+	fmt.Fprintf(os.Stdout, "Saving memory map in file %s\n", mapPath)
+	return amsdos.SaveStringOSFile(mapPath, m.Report())
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/jeromelesaux/martine/convert/address"
+	"github.com/jeromelesaux/martine/export/amsdos"
+)
+
+// exportScreenTable saves the table of the lines addresses of the screen
+// (SCRTABLE.BIN) and its source code with the next_line routine
+// (SCRTABLE.ASM) in the folder.
+func exportScreenTable(g address.Geometry, folder string) error {
+	code, err := g.Asm()
+	if err != nil {
+		return err
+	}
+	binPath := filepath.Join(folder, "SCRTABLE.BIN")
+	if err := amsdos.SaveAmsdosFile(binPath, ".BIN", g.LineTableBytes(), 2, 0, 0, 0); err != nil {
+		return err
+	}
+	asmPath := filepath.Join(folder, "SCRTABLE.ASM")
+	if err := amsdos.SaveStringOSFile(asmPath, code); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stdout, "Screen of %d bytes x %d lines, table saved in (%s) and (%s)\n", g.LineWidth(), g.Lines(), binPath, asmPath)
+	return nil
+}
@@ -0,0 +1,486 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/jeromelesaux/martine/common"
+	"github.com/jeromelesaux/martine/config"
+	"github.com/jeromelesaux/martine/export/diskimage"
+	"github.com/jeromelesaux/martine/export/m4"
+	"github.com/jeromelesaux/martine/export/snapshot"
+)
+
+var ErrorNoAssetsToWatch = errors.New("no assets found to watch")
+
+const watchStateFilename = ".martine-watch.json"
+
+// WatchProcess is the folder level process file used by the watch mode.
+// The embedded Process gives the default options applied on every image
+// matching the globs, the overrides map gives per file options (the key is a
+// glob matched against the relative path or the filename of the image).
+// Relative paths are relative to the manifest folder, the outputs go by
+// default in its build sub folder.
+type WatchProcess struct {
+	Process
+	Globs     []string                   `json:"globs"`
+	Overrides map[string]json.RawMessage `json:"overrides"`
+	Sna       bool                       `json:"generateSna"`
+	Delay     int                        `json:"delay"`
+
+	manifestPath string
+	baseFolder   string
+}
+
+// WatchAsset is an image of the watch process with its effective options.
+type WatchAsset struct {
+	Path     string
+	Process  *Process
+	Palettes []string
+}
+
+type watchAssetState struct {
+	Hash    string   `json:"hash"`
+	Mode    int      `json:"mode"`
+	Outputs []string `json:"outputs"`
+}
+
+type watchState struct {
+	Assets map[string]*watchAssetState `json:"assets"`
+}
+
+func NewWatchProcess() *WatchProcess {
+	return &WatchProcess{
+		Process:   *NewProcess(),
+		Globs:     make([]string, 0),
+		Overrides: make(map[string]json.RawMessage),
+		Delay:     500,
+	}
+}
+
+func InitWatchProcess(filePath string) (*WatchProcess, error) {
+	w := NewWatchProcess()
+	w.Globs = append(w.Globs, "*.png")
+	f, err := os.Create(filePath)
+	if err != nil {
+		return w, err
+	}
+	defer f.Close()
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "\t")
+	err = enc.Encode(w)
+	return w, err
+}
+
+func LoadWatchProcessFile(filePath string) (*WatchProcess, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	w := NewWatchProcess()
+	if err := json.NewDecoder(f).Decode(w); err != nil {
+		return nil, err
+	}
+	w.manifestPath, err = filepath.Abs(filePath)
+	if err != nil {
+		return nil, err
+	}
+	w.baseFolder = filepath.Dir(w.manifestPath)
+	if w.Output == "" {
+		w.Output = "build"
+	}
+	w.Output = w.resolve(w.Output)
+	return w, nil
+}
+
+func (w *WatchProcess) resolve(p string) string {
+	if p == "" || filepath.IsAbs(p) {
+		return p
+	}
+	return filepath.Join(w.baseFolder, p)
+}
+
+// Assets returns all the images matching the globs with their own process
+// (the default process with the matching overrides applied).
+func (w *WatchProcess) Assets() ([]*WatchAsset, error) {
+	globs := make([]string, len(w.Globs))
+	for i, v := range w.Globs {
+		globs[i] = w.resolve(v)
+	}
+	files, err := common.WilcardedFiles(globs)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(w.Overrides))
+	for k := range w.Overrides {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	assets := make([]*WatchAsset, 0, len(files))
+	for _, file := range files {
+		// never convert our own png outputs
+		if filepath.Dir(file) == w.Output {
+			continue
+		}
+		p := w.Process
+		p.Data = nil
+		p.Palette = nil
+		p.DeltaFile = nil
+		rel, err := filepath.Rel(w.baseFolder, file)
+		if err != nil {
+			rel = file
+		}
+		for _, k := range keys {
+			matchRel, _ := filepath.Match(k, rel)
+			matchBase, _ := filepath.Match(k, filepath.Base(file))
+			if !matchRel && !matchBase {
+				continue
+			}
+			if err := json.Unmarshal(w.Overrides[k], &p); err != nil {
+				return nil, fmt.Errorf("override (%s) error :%v", k, err)
+			}
+		}
+		p.PicturePath = file
+		p.Output = w.Output
+		p.PalettePath = w.resolve(p.PalettePath)
+		p.PalettePath2 = w.resolve(p.PalettePath2)
+		p.KitPath = w.resolve(p.KitPath)
+		p.InkPath = w.resolve(p.InkPath)
+		// dsk, sna and m4 are done once for all the assets
+		p.Dsk = false
+		p.M4Host = ""
+		palettes := make([]string, 0)
+		for _, v := range []string{p.PalettePath, p.PalettePath2, p.KitPath, p.InkPath} {
+			if v != "" {
+				palettes = append(palettes, v)
+			}
+		}
+		assets = append(assets, &WatchAsset{Path: file, Process: &p, Palettes: palettes})
+	}
+	return assets, nil
+}
+
+// Hash computes the content hash of the asset (options, image and palettes).
+func (a *WatchAsset) Hash() (string, error) {
+	h := sha256.New()
+	if err := json.NewEncoder(h).Encode(a.Process); err != nil {
+		return "", err
+	}
+	for _, v := range append([]string{a.Path}, a.Palettes...) {
+		f, err := os.Open(v)
+		if err != nil {
+			return "", err
+		}
+		_, err = io.Copy(h, f)
+		f.Close()
+		if err != nil {
+			return "", err
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Build converts the asset in a martine subprocess and returns the files
+// written in the output folder. The conversion writes in its own staging
+// folder, so the outputs of each asset are known exactly.
+func (a *WatchAsset) Build(tmpFolder string) ([]string, error) {
+	staging, err := os.MkdirTemp(a.Process.Output, ".staging")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(staging)
+	p := *a.Process
+	p.Output = staging
+
+	f, err := os.CreateTemp(tmpFolder, "*.json")
+	if err != nil {
+		return nil, err
+	}
+	processPath := f.Name()
+	err = json.NewEncoder(f).Encode(&p)
+	f.Close()
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(processPath)
+
+	executable, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.Command(executable, "-processfile", processPath)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return nil, err
+	}
+	return moveOutputs(staging, a.Process.Output)
+}
+
+// moveOutputs moves the files of the staging folder in the output folder and
+// returns the amsdos files among them.
+func moveOutputs(staging, output string) ([]string, error) {
+	outputs := make([]string, 0)
+	entries, err := os.ReadDir(staging)
+	if err != nil {
+		return outputs, err
+	}
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		dest := filepath.Join(output, e.Name())
+		if err := os.Rename(filepath.Join(staging, e.Name()), dest); err != nil {
+			return outputs, err
+		}
+		switch strings.ToLower(filepath.Ext(e.Name())) {
+		case ".png", ".dsk", ".sna", ".json":
+			continue
+		}
+		outputs = append(outputs, dest)
+	}
+	return outputs, nil
+}
+
+func (w *WatchProcess) loadState() *watchState {
+	s := &watchState{Assets: make(map[string]*watchAssetState)}
+	f, err := os.Open(filepath.Join(w.Output, watchStateFilename))
+	if err != nil {
+		return s
+	}
+	defer f.Close()
+	if err := json.NewDecoder(f).Decode(s); err != nil {
+		fmt.Fprintf(os.Stderr, "Cannot read watch state, all assets will be rebuilt. error :%v\n", err)
+		s.Assets = make(map[string]*watchAssetState)
+	}
+	return s
+}
+
+func (w *WatchProcess) saveState(s *watchState) error {
+	f, err := os.Create(filepath.Join(w.Output, watchStateFilename))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return json.NewEncoder(f).Encode(s)
+}
+
+// Rebuild converts the assets which content changed since the last build,
+// then regenerates the dsk and sna and sends them to the M4 if needed.
+func (w *WatchProcess) Rebuild() error {
+	if err := common.CheckOutput(w.Output); err != nil {
+		return err
+	}
+	assets, err := w.Assets()
+	if err != nil {
+		return err
+	}
+	if len(assets) == 0 {
+		return ErrorNoAssetsToWatch
+	}
+	tmpFolder, err := os.MkdirTemp("", "martine-watch")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpFolder)
+
+	state := w.loadState()
+	rebuilt := 0
+	current := make(map[string]bool)
+	for _, a := range assets {
+		current[a.Path] = true
+		hash, err := a.Hash()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Cannot hash asset (%s) error :%v\n", a.Path, err)
+			continue
+		}
+		if s, ok := state.Assets[a.Path]; ok && s.Hash == hash {
+			continue
+		}
+		fmt.Fprintf(os.Stdout, "Rebuilding asset (%s)\n", a.Path)
+		outputs, err := a.Build(tmpFolder)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error while building asset (%s) error :%v\n", a.Path, err)
+			delete(state.Assets, a.Path)
+			continue
+		}
+		state.Assets[a.Path] = &watchAssetState{Hash: hash, Mode: a.Process.Mode, Outputs: outputs}
+		rebuilt++
+	}
+	for k := range state.Assets {
+		if !current[k] {
+			delete(state.Assets, k)
+			rebuilt++
+		}
+	}
+	if err := w.saveState(state); err != nil {
+		return err
+	}
+	if rebuilt == 0 {
+		fmt.Fprintf(os.Stdout, "Nothing changed.\n")
+		return nil
+	}
+	fmt.Fprintf(os.Stdout, "%d asset(s) rebuilt.\n", rebuilt)
+	return w.bundle(state)
+}
+
+func (w *WatchProcess) bundle(state *watchState) error {
+	if !w.Dsk && !w.Sna && w.M4Host == "" {
+		return nil
+	}
+	cfg := config.NewMartineConfig(w.manifestPath, w.Output)
+	cfg.ExtendedDsk = w.ExtendedDsk
+	cfg.Overscan = w.Overscan
+	cfg.Dsk = w.Dsk
+	cfg.Sna = w.Sna
+	cfg.M4Host = w.M4Host
+	cfg.M4RemotePath = w.M4RemotePath
+	cfg.M4Autoexec = w.M4Autoexec
+	cfg.M4 = w.M4Host != ""
+	paths := make([]string, 0, len(state.Assets))
+	for k := range state.Assets {
+		paths = append(paths, k)
+	}
+	sort.Strings(paths)
+	for _, k := range paths {
+		for _, v := range state.Assets[k].Outputs {
+			if !common.ContainsFilepath(cfg.DskFiles, v) {
+				cfg.AddFile(v)
+			}
+		}
+	}
+
+	if cfg.Dsk {
+		if err := diskimage.ImportInDsk(w.manifestPath, cfg); err != nil {
+			fmt.Fprintf(os.Stderr, "Cannot create or write into dsk file error :%v\n", err)
+		}
+	}
+	if cfg.Sna {
+		// the first screen of the assets with the mode of its asset
+		var gfxFile string
+		var mode int
+		for _, k := range paths {
+			for _, v := range state.Assets[k].Outputs {
+				if gfxFile == "" && filepath.Ext(v) == ".SCR" {
+					gfxFile = v
+					mode = state.Assets[k].Mode
+				}
+			}
+		}
+		if gfxFile == "" {
+			fmt.Fprintf(os.Stderr, "No screen file found to create the sna.\n")
+		} else {
+			cfg.SnaPath = filepath.Join(w.Output, "test.sna")
+			if err := snapshot.ImportInSna(gfxFile, cfg.SnaPath, uint8(mode)); err != nil {
+				fmt.Fprintf(os.Stderr, "Cannot create or write into sna file error :%v\n", err)
+			} else {
+				fmt.Fprintf(os.Stdout, "Sna saved in file %s\n", cfg.SnaPath)
+			}
+		}
+	}
+	if cfg.M4 {
+		if err := m4.ImportInM4(cfg); err != nil {
+			fmt.Fprintf(os.Stderr, "Cannot send to M4 error :%v\n", err)
+		}
+	}
+	return nil
+}
+
+// watchedFolders returns the folders containing the manifest, the images and
+// the palettes.
+func (w *WatchProcess) watchedFolders() []string {
+	folders := []string{w.baseFolder}
+	add := func(p string) {
+		d := filepath.Dir(p)
+		if !common.ContainsFilepath(folders, d) {
+			folders = append(folders, d)
+		}
+	}
+	for _, v := range w.Globs {
+		add(w.resolve(v))
+	}
+	if assets, err := w.Assets(); err == nil {
+		for _, a := range assets {
+			for _, p := range a.Palettes {
+				add(p)
+			}
+		}
+	}
+	return folders
+}
+
+// Watch rebuilds the assets of the manifest and then waits for the changes
+// on the images, the palettes and the manifest itself to rebuild them again.
+func Watch(manifestPath string) error {
+	w, err := LoadWatchProcessFile(manifestPath)
+	if err != nil {
+		return err
+	}
+	if err := w.Rebuild(); err != nil {
+		return err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+	watchFolders := func() {
+		for _, v := range w.watchedFolders() {
+			if err := watcher.Add(v); err != nil {
+				fmt.Fprintf(os.Stderr, "Cannot watch folder (%s) error :%v\n", v, err)
+			}
+		}
+	}
+	watchFolders()
+	fmt.Fprintf(os.Stdout, "Watching changes of (%s), press Ctrl+C to quit.\n", w.manifestPath)
+
+	// editors save several times in a row, wait for the end of the burst
+	var timer <-chan time.Time
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Remove|fsnotify.Rename) == 0 {
+				continue
+			}
+			// ignore our own outputs
+			if filepath.Dir(event.Name) == w.Output && event.Name != w.manifestPath {
+				continue
+			}
+			timer = time.After(time.Duration(w.Delay) * time.Millisecond)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			fmt.Fprintf(os.Stderr, "Watcher error :%v\n", err)
+		case <-timer:
+			timer = nil
+			reloaded, err := LoadWatchProcessFile(manifestPath)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Cannot reload (%s) error :%v, keeping the previous one.\n", manifestPath, err)
+			} else {
+				w = reloaded
+				watchFolders()
+			}
+			if err := w.Rebuild(); err != nil {
+				fmt.Fprintf(os.Stderr, "Error while rebuilding error :%v\n", err)
+			}
+		}
+	}
+}
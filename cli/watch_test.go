@@ -0,0 +1,111 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeWatchFiles(t *testing.T, folder string, files map[string]string) {
+	for k, v := range files {
+		p := filepath.Join(folder, k)
+		if err := os.MkdirAll(filepath.Dir(p), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(v), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestWatchAssets(t *testing.T) {
+	folder := t.TempDir()
+	writeWatchFiles(t, folder, map[string]string{
+		"watch.json":       `{"mode":0,"globs":["*.png","sprites/*.png"],"overrides":{"sprites/*.png":{"mode":1},"title.png":{"palettePath":"title.pal"}}}`,
+		"title.png":        "title",
+		"sprites/hero.png": "hero",
+	})
+	w, err := LoadWatchProcessFile(filepath.Join(folder, "watch.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assets, err := w.Assets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(assets) != 2 {
+		t.Fatalf("expected 2 assets and gets %d", len(assets))
+	}
+	for _, a := range assets {
+		switch filepath.Base(a.Path) {
+		case "title.png":
+			if a.Process.Mode != 0 || a.Process.PalettePath != filepath.Join(folder, "title.pal") || len(a.Palettes) != 1 {
+				t.Fatalf("unexpected title options mode %d palette (%s)", a.Process.Mode, a.Process.PalettePath)
+			}
+		case "hero.png":
+			if a.Process.Mode != 1 || a.Process.PalettePath != "" {
+				t.Fatalf("unexpected hero options mode %d palette (%s)", a.Process.Mode, a.Process.PalettePath)
+			}
+		default:
+			t.Fatalf("unexpected asset (%s)", a.Path)
+		}
+	}
+}
+
+func TestWatchUnchangedAssets(t *testing.T) {
+	folder := t.TempDir()
+	writeWatchFiles(t, folder, map[string]string{
+		"watch.json": `{"globs":["*.png"]}`,
+		"title.png":  "title",
+	})
+	w, err := LoadWatchProcessFile(filepath.Join(folder, "watch.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(w.Output, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	assets, err := w.Assets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash, err := assets[0].Hash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	outputs := []string{filepath.Join(w.Output, "TITLE.SCR")}
+	if err := w.saveState(&watchState{Assets: map[string]*watchAssetState{assets[0].Path: {Hash: hash, Outputs: outputs}}}); err != nil {
+		t.Fatal(err)
+	}
+	// a build would run the test executable which fails on -processfile
+	if err := w.Rebuild(); err != nil {
+		t.Fatal(err)
+	}
+	s := w.loadState().Assets[assets[0].Path]
+	if s == nil || s.Hash != hash || len(s.Outputs) != 1 {
+		t.Fatalf("expected the asset to be skipped and gets %v", s)
+	}
+}
+
+func TestMoveOutputs(t *testing.T) {
+	output := t.TempDir()
+	staging, err := os.MkdirTemp(output, ".staging")
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeWatchFiles(t, staging, map[string]string{
+		"TITLE.SCR":             "screen",
+		"TITLE.PAL":             "palette",
+		"title.png_resized.png": "preview",
+	})
+	outputs, err := moveOutputs(staging, output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(outputs) != 2 || outputs[0] != filepath.Join(output, "TITLE.PAL") || outputs[1] != filepath.Join(output, "TITLE.SCR") {
+		t.Fatalf("unexpected outputs %v", outputs)
+	}
+	if _, err := os.Stat(filepath.Join(output, "title.png_resized.png")); err != nil {
+		t.Fatalf("expected the preview in the output folder")
+	}
+}
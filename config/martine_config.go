@@ -3,6 +3,7 @@ package config
 import (
 	"errors"
 	"fmt"
+	"image/color"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -11,6 +12,7 @@ import (
 	"github.com/disintegration/imaging"
 	"github.com/jeromelesaux/martine/common"
 	"github.com/jeromelesaux/martine/constants"
+	"github.com/jeromelesaux/martine/convert/address"
 	"github.com/jeromelesaux/martine/export"
 	"github.com/jeromelesaux/martine/export/compression"
 )
@@ -21,7 +23,10 @@ var (
 	Egx2Mode = 2
 )
 
-var ErrorNotAllowed = errors.New("error not allowed")
+var (
+	ErrorNotAllowed   = errors.New("error not allowed")
+	ErrorOddLineWidth = errors.New("odd line width, the crtc counts 2 bytes by character")
+)
 
 type MartineConfig struct {
 	InputPath                   string
@@ -40,6 +45,10 @@ type MartineConfig struct {
 	Rotation3DX0                int
 	Rotation3DY0                int
 	Rotation3DType              int
+	Rotation3DAxis              string
+	Rotation3DDistance          float64
+	Rotation3DBilinear          bool
+	Rotation3DKeys              string
 	TileMode                    bool
 	RollMode                    bool
 	RollIteration               int
@@ -68,6 +77,13 @@ type MartineConfig struct {
 	DitheringMultiplier         float64
 	DitheringWithQuantification bool
 	DitheringType               constants.DitheringType
+	DitheringPattern            int
+	DitheringPaletteAware       bool
+	DitheringSerpentine         bool
+	DitheringErrorClamp         float64
+	DitheringWeights            [3]float64
+	StableDithering             bool
+	StableThreshold             int
 	RotationRraBit              int
 	RotationRlaBit              int
 	RotationSraBit              int
@@ -89,6 +105,26 @@ type MartineConfig struct {
 	SnaPath                     string
 	SpriteHard                  bool
 	SplitRaster                 bool
+	FlashFrames                 int
+	FlashMaxFlicker             float64
+	MixedModeBands              string
+	PlusMaxColors               bool
+	PlusChangesByLine           int
+	DmaRaster                   bool
+	DmaPen                      int
+	DmaLine                     int
+	DmaKeyframes                string
+	BackgroundRaster            bool
+	PreShift                    bool
+	PreShiftMask                bool
+	PreShiftBank                int
+	Transparency                bool
+	TransparentKey              color.Color
+	TransparentMask             bool
+	TransparentInterleaved      bool
+	Collision                   bool
+	CollisionPixelMask          bool
+	CollisionHitboxes           []export.Hitbox
 	ScanlineSequence            []int
 	CustomScanlineSequence      bool
 	MaskSprite                  uint8
@@ -101,6 +137,8 @@ type MartineConfig struct {
 	OneRow                      bool
 	InkSwapper                  map[int]int
 	LineWidth                   int
+	Geometry                    address.Geometry
+	CustomGeometry              bool
 	FilloutGif                  bool
 	Saturation                  float64
 	Brightness                  float64
@@ -147,6 +185,7 @@ func NewMartineConfig(input, output string) *MartineConfig {
 		Tiles:          export.NewJsonSlice(),
 		InkSwapper:     make(map[int]int),
 		LineWidth:      0x50,
+		Geometry:       address.DefaultGeometry,
 	}
 }
 
@@ -285,11 +324,34 @@ func (e *MartineConfig) OsFullPath(filePath string, newExtension string) string
 	return filepath.Join(e.OutputPath, newFilename)
 }
 
+// SetLineWith sets the width in bytes of the lines of the screen geometry,
+// the width must be even as R1 counts characters of 2 bytes.
 func (e *MartineConfig) SetLineWith(i string) error {
 	v, err := common.ParseHexadecimal8(i)
 	if err != nil {
 		return err
 	}
+	if v%2 != 0 {
+		return ErrorOddLineWidth
+	}
+	g := e.Geometry
+	g.R1 = v / 2
+	if err := g.Validate(); err != nil {
+		return err
+	}
 	e.LineWidth = int(v)
+	e.Geometry = g
+	return nil
+}
+
+// SetGeometry sets the screen geometry used by the screens, the sprite and
+// delta addresses in place of the fixed sizes.
+func (e *MartineConfig) SetGeometry(g address.Geometry) error {
+	if err := g.Validate(); err != nil {
+		return err
+	}
+	e.Geometry = g
+	e.CustomGeometry = true
+	e.LineWidth = g.LineWidth()
 	return nil
 }
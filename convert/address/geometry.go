@@ -0,0 +1,272 @@
+package address
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jeromelesaux/martine/constants"
+	"github.com/jeromelesaux/martine/gfx/errors"
+)
+
+// Geometry is the screen defined by the CRTC registers : R1 the number of
+// characters (2 bytes) by line, R6 the number of displayed character lines,
+// R9 the number of scanlines minus one of a character line and R12/R13 the
+// start address of the screen.
+type Geometry struct {
+	R1  uint8 `json:"r1"`
+	R6  uint8 `json:"r6"`
+	R9  uint8 `json:"r9"`
+	R12 uint8 `json:"r12"`
+	R13 uint8 `json:"r13"`
+}
+
+// DefaultGeometry is the screen of the firmware at #C000.
+var DefaultGeometry = Geometry{R1: 40, R6: 25, R9: 7, R12: 0x30, R13: 0}
+
+// Geometries are the usual screens : the firmware one, the overscan of 96
+// bytes x 272 lines at #0200, the square screen of 64 bytes x 256 lines and
+// the screen of 80 bytes x 200 lines of 4 lines characters in the pages #8000
+// and #C000.
+var Geometries = map[string]Geometry{
+	"standard": DefaultGeometry,
+	"overscan": {R1: 48, R6: 34, R9: 7, R12: 0x0D, R13: 0},
+	"square":   {R1: 32, R6: 32, R9: 7, R12: 0x30, R13: 0},
+	"r9-3":     {R1: 40, R6: 50, R9: 3, R12: 0x2C, R13: 0},
+}
+
+// ParseGeometry returns the geometry of the registers R1,R6,R9,R12,R13 (ex
+// 48,34,7,#0D,#00) or of the name of one of the Geometries, the values are
+// decimal or hexadecimal (#, & or 0x).
+func ParseGeometry(registers string) (Geometry, error) {
+	if g, ok := Geometries[strings.ToLower(strings.TrimSpace(registers))]; ok {
+		return g, nil
+	}
+	var g Geometry
+	values := strings.Split(registers, ",")
+	if len(values) != 5 {
+		return g, errors.ErrorBadGeometry
+	}
+	r := make([]uint8, len(values))
+	for i, v := range values {
+		v = strings.TrimSpace(v)
+		base := 10
+		for _, prefix := range []string{"#", "&", "0x", "0X"} {
+			if strings.HasPrefix(v, prefix) {
+				v = strings.TrimPrefix(v, prefix)
+				base = 16
+				break
+			}
+		}
+		n, err := strconv.ParseUint(v, base, 8)
+		if err != nil {
+			return g, errors.ErrorBadGeometry
+		}
+		r[i] = uint8(n)
+	}
+	g = Geometry{R1: r[0], R6: r[1], R9: r[2], R12: r[3], R13: r[4]}
+	return g, g.Validate()
+}
+
+// Validate checks the registers used by the screen addressing.
+func (g Geometry) Validate() error {
+	if g.R1 == 0 || g.R1 > 127 || g.R6 == 0 || g.R6 > 127 || g.R9 > 7 || g.R12 > 0x3F {
+		return errors.ErrorBadGeometry
+	}
+	return nil
+}
+
+// LineWidth returns the number of bytes of a line.
+func (g Geometry) LineWidth() int {
+	return int(g.R1) * 2
+}
+
+// CharLines returns the number of scanlines of a character line.
+func (g Geometry) CharLines() int {
+	return int(g.R9) + 1
+}
+
+// Lines returns the number of displayed lines.
+func (g Geometry) Lines() int {
+	return int(g.R6) * g.CharLines()
+}
+
+// Overscan returns true if the screen uses two pages of 16K (bits 2 and 3 of
+// R12 set), the end of the first one goes on with the next one.
+func (g Geometry) Overscan() bool {
+	return g.R12&0x0C == 0x0C
+}
+
+// MemorySize returns the size of the memory of the screen from its page.
+func (g Geometry) MemorySize() int {
+	if g.Overscan() {
+		return 0x8000
+	}
+	return 0x4000
+}
+
+// PixelsByByte returns the number of pixels of a byte in the mode.
+func PixelsByByte(mode uint8) int {
+	switch mode {
+	case 0:
+		return 2
+	case 1:
+		return 4
+	}
+	return 8
+}
+
+// Size returns the size in pixels of the screen in the mode.
+func (g Geometry) Size(mode uint8) constants.Size {
+	s := constants.NewSize(mode)
+	s.Width = g.LineWidth() * PixelsByByte(mode)
+	s.Height = g.Lines()
+	s.LinesNumber = g.Lines()
+	s.ColumnsNumber = s.Width / 8
+	return s
+}
+
+// Page returns the address of the page of the start of the screen.
+func (g Geometry) Page() int {
+	return int(g.R12>>4&3) * 0x4000
+}
+
+// start returns the memory address (MA) of the start of the screen.
+func (g Geometry) start() int {
+	return int(g.R12&0x3F)<<8 | int(g.R13)
+}
+
+// Address returns the address of the byte x of the line y as the CRTC does :
+// MA0-MA9 give the bits 1 to 10, RA0-RA2 the bits 11 to 13 and MA12-MA13 the
+// bits 14 and 15.
+func (g Geometry) Address(x, y int) int {
+	ma := (g.start() + (y/g.CharLines())*int(g.R1) + x/2) & 0x3FFF
+	ra := y % g.CharLines()
+	return ((ma&0x3000)<<2 | (ra&7)<<11 | (ma&0x3FF)<<1 | x&1) & 0xFFFF
+}
+
+// Offset returns the offset of the byte x of the line y from the page of the
+// start of the screen.
+func (g Geometry) Offset(x, y int) int {
+	offset := g.Address(x, y) - g.Page()
+	if offset < 0 {
+		offset += 0x10000
+	}
+	return offset
+}
+
+// PixelOffset returns the offset from the page of the screen of the byte of
+// the pixel x of the line y in the mode, -1 if the pixel is off the screen.
+func (g Geometry) PixelOffset(x, y int, mode uint8) int {
+	column := x / PixelsByByte(mode)
+	if x < 0 || y < 0 || column >= g.LineWidth() || y >= g.Lines() {
+		return -1
+	}
+	return g.Offset(column, y)
+}
+
+// LineTable returns the address of each line of the screen.
+func (g Geometry) LineTable() []uint16 {
+	table := make([]uint16, g.Lines())
+	for y := range table {
+		table[y] = uint16(g.Address(0, y))
+	}
+	return table
+}
+
+// LineTableBytes returns the addresses of the lines in little endian.
+func (g Geometry) LineTableBytes() []byte {
+	table := g.LineTable()
+	b := make([]byte, len(table)*2)
+	for i, v := range table {
+		binary.LittleEndian.PutUint16(b[i*2:], v)
+	}
+	return b
+}
+
+// wraps returns true if the start of a character line goes past the end of
+// the 2K block of the previous one.
+func (g Geometry) wraps() bool {
+	for r := 1; r < int(g.R6); r++ {
+		if (g.start()+r*int(g.R1))&0x3FF < (g.start()+(r-1)*int(g.R1))&0x3FF {
+			return true
+		}
+	}
+	return false
+}
+
+// NextLineRoutine returns the routine next_line computing in hl the address of
+// the line following the address hl, R9 must be 0, 1, 3 or 7.
+func (g Geometry) NextLineRoutine() (string, error) {
+	n := g.CharLines()
+	if n&(n-1) != 0 {
+		return "", errors.ErrorBadGeometry
+	}
+	code := "; next_line : hl = address of a line, returns the address of the next line in hl\n"
+	code += "next_line\n"
+	// back removes the scanlines of the character line at its end
+	back := 0
+	if n > 1 {
+		code += fmt.Sprintf("\tld a,h\n\tadd a,#08\n\tld h,a\n\tand #%.2X\n\tret nz\n", (n-1)*8)
+		back = (0x100 - n*8) & 0xFF
+	}
+	code += fmt.Sprintf("\tld a,l\n\tadd a,#%.2X\n\tld l,a\n\tld a,h\n\tadc a,#%.2X\n\tld h,a\n", g.LineWidth(), back)
+	if g.wraps() {
+		code += "\tbit 3,h ; end of the 2K block\n\tret z\n\tres 3,h\n"
+		if g.Overscan() {
+			code += "\tld a,h ; next page\n\tadd a,#40\n\tld h,a\n"
+		}
+	}
+	code += "\tret\n"
+	return code, nil
+}
+
+// Asm returns the source code of the table of the lines addresses and the
+// next_line routine.
+func (g Geometry) Asm() (string, error) {
+	routine, err := g.NextLineRoutine()
+	if err != nil {
+		return "", err
+	}
+	code := fmt.Sprintf("; screen R1=%d R6=%d R9=%d R12=#%.2X R13=#%.2X, %d bytes x %d lines\n", g.R1, g.R6, g.R9, g.R12, g.R13, g.LineWidth(), g.Lines())
+	code += fmt.Sprintf("screen_linewidth equ %d\nscreen_lines equ %d\n", g.LineWidth(), g.Lines())
+	code += "screen_table\n"
+	table := g.LineTable()
+	for i := 0; i < len(table); i += 8 {
+		values := make([]string, 0, 8)
+		for _, v := range table[i:min(i+8, len(table))] {
+			values = append(values, fmt.Sprintf("#%.4X", v))
+		}
+		code += "\tdw " + strings.Join(values, ", ") + "\n"
+	}
+	return code + routine, nil
+}
+
+// CrtcAsm returns the source code setting the registers of the screen, the
+// total (R4, R5) keeps a frame of 312 lines and the syncs (R2, R7) center the
+// screen.
+func (g Geometry) CrtcAsm() string {
+	lines := 312
+	r4 := lines/g.CharLines() - 1
+	r5 := lines % g.CharLines()
+	r2 := 46 + (int(g.R1)-40)/2
+	r7 := (240 + (g.Lines()-200)/2 + g.CharLines()/2) / g.CharLines()
+	registers := [][2]int{
+		{1, int(g.R1)}, {2, r2}, {4, r4}, {5, r5}, {6, int(g.R6)}, {7, r7}, {9, int(g.R9)}, {12, int(g.R12)}, {13, int(g.R13)},
+	}
+	code := fmt.Sprintf("; crtc R1=%d R6=%d R9=%d R12=#%.2X R13=#%.2X\n", g.R1, g.R6, g.R9, g.R12, g.R13)
+	code += "crtc_set\n"
+	for _, r := range registers {
+		code += fmt.Sprintf("\tld bc,#BC%.2X\n\tout (c),c\n\tld bc,#BD%.2X\n\tout (c),c\n", r[0], r[1])
+	}
+	code += "\tret\n"
+	return code
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,117 @@
+package address
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/jeromelesaux/martine/constants"
+)
+
+// runNextLine interprets the instructions of the next_line routine.
+func runNextLine(t *testing.T, routine string, hl uint16) uint16 {
+	var a, h, l byte = 0, byte(hl >> 8), byte(hl)
+	var carry, zero bool
+	value := func(s string) byte {
+		v, err := strconv.ParseUint(strings.TrimPrefix(s, "#"), 16, 8)
+		if err != nil {
+			t.Fatalf("bad value %s", s)
+		}
+		return byte(v)
+	}
+	for _, line := range strings.Split(routine, "\n") {
+		if !strings.HasPrefix(line, "\t") {
+			continue
+		}
+		ins := strings.TrimSpace(strings.Split(line, ";")[0])
+		switch {
+		case ins == "ld a,h":
+			a = h
+		case ins == "ld a,l":
+			a = l
+		case ins == "ld h,a":
+			h = a
+		case ins == "ld l,a":
+			l = a
+		case strings.HasPrefix(ins, "add a,"):
+			r := int(a) + int(value(ins[6:]))
+			a, carry = byte(r), r > 0xFF
+		case strings.HasPrefix(ins, "adc a,"):
+			r := int(a) + int(value(ins[6:]))
+			if carry {
+				r++
+			}
+			a, carry = byte(r), r > 0xFF
+		case strings.HasPrefix(ins, "and "):
+			a &= value(ins[4:])
+			zero = a == 0
+		case ins == "bit 3,h":
+			zero = h&0x08 == 0
+		case ins == "res 3,h":
+			h &^= 0x08
+		case ins == "ret nz":
+			if !zero {
+				return uint16(h)<<8 | uint16(l)
+			}
+		case ins == "ret z":
+			if zero {
+				return uint16(h)<<8 | uint16(l)
+			}
+		case ins == "ret":
+			return uint16(h)<<8 | uint16(l)
+		default:
+			t.Fatalf("unknown instruction %s", ins)
+		}
+	}
+	t.Fatal("no ret")
+	return 0
+}
+
+func TestGeometry(t *testing.T) {
+	g := DefaultGeometry
+	for y := 0; y < 200; y++ {
+		for x := 0; x < 80; x++ {
+			if g.Address(x, y) != 0xC000+0x800*(y%8)+0x50*(y/8)+x {
+				t.Fatalf("unexpected address #%.4X for %d,%d", g.Address(x, y), x, y)
+			}
+		}
+	}
+	if g.Size(0) != constants.Mode0 || g.Size(1) != constants.Mode1 || g.Size(2) != constants.Mode2 {
+		t.Fatalf("unexpected sizes %v", g.Size(1))
+	}
+	if !strings.Contains(g.CrtcAsm(), "ld bc,#BD1E") {
+		t.Fatalf("expected the vsync R7 30 in %s", g.CrtcAsm())
+	}
+	overscan, err := ParseGeometry("48,34,7,#0D,#00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !overscan.Overscan() || overscan.Address(0, 0) != 0x0200 || overscan.Address(0, 128) != 0x4000 || overscan.Lines() != 272 {
+		t.Fatalf("unexpected overscan #%.4X #%.4X", overscan.Address(0, 0), overscan.Address(0, 128))
+	}
+	if _, err := ParseGeometry("48,34,8,#0D,#00"); err == nil {
+		t.Fatal("expected an error for R9 8")
+	}
+	for _, registers := range []string{"40,25,7,#30,#00", "48,34,7,#0D,#00", "32,32,3,#30,#00", "64,32,7,#2C,#00", "46,33,1,#30,#40", "40,100,0,#30,#00"} {
+		g, err := ParseGeometry(registers)
+		if err != nil {
+			t.Fatal(err)
+		}
+		routine, err := g.NextLineRoutine()
+		if err != nil {
+			t.Fatal(err)
+		}
+		table := g.LineTable()
+		if len(g.LineTableBytes()) != len(table)*2 {
+			t.Fatalf("unexpected table size for %s", registers)
+		}
+		for y := 0; y < len(table)-1; y++ {
+			if next := runNextLine(t, routine, table[y]); next != table[y+1] {
+				t.Fatalf("%s line %d : next_line returns #%.4X instead of #%.4X", registers, y, next, table[y+1])
+			}
+		}
+	}
+	if _, err := (Geometry{R1: 40, R6: 25, R9: 5, R12: 0x30}).NextLineRoutine(); err == nil {
+		t.Fatal("expected an error for R9 5")
+	}
+}
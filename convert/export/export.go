@@ -34,7 +34,12 @@ func ToMode2AndExport(in *image.NRGBA, p color.Palette, size constants.Size, fil
 }
 
 func Export(filePath string, bw []byte, p color.Palette, screenMode uint8, cfg *config.MartineConfig) error {
-	if cfg.Overscan {
+	if cfg.CustomGeometry {
+		if err := ocpartstudio.ScrGeometry(filePath, bw, cfg); err != nil {
+			fmt.Fprintf(os.Stderr, "Error while saving file %s error :%v", filePath, err)
+			return err
+		}
+	} else if cfg.Overscan {
 		if cfg.EgxFormat == 0 {
 			if cfg.ExportAsGoFile {
 				data, err := co.ToGo(bw, screenMode, p, cfg)
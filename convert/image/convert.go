@@ -419,3 +419,23 @@ func SplitImage(i image.Image) (*image.NRGBA, *image.NRGBA, error) {
 	f2.Close()
 	return raw1, raw2, err
 }
+
+func Zigzag(in *image.NRGBA) *image.NRGBA {
+	zizagImg := image.NewNRGBA(image.Rectangle{
+		image.Point{X: 0, Y: 0},
+		image.Point{X: in.Bounds().Max.X, Y: in.Bounds().Max.Y}})
+	for y := 1; y < in.Bounds().Max.Y; y += 2 {
+		xZigZag := 0
+		for x := in.Bounds().Max.X - 1; x >= 0; x-- {
+			zizagImg.Set(xZigZag, y, in.At(x, y))
+			xZigZag++
+		}
+	}
+	for y := 0; y < in.Bounds().Max.Y; y += 2 {
+		for x := 0; x < in.Bounds().Max.X; x++ {
+			zizagImg.Set(x, y, in.At(x, y))
+		}
+	}
+	in = zizagImg
+	return in
+}
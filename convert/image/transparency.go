@@ -0,0 +1,131 @@
+package image
+
+import (
+	"image"
+	"image/color"
+	"strconv"
+	"strings"
+
+	"github.com/jeromelesaux/martine/constants"
+	"github.com/jeromelesaux/martine/gfx/errors"
+)
+
+// alphaThreshold is the alpha under which a pixel is transparent
+const alphaThreshold = 0x80
+
+// TransparentMask returns the transparent pixels [y][x] of the image : the
+// alpha under the threshold or the key color if set.
+func TransparentMask(in *image.NRGBA, key color.Color) [][]bool {
+	b := in.Bounds()
+	mask := make([][]bool, b.Dy())
+	for y := 0; y < b.Dy(); y++ {
+		mask[y] = make([]bool, b.Dx())
+		for x := 0; x < b.Dx(); x++ {
+			c := in.NRGBAAt(b.Min.X+x, b.Min.Y+y)
+			mask[y][x] = c.A < alphaThreshold || key != nil && sameColor(c, key)
+		}
+	}
+	return mask
+}
+
+// ParseKeyColor returns the key color #RRGGBB.
+func ParseKeyColor(key string) (color.Color, error) {
+	v := strings.TrimPrefix(strings.TrimSpace(key), "#")
+	if len(v) != 6 {
+		return nil, errors.ErrorBadColor
+	}
+	rgb, err := strconv.ParseUint(v, 16, 32)
+	if err != nil {
+		return nil, errors.ErrorBadColor
+	}
+	return color.NRGBA{R: uint8(rgb >> 16), G: uint8(rgb >> 8), B: uint8(rgb), A: 0xFF}, nil
+}
+
+func sameColor(c color.NRGBA, key color.Color) bool {
+	k := color.NRGBAModel.Convert(key).(color.NRGBA)
+	return c.R == k.R && c.G == k.G && c.B == k.B
+}
+
+// FillTransparent replaces the transparent pixels by the most used opaque
+// color, they do not take an ink of the palette while downgrading.
+func FillTransparent(in *image.NRGBA, mask [][]bool) {
+	b := in.Bounds()
+	usage := make(map[color.NRGBA]int)
+	var fill color.NRGBA
+	for y := range mask {
+		for x, transparent := range mask[y] {
+			if transparent {
+				continue
+			}
+			c := in.NRGBAAt(b.Min.X+x, b.Min.Y+y)
+			c.A = 0xFF
+			usage[c]++
+			if usage[c] > usage[fill] {
+				fill = c
+			}
+		}
+	}
+	if len(usage) == 0 {
+		return
+	}
+	for y := range mask {
+		for x, transparent := range mask[y] {
+			if transparent {
+				in.SetNRGBA(b.Min.X+x, b.Min.Y+y, fill)
+			}
+		}
+	}
+}
+
+// ApplyTransparency reserves the ink 0 of the palette for the transparent
+// pixels, the opaque pixels are downgraded with the other inks. If reserved
+// is set, the ink 0 of the palette is already the transparent ink, otherwise
+// the transparent ink is the key color or an unused color of the cpc.
+func ApplyTransparency(in *image.NRGBA, mask [][]bool, p color.Palette, colors int, reserved bool, key color.Color, isCpcPlus bool) (color.Palette, *image.NRGBA) {
+	var ink color.Color
+	opaque := color.Palette{}
+	if reserved && len(p) > 0 {
+		ink = p[0]
+		p = p[1:]
+	}
+	for _, c := range p {
+		if len(opaque) < colors-1 && !paletteContains(opaque, c) && c != ink {
+			opaque = append(opaque, c)
+		}
+	}
+	if ink == nil {
+		ink = transparentInk(opaque, key, isCpcPlus)
+	}
+	if len(opaque) > 0 {
+		in = downgradeWithPalette(in, opaque)
+	}
+	b := in.Bounds()
+	for y := range mask {
+		for x, transparent := range mask[y] {
+			if transparent {
+				in.Set(b.Min.X+x, b.Min.Y+y, ink)
+			}
+		}
+	}
+	return append(color.Palette{ink}, opaque...), in
+}
+
+// transparentInk returns the key color or the first color of the cpc unused
+// by the opaque inks.
+func transparentInk(opaque color.Palette, key color.Color, isCpcPlus bool) color.Color {
+	cpc := constants.CpcOldPalette
+	if isCpcPlus {
+		cpc = constants.CpcPlusPalette
+	}
+	if key != nil {
+		if c := cpc.Convert(key); !paletteContains(opaque, c) {
+			return c
+		}
+	}
+	for _, c := range cpc {
+		if !paletteContains(opaque, c) {
+			return c
+		}
+	}
+	return cpc[0]
+}
@@ -0,0 +1,40 @@
+package image
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/jeromelesaux/martine/constants"
+)
+
+func TestApplyTransparency(t *testing.T) {
+	in := image.NewNRGBA(image.Rect(0, 0, 4, 1))
+	in.Set(0, 0, color.NRGBA{A: 0})
+	in.Set(1, 0, color.NRGBA{R: 0xFF, G: 0, B: 0xFF, A: 0xFF})
+	in.Set(2, 0, constants.White.Color)
+	in.Set(3, 0, constants.Black.Color)
+	key, err := ParseKeyColor("#FF00FF")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mask := TransparentMask(in, key)
+	if !mask[0][0] || !mask[0][1] || mask[0][2] || mask[0][3] {
+		t.Fatalf("unexpected mask %v", mask)
+	}
+	FillTransparent(in, mask)
+	p := color.Palette{constants.White.Color, constants.Black.Color}
+	p, out := ApplyTransparency(in, mask, p, 4, false, key, false)
+	if len(p) != 3 {
+		t.Fatalf("expected 3 inks, got %d", len(p))
+	}
+	for x := 0; x < 4; x++ {
+		index := p.Index(out.At(x, 0))
+		if mask[0][x] != (index == 0) {
+			t.Fatalf("pixel %d mapped to ink %d", x, index)
+		}
+	}
+	if _, err := ParseKeyColor("#FF00"); err == nil {
+		t.Fatal("expected an error for a bad key color")
+	}
+}
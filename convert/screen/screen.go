@@ -14,8 +14,29 @@ import (
 	"github.com/jeromelesaux/martine/convert/pixel"
 	"github.com/jeromelesaux/martine/export/ocpartstudio"
 	"github.com/jeromelesaux/martine/export/png"
+	"github.com/jeromelesaux/martine/gfx/errors"
 )
 
+// screenMemorySize returns the size of the memory of the screen.
+func screenMemorySize(cfg *config.MartineConfig) int {
+	if cfg.CustomGeometry {
+		return cfg.Geometry.MemorySize()
+	}
+	if cfg.Overscan {
+		return 0x8000
+	}
+	return 0x4000
+}
+
+// screenAddress returns the offset of the pixel in the memory of the screen,
+// -1 if it is off the screen of the geometry.
+func screenAddress(x, y int, mode uint8, cfg *config.MartineConfig) int {
+	if cfg.CustomGeometry {
+		return cfg.Geometry.PixelOffset(x, y, mode)
+	}
+	return address.CpcScreenAddress(0, x, y, mode, cfg.Overscan, cfg.DoubleScreenAddress)
+}
+
 func ToMode2(in *image.NRGBA, p color.Palette, cfg *config.MartineConfig) []byte {
 	var bw []byte
 
@@ -25,11 +46,7 @@ func ToMode2(in *image.NRGBA, p color.Palette, cfg *config.MartineConfig) []byte
 		lineToAdd = 2
 	}
 
-	if cfg.Overscan {
-		bw = make([]byte, 0x8000)
-	} else {
-		bw = make([]byte, 0x4000)
-	}
+	bw = make([]byte, screenMemorySize(cfg))
 	firmwareColorUsed := make(map[int]int)
 	//fmt.Fprintf(os.Stdout, "Informations palette (%d) for image (%d,%d)\n", len(p), in.Bounds().Max.X, in.Bounds().Max.Y)
 	//fmt.Println(in.Bounds())
@@ -114,7 +131,10 @@ func ToMode2(in *image.NRGBA, p color.Palette, cfg *config.MartineConfig) []byte
 			// MACRO PIXM0 COL2,COL1
 			// ({COL1}&8)/8 | (({COL1}&4)*4) | (({COL1}&2)*2) | (({COL1}&1)*64) | (({COL2}&8)/4) | (({COL2}&4)*8) | (({COL2}&2)*4) | (({COL2}&1)*128)
 			//	MEND
-			addr := address.CpcScreenAddress(0, x, y, 2, cfg.Overscan, cfg.DoubleScreenAddress)
+			addr := screenAddress(x, y, 2, cfg)
+			if addr < 0 {
+				continue
+			}
 			bw[addr] = pixel
 		}
 
@@ -132,11 +152,7 @@ func ToMode1(in *image.NRGBA, p color.Palette, cfg *config.MartineConfig) []byte
 	if cfg.OneRow {
 		lineToAdd = 2
 	}
-	if cfg.Overscan {
-		bw = make([]byte, 0x8000)
-	} else {
-		bw = make([]byte, 0x4000)
-	}
+	bw = make([]byte, screenMemorySize(cfg))
 
 	firmwareColorUsed := make(map[int]int)
 	//fmt.Fprintf(os.Stdout, "Informations palette (%d) for image (%d,%d)\n", len(p), in.Bounds().Max.X, in.Bounds().Max.Y)
@@ -187,7 +203,10 @@ func ToMode1(in *image.NRGBA, p color.Palette, cfg *config.MartineConfig) []byte
 			// MACRO PIXM0 COL2,COL1
 			// ({COL1}&8)/8 | (({COL1}&4)*4) | (({COL1}&2)*2) | (({COL1}&1)*64) | (({COL2}&8)/4) | (({COL2}&4)*8) | (({COL2}&2)*4) | (({COL2}&1)*128)
 			//	MEND
-			addr := address.CpcScreenAddress(0, x, y, 1, cfg.Overscan, cfg.DoubleScreenAddress)
+			addr := screenAddress(x, y, 1, cfg)
+			if addr < 0 {
+				continue
+			}
 			bw[addr] = pixel
 		}
 	}
@@ -201,11 +220,7 @@ func ToMode0(in *image.NRGBA, p color.Palette, cfg *config.MartineConfig) []byte
 	if cfg.OneRow {
 		lineToAdd = 2
 	}
-	if cfg.Overscan {
-		bw = make([]byte, 0x8000)
-	} else {
-		bw = make([]byte, 0x4000)
-	}
+	bw = make([]byte, screenMemorySize(cfg))
 	firmwareColorUsed := make(map[int]int)
 	//fmt.Fprintf(os.Stdout, "Informations palette (%d) for image (%d,%d)\n", len(p), in.Bounds().Max.X, in.Bounds().Max.Y)
 	//fmt.Println(in.Bounds())
@@ -238,7 +253,10 @@ func ToMode0(in *image.NRGBA, p color.Palette, cfg *config.MartineConfig) []byte
 			// MACRO PIXM0 COL2,COL1
 			// ({COL1}&8)/8 | (({COL1}&4)*4) | (({COL1}&2)*2) | (({COL1}&1)*64) | (({COL2}&8)/4) | (({COL2}&4)*8) | (({COL2}&2)*4) | (({COL2}&1)*128)
 			//	MEND
-			addr := address.CpcScreenAddress(0, x, y, 0, cfg.Overscan, cfg.DoubleScreenAddress)
+			addr := screenAddress(x, y, 0, cfg)
+			if addr < 0 {
+				continue
+			}
 			bw[addr] = pixel
 		}
 	}
@@ -321,6 +339,41 @@ func ScrRawToImg(d []byte, mode uint8, p color.Palette) (*image.NRGBA, error) {
 	return out, nil
 }
 
+// GeometryRawToImg converts the memory of the screen of the geometry in image
+// using the mode and the palette.
+func GeometryRawToImg(d []byte, mode uint8, p color.Palette, g address.Geometry) (*image.NRGBA, error) {
+	if len(d) < g.MemorySize() {
+		return nil, errors.ErrorSizeMismatch
+	}
+	m := g.Size(mode)
+	out := image.NewNRGBA(image.Rectangle{
+		Min: image.Point{X: 0, Y: 0},
+		Max: image.Point{X: m.Width, Y: m.Height}})
+	for y := 0; y < m.Height; y++ {
+		for x := 0; x < g.LineWidth(); x++ {
+			val := d[g.Offset(x, y)]
+			var pens []int
+			switch mode {
+			case 0:
+				pp1, pp2 := pixel.RawPixelMode0(val)
+				pens = []int{pp1, pp2}
+			case 1:
+				pp1, pp2, pp3, pp4 := pixel.RawPixelMode1(val)
+				pens = []int{pp1, pp2, pp3, pp4}
+			default:
+				pp1, pp2, pp3, pp4, pp5, pp6, pp7, pp8 := pixel.RawPixelMode2(val)
+				pens = []int{pp1, pp2, pp3, pp4, pp5, pp6, pp7, pp8}
+			}
+			for i, pen := range pens {
+				if pen < len(p) {
+					out.Set(x*len(pens)+i, y, p[pen])
+				}
+			}
+		}
+	}
+	return out, nil
+}
+
 // SrcToImg load the amstrad classical 17ko  screen image to image.NRBGA
 // using the mode and palette as arguments
 func ScrToImg(scrPath string, mode uint8, p color.Palette) (*image.NRGBA, error) {
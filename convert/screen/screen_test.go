@@ -0,0 +1,47 @@
+package screen
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/jeromelesaux/martine/config"
+	"github.com/jeromelesaux/martine/constants"
+	"github.com/jeromelesaux/martine/convert/address"
+)
+
+func TestGeometryScreen(t *testing.T) {
+	p := color.Palette{constants.Black.Color, constants.White.Color, constants.BrightRed.Color, constants.BrightYellow.Color}
+	for _, registers := range []string{"square", "overscan", "r9-3", "23,22,7,#30,#00"} {
+		g, err := address.ParseGeometry(registers)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cfg := config.NewMartineConfig("", "")
+		if err := cfg.SetGeometry(g); err != nil {
+			t.Fatal(err)
+		}
+		size := g.Size(1)
+		in := image.NewNRGBA(image.Rect(0, 0, size.Width, size.Height))
+		for y := 0; y < size.Height; y++ {
+			for x := 0; x < size.Width; x++ {
+				in.Set(x, y, p[(x/3+y)%len(p)])
+			}
+		}
+		data := ToMode1(in, p, cfg)
+		if len(data) != g.MemorySize() {
+			t.Fatalf("%s : unexpected memory size #%.4X", registers, len(data))
+		}
+		out, err := GeometryRawToImg(data, 1, p, g)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for y := 0; y < size.Height; y++ {
+			for x := 0; x < size.Width; x++ {
+				if out.NRGBAAt(x, y) != in.NRGBAAt(x, y) {
+					t.Fatalf("%s : pixel %d,%d differs", registers, x, y)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package sprite
+
+import (
+	"sort"
+
+	"github.com/jeromelesaux/martine/convert/pixel"
+	"github.com/jeromelesaux/martine/export"
+)
+
+// opaquePixels returns the pixels [y][x] of the sprite data which are not the
+// pen 0.
+func opaquePixels(data []byte, lineSize int, mode uint8) [][]bool {
+	if lineSize == 0 {
+		return [][]bool{}
+	}
+	height := len(data) / lineSize
+	pixels := make([][]bool, height)
+	for y := 0; y < height; y++ {
+		pixels[y] = make([]bool, 0, lineSize*8)
+		for _, v := range data[y*lineSize : (y+1)*lineSize] {
+			var pens []int
+			switch mode {
+			case 0:
+				p1, p2 := pixel.RawPixelMode0(v)
+				pens = []int{p1, p2}
+			case 1:
+				p1, p2, p3, p4 := pixel.RawPixelMode1(v)
+				pens = []int{p1, p2, p3, p4}
+			default:
+				p1, p2, p3, p4, p5, p6, p7, p8 := pixel.RawPixelMode2(v)
+				pens = []int{p1, p2, p3, p4, p5, p6, p7, p8}
+			}
+			for _, pen := range pens {
+				pixels[y] = append(pixels[y], pen != 0)
+			}
+		}
+	}
+	return pixels
+}
+
+// NewCollision returns the collision data of the sprite (lineSize bytes by
+// line), the mask has 1 bit by pixel if pixelMask is set, otherwise 1 bit by
+// byte of the sprite.
+func NewCollision(data []byte, lineSize int, mode uint8, pixelMask bool, hitboxes []export.Hitbox) *export.Collision {
+	c := &export.Collision{PixelMask: pixelMask, Hitboxes: hitboxes}
+	if c.Hitboxes == nil {
+		c.Hitboxes = []export.Hitbox{}
+	}
+	pixels := opaquePixels(data, lineSize, mode)
+	width := lineSize
+	if pixelMask && len(pixels) > 0 {
+		width = len(pixels[0])
+	}
+	c.MaskLineSize = (width + 7) / 8
+	mask := make([]byte, c.MaskLineSize*len(pixels))
+	for y := range pixels {
+		for x := 0; x < width; x++ {
+			set := false
+			if pixelMask {
+				set = pixels[y][x]
+			} else {
+				set = data[y*lineSize+x] != 0
+			}
+			if set {
+				mask[y*c.MaskLineSize+x/8] |= 0x80 >> uint(x%8)
+			}
+		}
+	}
+	c.SetMask(mask)
+	c.Bounds, c.Outline = outline(pixels)
+	return c
+}
+
+// outline returns the bounding box and the convex hull (clockwise, y down) of
+// the corners of the non transparent pixels.
+func outline(pixels [][]bool) (export.Hitbox, []export.Point) {
+	points := make([]export.Point, 0)
+	minX, minY, maxX, maxY := -1, -1, -1, -1
+	for y := range pixels {
+		first, last := -1, -1
+		for x, v := range pixels[y] {
+			if v {
+				if first == -1 {
+					first = x
+				}
+				last = x
+			}
+		}
+		if first == -1 {
+			continue
+		}
+		if minY == -1 {
+			minX, minY, maxX = first, y, last
+		}
+		if first < minX {
+			minX = first
+		}
+		if last > maxX {
+			maxX = last
+		}
+		maxY = y
+		points = append(points,
+			export.Point{X: first, Y: y}, export.Point{X: last + 1, Y: y},
+			export.Point{X: first, Y: y + 1}, export.Point{X: last + 1, Y: y + 1})
+	}
+	if minY == -1 {
+		return export.Hitbox{}, []export.Point{}
+	}
+	bounds := export.Hitbox{X: minX, Y: minY, Width: maxX - minX + 1, Height: maxY - minY + 1}
+	return bounds, convexHull(points)
+}
+
+// convexHull returns the convex hull of the points (monotone chain) without
+// collinear vertices.
+func convexHull(points []export.Point) []export.Point {
+	sort.Slice(points, func(i, j int) bool {
+		if points[i].X != points[j].X {
+			return points[i].X < points[j].X
+		}
+		return points[i].Y < points[j].Y
+	})
+	cross := func(o, a, b export.Point) int {
+		return (a.X-o.X)*(b.Y-o.Y) - (a.Y-o.Y)*(b.X-o.X)
+	}
+	hull := make([]export.Point, 0, len(points)*2)
+	for _, p := range points {
+		for len(hull) >= 2 && cross(hull[len(hull)-2], hull[len(hull)-1], p) <= 0 {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, p)
+	}
+	lower := len(hull) + 1
+	for i := len(points) - 2; i >= 0; i-- {
+		p := points[i]
+		for len(hull) >= lower && cross(hull[len(hull)-2], hull[len(hull)-1], p) <= 0 {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, p)
+	}
+	return hull[:len(hull)-1]
+}
@@ -0,0 +1,44 @@
+package sprite
+
+import (
+	"testing"
+
+	"github.com/jeromelesaux/martine/convert/pixel"
+	"github.com/jeromelesaux/martine/export"
+)
+
+func TestNewCollision(t *testing.T) {
+	// mode 1, 2 bytes by line : a 2x2 block at the pixels (3,1)-(4,2)
+	data := []byte{
+		0, 0,
+		pixel.PixelMode1(0, 0, 0, 1), pixel.PixelMode1(1, 0, 0, 0),
+		pixel.PixelMode1(0, 0, 0, 2), pixel.PixelMode1(3, 0, 0, 0),
+	}
+	c := NewCollision(data, 2, 1, true, nil)
+	if c.MaskLineSize != 1 || len(c.Mask) != 3 || c.Mask[0] != 0 || c.Mask[1] != 0x18 || c.Mask[2] != 0x18 {
+		t.Fatalf("unexpected pixel mask %X", c.Mask)
+	}
+	if c.Bounds != (export.Hitbox{X: 3, Y: 1, Width: 2, Height: 2}) {
+		t.Fatalf("unexpected bounds %v", c.Bounds)
+	}
+	expected := []export.Point{{X: 3, Y: 1}, {X: 5, Y: 1}, {X: 5, Y: 3}, {X: 3, Y: 3}}
+	if len(c.Outline) != len(expected) {
+		t.Fatalf("unexpected outline %v", c.Outline)
+	}
+	for i, v := range expected {
+		if c.Outline[i] != v {
+			t.Fatalf("unexpected outline %v", c.Outline)
+		}
+	}
+	c = NewCollision(data, 2, 1, false, nil)
+	if len(c.Mask) != 3 || c.Mask[1] != 0xC0 || c.Mask[2] != 0xC0 {
+		t.Fatalf("unexpected byte mask %X", c.Mask)
+	}
+	boxes, err := export.ParseHitboxes("2,0,12,16;4,4,8,8")
+	if err != nil || len(boxes) != 2 || boxes[1] != (export.Hitbox{X: 4, Y: 4, Width: 8, Height: 8}) {
+		t.Fatalf("unexpected hitboxes %v %v", boxes, err)
+	}
+	if _, err := export.ParseHitboxes("2,0,12"); err == nil {
+		t.Fatal("expected an error for a bad hitbox")
+	}
+}
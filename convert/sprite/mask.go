@@ -0,0 +1,43 @@
+package sprite
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/jeromelesaux/martine/config"
+	"github.com/jeromelesaux/martine/export/amsdos"
+)
+
+// MaskTables returns the and masks (all the bits of the transparent pixels,
+// pen 0, set) and the or data of the sprite, interleaved (the mask then the
+// data of each byte) or separate (all the masks then all the data). The
+// sprite is drawn by screen = (screen and mask) or data.
+func MaskTables(data []byte, mode uint8, interleaved bool) []byte {
+	mask := Mask(data, mode)
+	if !interleaved {
+		return append(mask, data...)
+	}
+	tables := make([]byte, 0, len(data)*2)
+	for i := range data {
+		tables = append(tables, mask[i], data[i])
+	}
+	return tables
+}
+
+// ExportMaskTables saves the mask tables of the sprite in the file .MSK.
+func ExportMaskTables(data []byte, mode uint8, interleaved bool, filename string, cfg *config.MartineConfig) error {
+	tables := MaskTables(data, mode, interleaved)
+	path := cfg.AmsdosFullPath(filename, ".MSK")
+	var err error
+	if cfg.NoAmsdosHeader {
+		err = amsdos.SaveOSFile(path, tables)
+	} else {
+		err = amsdos.SaveAmsdosFile(path, ".MSK", tables, 2, 0, 0x4000, 0x4000)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error while saving file %s error :%v", path, err)
+		return err
+	}
+	cfg.AddFile(path)
+	return nil
+}
@@ -0,0 +1,18 @@
+package sprite
+
+import (
+	"testing"
+)
+
+func TestMaskTables(t *testing.T) {
+	// mode 1 : pixels 0 and 2 of the first byte are the pen 0
+	data := []byte{0x50, 0x00}
+	tables := MaskTables(data, 1, true)
+	if len(tables) != 4 || tables[0] != 0xAA || tables[1] != 0x50 || tables[2] != 0xFF || tables[3] != 0x00 {
+		t.Fatalf("unexpected interleaved tables %X", tables)
+	}
+	tables = MaskTables(data, 1, false)
+	if len(tables) != 4 || tables[0] != 0xAA || tables[1] != 0xFF || tables[2] != 0x50 || tables[3] != 0x00 {
+		t.Fatalf("unexpected separate tables %X", tables)
+	}
+}
@@ -0,0 +1,218 @@
+package sprite
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"os"
+	"runtime"
+
+	"github.com/jeromelesaux/martine/config"
+	ci "github.com/jeromelesaux/martine/convert/image"
+	"github.com/jeromelesaux/martine/convert/pixel"
+	"github.com/jeromelesaux/martine/export/amsdos"
+	"github.com/jeromelesaux/martine/export/ascii"
+	"github.com/jeromelesaux/martine/export/impdraw/palette"
+	"github.com/jeromelesaux/martine/export/ocpartstudio"
+	"github.com/jeromelesaux/martine/export/ocpartstudio/window"
+	gfxerrors "github.com/jeromelesaux/martine/gfx/errors"
+)
+
+var (
+	ErrorPreShiftBank    = errors.New("the sprite width is not a multiple of the number of sprites of the bank")
+	ErrorPreShiftTooHigh = errors.New("the pre-shifted sprites are higher than 255 lines, reduce the bank")
+)
+
+// PreShifted contains the shift variants of a bank of sprites, the variant
+// of the sprite i for the x coordinate is at the index i*Variants+x%Variants.
+type PreShifted struct {
+	Sprites  [][]byte
+	Masks    [][]byte
+	Variants int
+	LineSize int
+	Height   int
+}
+
+// PixelsByByte returns the number of pixels by byte of the mode.
+func PixelsByByte(mode uint8) (int, error) {
+	switch mode {
+	case 0:
+		return 2, nil
+	case 1:
+		return 4, nil
+	case 2:
+		return 8, nil
+	}
+	return 0, gfxerrors.ErrorModeNotFound
+}
+
+// PreShift returns the sprite moved of 0 to n-1 pixels to the right, n is the
+// number of pixels by byte of the mode. Each variant gets an extra byte column
+// filled with the pen 0.
+func PreShift(in *image.NRGBA, p color.Palette, mode uint8) ([]*image.NRGBA, error) {
+	n, err := PixelsByByte(mode)
+	if err != nil {
+		return nil, err
+	}
+	width := (in.Bounds().Dx() + n - 1) / n * n
+	variants := make([]*image.NRGBA, n)
+	for shift := 0; shift < n; shift++ {
+		out := image.NewNRGBA(image.Rect(0, 0, width+n, in.Bounds().Dy()))
+		draw.Draw(out, out.Bounds(), &image.Uniform{p[0]}, image.Point{}, draw.Src)
+		draw.Draw(out, image.Rect(shift, 0, shift+in.Bounds().Dx(), in.Bounds().Dy()), in, in.Bounds().Min, draw.Src)
+		variants[shift] = out
+	}
+	return variants, nil
+}
+
+// ToPreShiftedSprites converts the sprites of the bank and all their shift
+// variants, with the mask of the pen 0 if needed. The zigzag and the scanline
+// sequence of the configuration are applied to each variant.
+func ToPreShiftedSprites(bank []*image.NRGBA, p color.Palette, mode uint8, masks bool, cfg *config.MartineConfig) (PreShifted, error) {
+	var ps PreShifted
+	for _, in := range bank {
+		variants, err := PreShift(in, p, mode)
+		if err != nil {
+			return ps, err
+		}
+		ps.Variants = len(variants)
+		for _, v := range variants {
+			if cfg.ZigZag {
+				v = ci.Zigzag(v)
+			}
+			data, _, lineSize, err := ToSprite(v, p, cfg.Size, mode, cfg)
+			if err != nil {
+				return ps, err
+			}
+			ps.LineSize = lineSize
+			ps.Height = v.Bounds().Dy()
+			ps.Sprites = append(ps.Sprites, data)
+			if masks {
+				ps.Masks = append(ps.Masks, Mask(data, mode))
+			}
+		}
+	}
+	return ps, nil
+}
+
+// Mask returns the mask of the sprite data, all the bits of the pixels of the
+// pen 0 are set.
+func Mask(data []byte, mode uint8) []byte {
+	mask := make([]byte, len(data))
+	for i, b := range data {
+		switch mode {
+		case 0:
+			p1, p2 := pixel.RawPixelMode0(b)
+			mask[i] = pixel.PixelMode0(transparent(p1, 15), transparent(p2, 15))
+		case 1:
+			p1, p2, p3, p4 := pixel.RawPixelMode1(b)
+			mask[i] = pixel.PixelMode1(transparent(p1, 3), transparent(p2, 3), transparent(p3, 3), transparent(p4, 3))
+		case 2:
+			p1, p2, p3, p4, p5, p6, p7, p8 := pixel.RawPixelMode2(b)
+			mask[i] = pixel.PixelMode2(transparent(p1, 1), transparent(p2, 1), transparent(p3, 1), transparent(p4, 1),
+				transparent(p5, 1), transparent(p6, 1), transparent(p7, 1), transparent(p8, 1))
+		}
+	}
+	return mask
+}
+
+func transparent(pen, all int) int {
+	if pen == 0 {
+		return all
+	}
+	return 0
+}
+
+// SplitBank returns the n sprites of the same width side by side in the
+// image.
+func SplitBank(in *image.NRGBA, n int) ([]*image.NRGBA, error) {
+	if n < 1 || in.Bounds().Dx()%n != 0 {
+		return nil, ErrorPreShiftBank
+	}
+	width := in.Bounds().Dx() / n
+	bank := make([]*image.NRGBA, n)
+	for i := 0; i < n; i++ {
+		s := image.NewNRGBA(image.Rect(0, 0, width, in.Bounds().Dy()))
+		draw.Draw(s, s.Bounds(), in, image.Point{X: in.Bounds().Min.X + i*width, Y: in.Bounds().Min.Y}, draw.Src)
+		bank[i] = s
+	}
+	return bank, nil
+}
+
+// PreShiftAsm returns the assembly source of the pre-shifted sprites with the
+// tables of pointers indexed by sprite*variants+(x and variants-1).
+func PreShiftAsm(ps PreShifted, eol string) string {
+	var out string
+	out += fmt.Sprintf("; %d sprites, %d shifts, width %d bytes, height %d lines%s", len(ps.Sprites)/ps.Variants, ps.Variants, ps.LineSize, ps.Height, eol)
+	out += fmt.Sprintf("; sprite address = (shift_table + 2*(sprite*%d + (x and %d)))%s", ps.Variants, ps.Variants-1, eol)
+	out += "shift_table:" + eol
+	for i := range ps.Sprites {
+		out += fmt.Sprintf("dw %s%s", preShiftLabel("sprite", i, ps.Variants), eol)
+	}
+	if len(ps.Masks) > 0 {
+		out += "mask_table:" + eol
+		for i := range ps.Masks {
+			out += fmt.Sprintf("dw %s%s", preShiftLabel("mask", i, ps.Variants), eol)
+		}
+	}
+	for i, data := range ps.Sprites {
+		out += preShiftLabel("sprite", i, ps.Variants) + ":" + eol
+		out += ascii.FormatAssemblyDatabyte(data, eol)
+		if len(ps.Masks) > 0 {
+			out += preShiftLabel("mask", i, ps.Variants) + ":" + eol
+			out += ascii.FormatAssemblyDatabyte(ps.Masks[i], eol)
+		}
+	}
+	return out
+}
+
+func preShiftLabel(prefix string, index, variants int) string {
+	return fmt.Sprintf("%s_%.2d_%d", prefix, index/variants, index%variants)
+}
+
+// PreShiftAndExport generates the shift variants of the n sprites side by
+// side in the image and saves them in one window file (.WIN), the variants
+// one below the other followed by their masks, with the assembly source
+// (.ASM) and the palette.
+func PreShiftAndExport(in *image.NRGBA, p color.Palette, mode uint8, n int, masks bool, filename string, cfg *config.MartineConfig) error {
+	bank, err := SplitBank(in, n)
+	if err != nil {
+		return err
+	}
+	ps, err := ToPreShiftedSprites(bank, p, mode, masks, cfg)
+	if err != nil {
+		return err
+	}
+	data := make([]byte, 0)
+	for i := range ps.Sprites {
+		data = append(data, ps.Sprites[i]...)
+		if masks {
+			data = append(data, ps.Masks[i]...)
+		}
+	}
+	height := len(data) / ps.LineSize
+	if height > 255 {
+		return ErrorPreShiftTooHigh
+	}
+	fmt.Fprintf(os.Stdout, "Pre-shifted %d sprites in %d variants of %d bytes by %d lines\n", n, ps.Variants, ps.LineSize, ps.Height)
+	if err := window.Win(filename, data, mode, ps.LineSize, height, false, cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "Error while saving file %s error :%v", filename, err)
+		return err
+	}
+	eol := "\n"
+	if runtime.GOOS == "windows" {
+		eol = "\r\n"
+	}
+	asmPath := cfg.AmsdosFullPath(filename, ".ASM")
+	if err := amsdos.SaveStringOSFile(asmPath, PreShiftAsm(ps, eol)); err != nil {
+		fmt.Fprintf(os.Stderr, "Error while saving file %s error :%v", asmPath, err)
+		return err
+	}
+	cfg.AddFile(asmPath)
+	if cfg.CpcPlus {
+		return palette.Kit(filename, p, mode, false, cfg)
+	}
+	return ocpartstudio.Pal(filename, p, mode, false, cfg)
+}
@@ -0,0 +1,57 @@
+package sprite
+
+import (
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+
+	"github.com/jeromelesaux/martine/config"
+	"github.com/jeromelesaux/martine/convert/pixel"
+)
+
+func TestPreShift(t *testing.T) {
+	p := color.Palette{color.Black, color.White}
+	in := image.NewNRGBA(image.Rect(0, 0, 4, 2))
+	for y := 0; y < 2; y++ {
+		for x := 0; x < 4; x++ {
+			in.Set(x, y, p[0])
+		}
+	}
+	in.Set(0, 0, p[1])
+	in.Set(0, 1, p[1])
+	cfg := config.NewMartineConfig("", "")
+	cfg.ZigZag = true
+
+	ps, err := ToPreShiftedSprites([]*image.NRGBA{in}, p, 1, true, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ps.Variants != 4 || len(ps.Sprites) != 4 || ps.LineSize != 2 || ps.Height != 2 {
+		t.Fatalf("unexpected variants %d sprites %d line size %d height %d", ps.Variants, len(ps.Sprites), ps.LineSize, ps.Height)
+	}
+	// the pixel moves of one pixel in each variant, the odd line is reversed
+	for shift, data := range ps.Sprites {
+		pens := []int{0, 0, 0, 0}
+		pens[shift] = 1
+		if data[0] != pixel.PixelMode1(pens[0], pens[1], pens[2], pens[3]) {
+			t.Fatalf("shift %d unexpected first byte #%.2x", shift, data[0])
+		}
+		p1, p2, p3, p4 := pixel.RawPixelMode1(data[3])
+		if []int{p4, p3, p2, p1}[shift] != 1 {
+			t.Fatalf("shift %d unexpected zigzag byte #%.2x", shift, data[3])
+		}
+		masks := []int{3, 3, 3, 3}
+		masks[shift] = 0
+		if ps.Masks[shift][0] != pixel.PixelMode1(masks[0], masks[1], masks[2], masks[3]) || ps.Masks[shift][1] != 0xff {
+			t.Fatalf("shift %d unexpected mask %v", shift, ps.Masks[shift])
+		}
+	}
+	asm := PreShiftAsm(ps, "\n")
+	if !strings.Contains(asm, "dw sprite_00_3\n") || !strings.Contains(asm, "mask_00_1:\n") {
+		t.Fatalf("unexpected assembly source %s", asm)
+	}
+	if _, err := SplitBank(in, 3); err != ErrorPreShiftBank {
+		t.Fatalf("expected a bank error and gets %v", err)
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/jeromelesaux/martine/constants"
 	pal "github.com/jeromelesaux/martine/convert/palette"
 	"github.com/jeromelesaux/martine/convert/pixel"
+	"github.com/jeromelesaux/martine/export"
 	"github.com/jeromelesaux/martine/export/ascii"
 	"github.com/jeromelesaux/martine/export/impdraw/palette"
 	"github.com/jeromelesaux/martine/export/ocpartstudio"
@@ -259,7 +260,11 @@ func ExportSprite(data []byte, lineSize int, p color.Palette, size constants.Siz
 			return err
 		}
 	}
-	if err := ascii.Ascii(filename, data, p, dontImportDsk, cfg); err != nil {
+	var collision *export.Collision
+	if cfg.Collision {
+		collision = NewCollision(data, lineSize, mode, cfg.CollisionPixelMask, cfg.CollisionHitboxes)
+	}
+	if err := ascii.AsciiWithCollision(filename, data, p, collision, dontImportDsk, cfg); err != nil {
 		fmt.Fprintf(os.Stderr, "Error while saving ascii file for (%s) error :%v\n", filename, err)
 	}
 	return ascii.AsciiByColumn(filename, data, p, dontImportDsk, mode, cfg)
@@ -271,6 +276,11 @@ func ToSpriteAndExport(in *image.NRGBA, p color.Palette, size constants.Size, mo
 	if err != nil {
 		return err
 	}
+	if ex.TransparentMask {
+		if err := ExportMaskTables(data, mode, ex.TransparentInterleaved, filename, ex); err != nil {
+			return err
+		}
+	}
 	fmt.Println(firmwareColorUsed)
 	return ExportSprite(data, lineSize, p, size, mode, filename, dontImportDsk, ex)
 }
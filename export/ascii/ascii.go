@@ -5,6 +5,7 @@ import (
 	"image/color"
 	"os"
 	"runtime"
+	"strings"
 
 	"github.com/jeromelesaux/martine/config"
 	"github.com/jeromelesaux/martine/constants"
@@ -16,7 +17,28 @@ import (
 // ByteToken is the token by default
 var ByteToken = "db" // "BYTE"
 
+// WordToken returns the token of the words of the syntax of ByteToken (dw for
+// db, defw for defb, word for byte).
+func WordToken() string {
+	switch {
+	case strings.HasSuffix(ByteToken, "BYTE"):
+		return strings.TrimSuffix(ByteToken, "BYTE") + "WORD"
+	case strings.HasSuffix(strings.ToLower(ByteToken), "byte"):
+		return ByteToken[:len(ByteToken)-4] + "word"
+	case strings.HasSuffix(ByteToken, "B"):
+		return strings.TrimSuffix(ByteToken, "B") + "W"
+	case strings.HasSuffix(ByteToken, "b"):
+		return strings.TrimSuffix(ByteToken, "b") + "w"
+	}
+	return "dw"
+}
+
 func Ascii(filePath string, data []byte, p color.Palette, dontImportDsk bool, cgf *config.MartineConfig) error {
+	return AsciiWithCollision(filePath, data, p, nil, dontImportDsk, cgf)
+}
+
+// AsciiWithCollision saves the sprite with its collision data if set.
+func AsciiWithCollision(filePath string, data []byte, p color.Palette, collision *x.Collision, dontImportDsk bool, cgf *config.MartineConfig) error {
 	eol := "\n"
 	if runtime.GOOS == "windows" {
 		eol = "\r\n"
@@ -42,6 +64,9 @@ func Ascii(filePath string, data []byte, p color.Palette, dontImportDsk bool, cg
 		out += FormatAssemblyBasicPalette(p, eol)
 		out += eol
 	}
+	if collision != nil {
+		out += FormatAssemblyCollision(collision, cpcFilename, eol)
+	}
 	if !cgf.NoAmsdosHeader {
 		if err := amsdos.SaveAmsdosFile(osFilepath, ".TXT", []byte(out), 0, 0, 0, 0); err != nil {
 			return err
@@ -75,6 +100,7 @@ func Ascii(filePath string, data []byte, p color.Palette, dontImportDsk bool, cg
 			screen[i] = fmt.Sprintf("0x%.2x", data[i])
 		}
 		j := x.NewJson(cgf.Filename(), cgf.Size.Width, cgf.Size.Height, screen, palette, hardwarepalette)
+		j.Collision = collision
 		fmt.Fprintf(os.Stdout, "Filepath:%s\n", filePath)
 		if cgf.TileMode {
 			cgf.Tiles.Sprites = append(cgf.Tiles.Sprites, j)
@@ -281,6 +307,33 @@ func FormatAssemblyDatabyte(data []byte, eol string) string {
 	return out
 }
 
+// FormatAssemblyCollision returns the collision tables of the sprite, the
+// coordinates are words in pixels as a sprite of the mode 1 or 2 can be wider
+// than 255 pixels.
+func FormatAssemblyCollision(c *x.Collision, cpcFilename string, eol string) string {
+	var out string
+	unit := "byte"
+	if c.PixelMask {
+		unit = "pixel"
+	}
+	out += "; Collision mask " + cpcFilename + eol + ".collision_mask:" + eol
+	out += fmt.Sprintf("; 1 bit by %s, %d bytes by line%s", unit, c.MaskLineSize, eol)
+	out += FormatAssemblyDatabyte(c.Mask, eol)
+	out += "; Bounding box x, y, width, height" + eol + ".collision_bounds:" + eol
+	out += fmt.Sprintf("%s %d, %d, %d, %d%s", WordToken(), c.Bounds.X, c.Bounds.Y, c.Bounds.Width, c.Bounds.Height, eol)
+	out += "; Hitboxes x, y, width, height" + eol + ".collision_hitboxes:" + eol
+	out += fmt.Sprintf("%s %d%s", ByteToken, len(c.Hitboxes), eol)
+	for _, h := range c.Hitboxes {
+		out += fmt.Sprintf("%s %d, %d, %d, %d%s", WordToken(), h.X, h.Y, h.Width, h.Height, eol)
+	}
+	out += "; Convex outline x, y" + eol + ".collision_outline:" + eol
+	out += fmt.Sprintf("%s %d%s", ByteToken, len(c.Outline), eol)
+	for _, v := range c.Outline {
+		out += fmt.Sprintf("%s %d, %d%s", WordToken(), v.X, v.Y, eol)
+	}
+	return out
+}
+
 func FormatAssemblyCPCPalette(p color.Palette, eol string) string {
 	var out string
 	for i := 0; i < len(p); i++ {
@@ -2,10 +2,12 @@ package ascii_test
 
 import (
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/jeromelesaux/martine/config"
 	"github.com/jeromelesaux/martine/constants"
+	"github.com/jeromelesaux/martine/export"
 	"github.com/jeromelesaux/martine/export/ascii"
 )
 
@@ -26,3 +28,39 @@ func TestAsciiByColumn(t *testing.T) {
 	}
 	os.Remove("TESTC.TXT")
 }
+
+func TestFormatAssemblyCollision(t *testing.T) {
+	// a mode 2 sprite of 640 pixels
+	c := &export.Collision{
+		Bounds:   export.Hitbox{X: 300, Y: 2, Width: 340, Height: 8},
+		Hitboxes: []export.Hitbox{{X: 400, Y: 0, Width: 16, Height: 16}},
+		Outline:  []export.Point{{X: 300, Y: 2}, {X: 639, Y: 2}, {X: 639, Y: 9}},
+	}
+	code := ascii.FormatAssemblyCollision(c, "SPRITE", "\n")
+	for _, v := range []string{
+		".collision_bounds:\ndw 300, 2, 340, 8\n",
+		".collision_hitboxes:\ndb 1\ndw 400, 0, 16, 16\n",
+		".collision_outline:\ndb 3\ndw 300, 2\ndw 639, 2\ndw 639, 9\n",
+	} {
+		if !strings.Contains(code, v) {
+			t.Fatalf("expected %q in %s", v, code)
+		}
+	}
+
+	ascii.ByteToken = "defb"
+	defer func() { ascii.ByteToken = "db" }()
+	code = ascii.FormatAssemblyCollision(c, "SPRITE", "\n")
+	if !strings.Contains(code, ".collision_hitboxes:\ndefb 1\ndefw 400, 0, 16, 16\n") || strings.Contains(code, "dw ") {
+		t.Fatalf("expected the defb syntax in %s", code)
+	}
+}
+
+func TestWordToken(t *testing.T) {
+	defer func() { ascii.ByteToken = "db" }()
+	for byteToken, wordToken := range map[string]string{"db": "dw", "defb": "defw", "byte": "word", "BYTE": "WORD", ".byte": ".word", "DEFB": "DEFW"} {
+		ascii.ByteToken = byteToken
+		if ascii.WordToken() != wordToken {
+			t.Fatalf("expected %s for %s and gets %s", wordToken, byteToken, ascii.WordToken())
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package export
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jeromelesaux/martine/gfx/errors"
+)
+
+// Hitbox is a rectangle of the sprite in pixels.
+type Hitbox struct {
+	X      int `json:"x"`
+	Y      int `json:"y"`
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+// Point is a vertex of the outline in pixels.
+type Point struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+// Collision is the collision data of a sprite : the hitbox mask (1 bit by
+// pixel or by byte of the sprite, msb first, MaskLineSize bytes by line), the
+// tight bounding box of the non transparent pixels, the user hitboxes and the
+// convex outline (clockwise) of the sprite.
+type Collision struct {
+	PixelMask    bool     `json:"pixelMask"`
+	MaskLineSize int      `json:"maskLineSize"`
+	Mask         []byte   `json:"-"`
+	MaskValues   []string `json:"mask"`
+	Bounds       Hitbox   `json:"bounds"`
+	Hitboxes     []Hitbox `json:"hitboxes"`
+	Outline      []Point  `json:"outline"`
+}
+
+// ParseHitboxes returns the hitboxes of the list x,y,width,height in pixels
+// separated by ; (ex 2,0,12,16;4,4,8,8).
+func ParseHitboxes(hitboxes string) ([]Hitbox, error) {
+	result := make([]Hitbox, 0)
+	if strings.TrimSpace(hitboxes) == "" {
+		return result, nil
+	}
+	for _, v := range strings.Split(hitboxes, ";") {
+		values := strings.Split(strings.TrimSpace(v), ",")
+		if len(values) != 4 {
+			return result, errors.ErrorBadHitbox
+		}
+		var n [4]int
+		for i, s := range values {
+			var err error
+			if n[i], err = strconv.Atoi(strings.TrimSpace(s)); err != nil || n[i] < 0 || n[i] > 0xFFFF {
+				return result, errors.ErrorBadHitbox
+			}
+		}
+		if n[2] == 0 || n[3] == 0 {
+			return result, errors.ErrorBadHitbox
+		}
+		result = append(result, Hitbox{X: n[0], Y: n[1], Width: n[2], Height: n[3]})
+	}
+	return result, nil
+}
+
+// SetMask sets the mask and its values of the json.
+func (c *Collision) SetMask(mask []byte) {
+	c.Mask = mask
+	c.MaskValues = make([]string, len(mask))
+	for i, v := range mask {
+		c.MaskValues[i] = fmt.Sprintf("0x%.2x", v)
+	}
+}
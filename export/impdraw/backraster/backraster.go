@@ -0,0 +1,150 @@
+// Package backraster exports the background rasters of the old cpc: the ink
+// 0 and the border get a color at each line of the screen.
+package backraster
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jeromelesaux/martine/config"
+	"github.com/jeromelesaux/martine/export/amsdos"
+	"github.com/jeromelesaux/martine/export/impdraw/mixedmode"
+	"github.com/jeromelesaux/martine/export/impdraw/raster"
+)
+
+var (
+	ErrorLineBeforeSync = errors.New("raster line displayed before the synchronisation of the routine")
+	ErrorRasterTooLong  = errors.New("raster routine lasts more than a frame")
+)
+
+const (
+	// RoutineAddress is the loading and execution address of the routine.
+	RoutineAddress = 0x8000
+	// duration of the change of the ink 0 and the border
+	changeNops = 18
+	// maximum delay of a change after the start of the right border
+	maxLate = 16
+)
+
+// Routine returns the raster routine which loads the screen file at the
+// address, sets the inks 1 to n, then changes the ink 0 and the border in the
+// right border of the line before each color change of the table (one
+// hardware color by line) at every frame. The routine is synchronised on the
+// interruption which follows the vsync.
+func Routine(table []byte, inks []byte, mode uint8, screenFile string, screenAddress uint16, layout mixedmode.Layout) ([]byte, string, error) {
+	c := raster.NewCode(RoutineAddress)
+	name := []byte(strings.ToUpper(screenFile))
+	c.Comment("load the screen")
+	nameAddress := c.LoadFile(len(name), screenAddress)
+	c.Emit(1, "di", 0xF3)
+	if layout.Overscan {
+		c.Comment("overscan crtc values")
+		for _, r := range [][2]int{{1, layout.R1}, {2, layout.R2}, {6, layout.R6}, {7, layout.R7}, {12, 0x0D}, {13, 0}} {
+			c.Emit(3, fmt.Sprintf("ld bc,#BC%.2X", r[0]), 0x01, byte(r[0]), 0xBC)
+			c.Emit(4, "out (c),c", 0xED, 0x49)
+			c.Emit(3, fmt.Sprintf("ld bc,#BD%.2X", r[1]), 0x01, byte(r[1]), 0xBD)
+			c.Emit(4, "out (c),c", 0xED, 0x49)
+		}
+	}
+	c.Comment("ei:ret interruption handler")
+	c.Emit(2, "im 1", 0xED, 0x56)
+	c.Emit(3, "ld hl,#C9FB", 0x21, 0xFB, 0xC9)
+	c.Emit(5, "ld (#0038),hl", 0x22, 0x38, 0x00)
+	c.Emit(2, "ld b,#7F", 0x06, 0x7F)
+	c.SetMode(mode)
+	for i, ink := range inks {
+		c.SetInk(i+1, ink)
+	}
+	// d selects the ink 0 and e the border
+	c.Emit(3, "ld de,#0010", 0x11, 0x10, 0x00)
+
+	frame := c.Address()
+	c.Label("frame")
+	c.WaitVsync()
+	c.ResetInterrupts(mode)
+	synced := false
+	var previous byte
+	for line, ink := range table {
+		if line > 0 && ink == previous {
+			continue
+		}
+		previous = ink
+		// the colors are changed in the right border of the previous line,
+		// the lines before the interruption are timed from the vsync polling
+		start := (layout.FirstLineDelay()+line-1)*raster.NopsByLine + layout.R1
+		if !synced && start+changeNops >= raster.HaltSyncLine*raster.NopsByLine {
+			c.HaltSync()
+			synced = true
+		}
+		if c.Nops()-start > maxLate {
+			return nil, "", ErrorLineBeforeSync
+		}
+		c.Comment(fmt.Sprintf("line %d", line))
+		if start > c.Nops() {
+			c.Delay(start - c.Nops())
+		}
+		c.Emit(4, "out (c),d", 0xED, 0x51)
+		c.Emit(2, fmt.Sprintf("ld a,#%.2X", ink), 0x3E, ink)
+		c.Emit(4, "out (c),a", 0xED, 0x79)
+		c.Emit(4, "out (c),e", 0xED, 0x59)
+		c.Emit(4, "out (c),a", 0xED, 0x79)
+	}
+	// the routine must end before the next vsync
+	if c.Nops() >= (layout.R4+1)*8*raster.NopsByLine {
+		return nil, "", ErrorRasterTooLong
+	}
+	c.Emit(3, "jp frame", 0xC3, byte(frame), byte(frame>>8))
+	c.Patch16(nameAddress, uint16(c.Address()))
+	c.Label("filename")
+	c.Emit(0, fmt.Sprintf("db \"%s\"", name), name...)
+	return c.Bytes(), c.Listing(), nil
+}
+
+// Loader returns an ascii basic loader of the routine.
+func Loader(routineFile string, mode uint8) string {
+	return fmt.Sprintf("10 MODE %d:MEMORY &%X\r\n20 LOAD\"%s\",&%X\r\n30 CALL &%X\r\n",
+		mode, RoutineAddress-1, routineFile, RoutineAddress, RoutineAddress)
+}
+
+// ExportBackRaster saves the raster table (.RAS, one hardware color by line),
+// the routine (.RST) with its listing (.ASM) and the basic loader
+// (-RASTER.BAS).
+func ExportBackRaster(filePath string, table []byte, inks []byte, mode uint8, cfg *config.MartineConfig) error {
+	layout := mixedmode.StandardLayout
+	var screenAddress uint16 = 0xC000
+	if cfg.Overscan {
+		layout = mixedmode.OverscanLayout
+		screenAddress = 0x170
+	}
+	tablePath := cfg.AmsdosFullPath(filePath, ".RAS")
+	fmt.Fprintf(os.Stdout, "Saving raster table (%s) %d lines\n", tablePath, len(table))
+	if err := amsdos.SaveAmsdosFile(tablePath, ".RAS", table, 2, 0, 0, 0); err != nil {
+		return err
+	}
+	cfg.AddFile(tablePath)
+
+	screenFile := filepath.Base(cfg.AmsdosFullPath(filePath, ".SCR"))
+	routine, listing, err := Routine(table, inks, mode, screenFile, screenAddress, layout)
+	if err != nil {
+		return err
+	}
+	routinePath := cfg.AmsdosFullPath(filePath, ".RST")
+	fmt.Fprintf(os.Stdout, "Saving raster routine (%s) %d bytes\n", routinePath, len(routine))
+	if err := amsdos.SaveAmsdosFile(routinePath, ".RST", routine, 2, 0, RoutineAddress, RoutineAddress); err != nil {
+		return err
+	}
+	cfg.AddFile(routinePath)
+	if err := amsdos.SaveStringOSFile(cfg.AmsdosFullPath(filePath, ".ASM"), listing); err != nil {
+		return err
+	}
+
+	loaderPath := filepath.Join(cfg.OutputPath, "-RASTER.BAS")
+	if err := amsdos.SaveStringOSFile(loaderPath, Loader(filepath.Base(routinePath), mode)); err != nil {
+		return err
+	}
+	cfg.AddFile(loaderPath)
+	return nil
+}
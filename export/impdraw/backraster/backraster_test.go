@@ -0,0 +1,40 @@
+package backraster
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/jeromelesaux/martine/export/impdraw/mixedmode"
+)
+
+func TestRoutine(t *testing.T) {
+	table := make([]byte, 200)
+	for i := range table {
+		table[i] = 0x54
+		if i >= 100 {
+			table[i] = 0x4C
+		}
+	}
+	code, listing, err := Routine(table, []byte{0x4B, 0x4C, 0x5C}, 1, "TEST.SCR", 0xC000, mixedmode.StandardLayout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasSuffix(code, []byte("TEST.SCR")) {
+		t.Fatalf("expected the filename at the end of the routine")
+	}
+	// the halt precedes the two color changes
+	halt := bytes.IndexByte(code, 0x76)
+	if halt < 0 || bytes.Count(code[halt:], []byte{0xED, 0x51}) != 2 {
+		t.Fatalf("expected the halt then two changes of the ink 0\n%s", listing)
+	}
+
+	// the first lines of the overscan are displayed before the interruption
+	code, _, err = Routine(table, nil, 1, "TEST.SCR", 0x170, mixedmode.OverscanLayout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	halt = bytes.IndexByte(code, 0x76)
+	if halt < 0 || bytes.Count(code[:halt], []byte{0xED, 0x51}) != 1 {
+		t.Fatalf("expected the change of the first line before the halt")
+	}
+}
@@ -0,0 +1,222 @@
+package mixedmode
+
+import (
+	"errors"
+	"fmt"
+	"image/color"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jeromelesaux/martine/config"
+	"github.com/jeromelesaux/martine/constants"
+	"github.com/jeromelesaux/martine/export/amsdos"
+	"github.com/jeromelesaux/martine/export/impdraw/raster"
+)
+
+var (
+	ErrorBadBand       = errors.New("band must be described as first-last:mode (ex: 0-175:0)")
+	ErrorBandOverlap   = errors.New("bands overlap or are out of the screen")
+	ErrorBandsTooClose = errors.New("not enough time between two bands to switch the mode and the inks")
+)
+
+// RoutineAddress is the loading and execution address of the raster routine.
+const RoutineAddress = 0x8000
+
+// Band is a horizontal part of the screen with its own mode and palette.
+type Band struct {
+	Line    int
+	Height  int
+	Mode    uint8
+	Palette color.Palette
+}
+
+// ParseBands reads the bands described as first-last:mode separated by commas
+// (ex: "0-175:0,176-199:1").
+func ParseBands(s string) ([]Band, error) {
+	bands := make([]Band, 0)
+	for _, v := range strings.Split(s, ",") {
+		parts := strings.Split(strings.TrimSpace(v), ":")
+		if len(parts) != 2 {
+			return bands, ErrorBadBand
+		}
+		lines := strings.Split(parts[0], "-")
+		if len(lines) != 2 {
+			return bands, ErrorBadBand
+		}
+		first, err := strconv.Atoi(lines[0])
+		if err != nil {
+			return bands, ErrorBadBand
+		}
+		last, err := strconv.Atoi(lines[1])
+		if err != nil {
+			return bands, ErrorBadBand
+		}
+		mode, err := strconv.Atoi(parts[1])
+		if err != nil || mode < 0 || mode > 2 || last < first {
+			return bands, ErrorBadBand
+		}
+		bands = append(bands, Band{Line: first, Height: last - first + 1, Mode: uint8(mode)})
+	}
+	sort.Slice(bands, func(i, j int) bool { return bands[i].Line < bands[j].Line })
+	return bands, nil
+}
+
+// CheckBands verifies the bands do not overlap and stay in the screen height.
+func CheckBands(bands []Band, height int) error {
+	if len(bands) == 0 {
+		return ErrorBadBand
+	}
+	for i, b := range bands {
+		if b.Line < 0 || b.Height <= 0 || b.Line+b.Height > height {
+			return ErrorBandOverlap
+		}
+		if i > 0 && bands[i-1].Line+bands[i-1].Height > b.Line {
+			return ErrorBandOverlap
+		}
+	}
+	return nil
+}
+
+// Layout contains the CRTC values used to time the raster routine.
+type Layout struct {
+	R1, R2, R4, R6, R7 int
+	Overscan           bool
+}
+
+var (
+	StandardLayout = Layout{R1: 40, R2: 46, R4: 38, R6: 25, R7: 30}
+	// OverscanLayout uses the CRTC values of the impdraw overscan boot.
+	OverscanLayout = Layout{R1: 48, R2: 50, R4: 38, R6: 34, R7: 35, Overscan: true}
+)
+
+// FirstLineDelay returns the number of lines between the start of the vsync
+// and the first displayed line.
+func (l Layout) FirstLineDelay() int {
+	return (l.R4 + 1 - l.R7) * 8
+}
+
+func hardwareInks(p color.Palette) []byte {
+	inks := make([]byte, len(p))
+	for i, c := range p {
+		v, err := constants.HardwareValues(c)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error while getting the hardware values for color %v, error :%v\n", c, err)
+			v = []byte{0x54}
+		}
+		inks[i] = v[0]
+	}
+	return inks
+}
+
+// Routine returns the raster routine which loads the screen file at the
+// address, then switches the mode and the inks at the first line of each band
+// at every frame (the interruptions are disabled).
+func Routine(bands []Band, screenFile string, screenAddress uint16, layout Layout) ([]byte, string, error) {
+	c := raster.NewCode(RoutineAddress)
+	name := []byte(strings.ToUpper(screenFile))
+	c.Comment("load the screen")
+	nameAddress := c.LoadFile(len(name), screenAddress)
+	c.Emit(1, "di", 0xF3)
+	if layout.Overscan {
+		c.Comment("overscan crtc values")
+		for _, r := range [][2]int{{1, layout.R1}, {2, layout.R2}, {6, layout.R6}, {7, layout.R7}, {12, 0x0D}, {13, 0}} {
+			c.Emit(3, fmt.Sprintf("ld bc,#BC%.2X", r[0]), 0x01, byte(r[0]), 0xBC)
+			c.Emit(4, "out (c),c", 0xED, 0x49)
+			c.Emit(3, fmt.Sprintf("ld bc,#BD%.2X", r[1]), 0x01, byte(r[1]), 0xBD)
+			c.Emit(4, "out (c),c", 0xED, 0x49)
+		}
+	}
+	c.Emit(3, "ld bc,#7F10", 0x01, 0x10, 0x7F)
+	c.Emit(4, "out (c),c", 0xED, 0x49)
+	border := hardwareInks(bands[0].Palette)
+	c.Emit(2, fmt.Sprintf("ld a,#%.2X", border[0]), 0x3E, border[0])
+	c.Emit(4, "out (c),a", 0xED, 0x79)
+
+	frame := c.Address()
+	c.Label("frame")
+	c.WaitVsync()
+
+	var previous []byte
+	var previousMode uint8
+	for i, b := range bands {
+		inks := hardwareInks(b.Palette)
+		c.Comment(fmt.Sprintf("band %d line %d mode %d", i, b.Line, b.Mode))
+		if i > 0 {
+			// the mode is latched by the hsync, it is written in the right
+			// border of the previous line, then the inks are changed
+			start := (layout.FirstLineDelay()+b.Line-1)*raster.NopsByLine + layout.R1
+			if b.Mode == previousMode {
+				start++
+			} else {
+				start -= 5
+			}
+			if start < c.Nops() {
+				return nil, "", ErrorBandsTooClose
+			}
+			c.Delay(start - c.Nops())
+		}
+		if i == 0 || b.Mode != previousMode {
+			c.SetMode(b.Mode)
+		}
+		for pen, ink := range inks {
+			if i > 0 && pen < len(previous) && previous[pen] == ink {
+				continue
+			}
+			c.SetInk(pen, ink)
+		}
+		previous = inks
+		previousMode = b.Mode
+	}
+	// the routine must end before the next vsync
+	if c.Nops() >= (layout.R4+1)*8*raster.NopsByLine {
+		return nil, "", ErrorBandsTooClose
+	}
+	c.Emit(3, "jp frame", 0xC3, byte(frame), byte(frame>>8))
+	c.Patch16(nameAddress, uint16(c.Address()))
+	c.Label("filename")
+	c.Emit(0, fmt.Sprintf("db \"%s\"", string(name)), name...)
+	return c.Bytes(), c.Listing(), nil
+}
+
+// Loader returns an ascii basic loader of the raster routine.
+func Loader(routineFile string, firstMode uint8) string {
+	return fmt.Sprintf("10 MODE %d:MEMORY &%X\r\n20 LOAD\"%s\",&%X\r\n30 CALL &%X\r\n",
+		firstMode, RoutineAddress-1, strings.ToUpper(routineFile), RoutineAddress, RoutineAddress)
+}
+
+// ExportMixedMode saves the raster routine (.RST), its listing (.ASM) and the
+// basic loader (-MIXED.BAS) of the screen file.
+func ExportMixedMode(filePath string, bands []Band, cfg *config.MartineConfig) error {
+	layout := StandardLayout
+	var screenAddress uint16 = 0xC000
+	if cfg.Overscan {
+		layout = OverscanLayout
+		screenAddress = 0x170
+	}
+	screenFile := filepath.Base(cfg.AmsdosFullPath(filePath, ".SCR"))
+	routine, listing, err := Routine(bands, screenFile, screenAddress, layout)
+	if err != nil {
+		return err
+	}
+	routinePath := cfg.AmsdosFullPath(filePath, ".RST")
+	fmt.Fprintf(os.Stdout, "Saving raster routine (%s) %d bytes\n", routinePath, len(routine))
+	if err := amsdos.SaveAmsdosFile(routinePath, ".RST", routine, 2, 0, RoutineAddress, RoutineAddress); err != nil {
+		return err
+	}
+	cfg.AddFile(routinePath)
+
+	asmPath := cfg.AmsdosFullPath(filePath, ".ASM")
+	if err := amsdos.SaveStringOSFile(asmPath, listing); err != nil {
+		return err
+	}
+
+	loaderPath := filepath.Join(cfg.OutputPath, "-MIXED.BAS")
+	if err := amsdos.SaveStringOSFile(loaderPath, Loader(filepath.Base(routinePath), bands[0].Mode)); err != nil {
+		return err
+	}
+	cfg.AddFile(loaderPath)
+	return nil
+}
@@ -0,0 +1,69 @@
+package mixedmode
+
+import (
+	"bytes"
+	"image/color"
+	"testing"
+
+	"github.com/jeromelesaux/martine/constants"
+)
+
+func TestParseBands(t *testing.T) {
+	bands, err := ParseBands("176-199:1,0-175:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bands) != 2 {
+		t.Fatalf("expected 2 bands and gets %d", len(bands))
+	}
+	if bands[0].Line != 0 || bands[0].Height != 176 || bands[0].Mode != 0 {
+		t.Fatalf("unexpected first band %v", bands[0])
+	}
+	if bands[1].Line != 176 || bands[1].Height != 24 || bands[1].Mode != 1 {
+		t.Fatalf("unexpected second band %v", bands[1])
+	}
+	if err := CheckBands(bands, 200); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseBands("0-10:3"); err == nil {
+		t.Fatalf("expected an error for the mode 3")
+	}
+	bands, _ = ParseBands("0-100:0,90-199:1")
+	if err := CheckBands(bands, 200); err != ErrorBandOverlap {
+		t.Fatalf("expected overlap error and gets %v", err)
+	}
+}
+
+func TestRoutine(t *testing.T) {
+	p0 := color.Palette{constants.Black.Color, constants.Blue.Color, constants.BrightRed.Color, constants.BrightWhite.Color}
+	p1 := color.Palette{constants.Black.Color, constants.Red.Color, constants.BrightRed.Color, constants.BrightWhite.Color}
+	bands := []Band{
+		{Line: 0, Height: 100, Mode: 1, Palette: p0},
+		{Line: 100, Height: 100, Mode: 0, Palette: p1},
+	}
+	code, listing, err := Routine(bands, "TEST.SCR", 0xC000, StandardLayout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasSuffix(code, []byte("TEST.SCR")) {
+		t.Fatalf("expected the filename at the end of the routine")
+	}
+	// only the ink 1 changes with the second band
+	if bytes.Count(code, []byte{0x3E, 0x01, 0xED, 0x79}) != 2 {
+		t.Fatalf("expected the pen 1 selected twice\n%s", listing)
+	}
+	if !bytes.Contains(code, []byte{0x3E, 0x8C, 0xED, 0x79}) || !bytes.Contains(code, []byte{0x3E, 0x8D, 0xED, 0x79}) {
+		t.Fatalf("expected the modes 0 and 1 in the routine\n%s", listing)
+	}
+
+	// all the inks change between two consecutive lines
+	p2 := constants.CpcOldPalette[10:26]
+	bands = []Band{
+		{Line: 0, Height: 10, Mode: 1, Palette: p0},
+		{Line: 10, Height: 1, Mode: 0, Palette: p2},
+		{Line: 11, Height: 10, Mode: 1, Palette: p0},
+	}
+	if _, _, err := Routine(bands, "TEST.SCR", 0xC000, StandardLayout); err != ErrorBandsTooClose {
+		t.Fatalf("expected bands too close error and gets %v", err)
+	}
+}
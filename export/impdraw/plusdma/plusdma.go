@@ -0,0 +1,434 @@
+// Package plusdma builds the dma lists of the cpc plus asic. A channel
+// executes one instruction at each line, the lists of the raster gradients
+// raise an interruption at each line where a pen changes and the interruption
+// writes the new colors in the asic palette.
+package plusdma
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jeromelesaux/martine/config"
+	"github.com/jeromelesaux/martine/constants"
+	"github.com/jeromelesaux/martine/export/amsdos"
+	"github.com/jeromelesaux/martine/export/impdraw/raster"
+)
+
+var (
+	ErrorBadKeyframe    = errors.New("bad keyframe, expected line:RGB (ex 100:F80)")
+	ErrorBadPen         = errors.New("bad pen, expected 0 to 15 or 16 for the border")
+	ErrorBadGradient    = errors.New("gradient out of the frame")
+	ErrorRoutineTooLong = errors.New("routine overwrites the firmware memory")
+)
+
+const (
+	// RoutineAddress is the loading and execution address of the routine.
+	RoutineAddress = 0x8000
+	// BorderPen is the pen of the border in the asic palette.
+	BorderPen = 16
+	// FrameLines is the number of lines of a frame.
+	FrameLines = 312
+	// highest address available under the firmware
+	himem = 0xA67B
+)
+
+// Instruction is a dma instruction (16 bits).
+type Instruction uint16
+
+const (
+	Nop  Instruction = 0x4000
+	Loop Instruction = 0x4001
+	Int  Instruction = 0x4010
+	Stop Instruction = 0x4020
+)
+
+// Load writes the value in the register of the sound chip.
+func Load(register, value uint8) Instruction {
+	return Instruction(uint16(register&0x0F)<<8 | uint16(value))
+}
+
+// Pause waits lines × (prescaler+1) lines before the next instruction.
+func Pause(lines int) Instruction {
+	return Instruction(0x1000 | lines&0x0FFF)
+}
+
+// Repeat starts a block ended by Loop, the block is executed count+1 times.
+func Repeat(count int) Instruction {
+	return Instruction(0x2000 | count&0x0FFF)
+}
+
+func (i Instruction) String() string {
+	switch i >> 12 {
+	case 0:
+		return fmt.Sprintf("load %d,#%.2X", i>>8&0x0F, uint8(i))
+	case 1:
+		return fmt.Sprintf("pause %d", i&0x0FFF)
+	case 2:
+		return fmt.Sprintf("repeat %d", i&0x0FFF)
+	}
+	ops := make([]string, 0)
+	if i&Loop == Loop {
+		ops = append(ops, "loop")
+	}
+	if i&Int == Int {
+		ops = append(ops, "int")
+	}
+	if i&Stop == Stop {
+		ops = append(ops, "stop")
+	}
+	if len(ops) == 0 {
+		return "nop"
+	}
+	return strings.Join(ops, ":")
+}
+
+// List is a dma list.
+type List []Instruction
+
+// Bytes returns the list in little endian words.
+func (l List) Bytes() []byte {
+	b := make([]byte, 0, len(l)*2)
+	for _, i := range l {
+		b = append(b, byte(i), byte(i>>8))
+	}
+	return b
+}
+
+// Asm returns the list in assembler.
+func (l List) Asm() string {
+	var sb strings.Builder
+	for _, i := range l {
+		sb.WriteString(fmt.Sprintf("\tdw #%.4X ; %s\n", uint16(i), i.String()))
+	}
+	return sb.String()
+}
+
+// Gradient is the colors of the pen from the line (counted from the start of
+// the vsync), one color by line.
+type Gradient struct {
+	Pen    int
+	Line   int
+	Colors []color.Color
+}
+
+// ParseKeyframes returns the gradient of the keyframes "line:RGB,...", the
+// colors are interpolated between the keyframes in the 4096 colors.
+func ParseKeyframes(pen int, keyframes string) (Gradient, error) {
+	type keyframe struct {
+		line    int
+		r, g, b float64
+	}
+	keys := make([]keyframe, 0)
+	for _, v := range strings.Split(keyframes, ",") {
+		parts := strings.Split(strings.TrimSpace(v), ":")
+		if len(parts) != 2 {
+			return Gradient{}, ErrorBadKeyframe
+		}
+		line, err := strconv.Atoi(parts[0])
+		if err != nil || line < 0 || line >= FrameLines {
+			return Gradient{}, ErrorBadKeyframe
+		}
+		rgb, err := strconv.ParseUint(strings.TrimPrefix(parts[1], "#"), 16, 16)
+		if err != nil || len(strings.TrimPrefix(parts[1], "#")) != 3 {
+			return Gradient{}, ErrorBadKeyframe
+		}
+		keys = append(keys, keyframe{line: line, r: float64(rgb >> 8 & 0xF), g: float64(rgb >> 4 & 0xF), b: float64(rgb & 0xF)})
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].line < keys[j].line })
+	g := Gradient{Pen: pen, Line: keys[0].line}
+	for i, k := range keys {
+		if i == len(keys)-1 {
+			g.Colors = append(g.Colors, plusColor(k.r, k.g, k.b))
+			break
+		}
+		next := keys[i+1]
+		for line := k.line; line < next.line; line++ {
+			t := float64(line-k.line) / float64(next.line-k.line)
+			g.Colors = append(g.Colors, plusColor(k.r+(next.r-k.r)*t, k.g+(next.g-k.g)*t, k.b+(next.b-k.b)*t))
+		}
+	}
+	return g, nil
+}
+
+func plusColor(r, g, b float64) color.Color {
+	return constants.NewColorCpcPlusColor(constants.CpcPlusColor{R: uint16(r + .5), G: uint16(g + .5), B: uint16(b + .5)})
+}
+
+// ImageGradient returns the gradient of the image, the mean color of each
+// row is the color of a line.
+func ImageGradient(pen, line int, in image.Image) Gradient {
+	g := Gradient{Pen: pen, Line: line}
+	b := in.Bounds()
+	for y := b.Min.Y; y < b.Max.Y && line+y-b.Min.Y < FrameLines; y++ {
+		var r, gr, bl uint32
+		for x := b.Min.X; x < b.Max.X; x++ {
+			cr, cg, cb, _ := in.At(x, y).RGBA()
+			r += cr >> 8
+			gr += cg >> 8
+			bl += cb >> 8
+		}
+		n := uint32(b.Dx())
+		g.Colors = append(g.Colors, constants.NewColorCpcPlusColor(constants.NewCpcPlusColor(color.NRGBA{R: uint8(r / n), G: uint8(gr / n), B: uint8(bl / n), A: 0xff})))
+	}
+	return g
+}
+
+// PenChange is the new color value of the pen at the asic palette address.
+type PenChange struct {
+	Address uint16
+	Value   uint16
+}
+
+// LineChanges are the pens changed at the line.
+type LineChanges struct {
+	Line    int
+	Changes []PenChange
+}
+
+func penAddress(pen int) uint16 {
+	return uint16(0x6400 + pen*2)
+}
+
+func plusValue(c color.Color) uint16 {
+	cp := constants.NewCpcPlusColor(c)
+	return cp.Value()
+}
+
+// Changes returns the colors of the pens at the start of the frame and the
+// lines where the colors of the gradients change.
+func Changes(gradients []Gradient) ([]PenChange, []LineChanges, error) {
+	initial := make([]PenChange, 0, len(gradients))
+	current := make(map[int]uint16)
+	byLine := make(map[int][]PenChange)
+	for _, g := range gradients {
+		if g.Pen < 0 || g.Pen > BorderPen {
+			return nil, nil, ErrorBadPen
+		}
+		if len(g.Colors) == 0 || g.Line < 0 || g.Line+len(g.Colors) > FrameLines {
+			return nil, nil, ErrorBadGradient
+		}
+		for i, c := range g.Colors {
+			v := plusValue(c)
+			if i == 0 {
+				initial = append(initial, PenChange{Address: penAddress(g.Pen), Value: v})
+			} else if v != current[g.Pen] {
+				byLine[g.Line+i] = append(byLine[g.Line+i], PenChange{Address: penAddress(g.Pen), Value: v})
+			}
+			current[g.Pen] = v
+		}
+	}
+	lines := make([]LineChanges, 0, len(byLine))
+	for line, changes := range byLine {
+		lines = append(lines, LineChanges{Line: line, Changes: changes})
+	}
+	sort.Slice(lines, func(i, j int) bool { return lines[i].Line < lines[j].Line })
+	return initial, lines, nil
+}
+
+// InterruptList returns the dma list which raises an interruption at each
+// line, the channel is started at the line 0. The consecutive lines are
+// repeated and the lines without change are paused.
+func InterruptList(lines []int) List {
+	l := make(List, 0)
+	next := 0 // line of the next instruction
+	for i := 0; i < len(lines); {
+		j := i + 1
+		for j < len(lines) && lines[j] == lines[j-1]+1 {
+			j++
+		}
+		line := lines[i]
+		if j-i > 2 && line-1 >= next {
+			// repeat at the previous line then interrupt on each line
+			if line-1 > next {
+				l = append(l, Pause(line-1-next))
+			}
+			l = append(l, Repeat(j-i-1), Int|Loop)
+			next = lines[j-1] + 1
+			i = j
+			continue
+		}
+		if line > next {
+			l = append(l, Pause(line-next))
+		}
+		l = append(l, Int)
+		next = line + 1
+		i++
+	}
+	return append(l, Stop)
+}
+
+// Routine returns the routine which unlocks the asic and starts the dma
+// channel 0 at each vsync, the interruptions of the list write the colors of
+// the next line of the table (number of pens then address and value of each
+// pen) in the asic palette.
+func Routine(gradients []Gradient, mode uint8) ([]byte, string, error) {
+	initial, lines, err := Changes(gradients)
+	if err != nil {
+		return nil, "", err
+	}
+	c := raster.NewCode(RoutineAddress)
+	c.Emit(1, "di", 0xF3)
+	c.UnlockAsic()
+	EmitChanges(c, initial, lines, mode)
+	if c.Address() > himem {
+		return nil, "", ErrorRoutineTooLong
+	}
+	return c.Bytes(), c.Listing(), nil
+}
+
+// EmitChanges adds to the code, once the asic is unlocked, the frame loop
+// setting the initial colors and starting the dma channel 0 at each vsync,
+// the interruption handler, the table of the changes and the dma list.
+func EmitChanges(c *raster.Code, initial []PenChange, lines []LineChanges, mode uint8) {
+	c.Emit(3, fmt.Sprintf("ld bc,#7F%.2X", 0x8C|mode), 0x01, 0x8C|mode, 0x7F)
+	c.Emit(4, "out (c),c", 0xED, 0x49)
+	c.Comment("interruption handler at #0038")
+	c.Emit(2, "im 1", 0xED, 0x56)
+	c.Emit(2, "ld a,#C3", 0x3E, 0xC3)
+	c.Emit(4, "ld (#0038),a", 0x32, 0x38, 0x00)
+	handlerAddress := c.Address() + 1
+	c.Emit(3, "ld hl,handler", 0x21, 0, 0)
+	c.Emit(5, "ld (#0039),hl", 0x22, 0x39, 0x00)
+	c.Emit(1, "xor a", 0xAF)
+	c.Emit(4, "ld (#6C02),a", 0x32, 0x02, 0x6C)
+
+	frame := c.Address()
+	c.Label("frame")
+	c.WaitVsync()
+	c.Emit(1, "di", 0xF3)
+	tableAddress := c.Address() + 1
+	c.Emit(3, "ld hl,table", 0x21, 0, 0)
+	c.Emit(5, "ld (pointer),hl", 0x22, 0, 0)
+	pointerPatches := []int{c.Address() - 2}
+	for _, v := range initial {
+		c.Emit(3, fmt.Sprintf("ld hl,#%.4X", v.Value), 0x21, byte(v.Value), byte(v.Value>>8))
+		c.Emit(5, fmt.Sprintf("ld (#%.4X),hl", v.Address), 0x22, byte(v.Address), byte(v.Address>>8))
+	}
+	c.Comment("start the channel 0")
+	listAddress := c.Address() + 1
+	c.Emit(3, "ld hl,list", 0x21, 0, 0)
+	c.Emit(5, "ld (#6C00),hl", 0x22, 0x00, 0x6C)
+	c.Emit(2, "ld a,#41", 0x3E, 0x41)
+	c.Emit(4, "ld (#6C0F),a", 0x32, 0x0F, 0x6C)
+	c.Emit(1, "ei", 0xFB)
+	c.Emit(3, "jp frame", 0xC3, byte(frame), byte(frame>>8))
+
+	c.Patch16(handlerAddress, uint16(c.Address()))
+	c.Label("handler")
+	c.Emit(4, "push af", 0xF5)
+	c.Emit(4, "push bc", 0xC5)
+	c.Emit(4, "push de", 0xD5)
+	c.Emit(4, "push hl", 0xE5)
+	c.Comment("only the interruptions of the channel 0")
+	c.Emit(4, "ld a,(#6C0F)", 0x3A, 0x0F, 0x6C)
+	c.Emit(2, "and #40", 0xE6, 0x40)
+	c.Emit(2, "jr z,exit", 0x28, 0x19)
+	c.Emit(2, "ld a,#41", 0x3E, 0x41)
+	c.Emit(4, "ld (#6C0F),a", 0x32, 0x0F, 0x6C)
+	c.Emit(5, "ld hl,(pointer)", 0x2A, 0, 0)
+	pointerPatches = append(pointerPatches, c.Address()-2)
+	c.Emit(2, "ld b,(hl)", 0x46)
+	// ldi decrements bc, b is kept as long as c is not zero
+	c.Emit(2, "ld c,#FF", 0x0E, 0xFF)
+	c.Emit(2, "inc hl", 0x23)
+	c.Label("pens")
+	c.Emit(2, "ld e,(hl)", 0x5E)
+	c.Emit(2, "inc hl", 0x23)
+	c.Emit(2, "ld d,(hl)", 0x56)
+	c.Emit(2, "inc hl", 0x23)
+	c.Emit(2, "ldi", 0xED, 0xA0)
+	c.Emit(2, "ldi", 0xED, 0xA0)
+	c.Emit(4, "djnz pens", 0x10, 0xF6)
+	c.Emit(5, "ld (pointer),hl", 0x22, 0, 0)
+	pointerPatches = append(pointerPatches, c.Address()-2)
+	c.Label("exit")
+	c.Emit(3, "pop hl", 0xE1)
+	c.Emit(3, "pop de", 0xD1)
+	c.Emit(3, "pop bc", 0xC1)
+	c.Emit(3, "pop af", 0xF1)
+	c.Emit(1, "ei", 0xFB)
+	c.Emit(3, "ret", 0xC9)
+
+	for _, v := range pointerPatches {
+		c.Patch16(v, uint16(c.Address()))
+	}
+	c.Label("pointer")
+	c.Emit(0, "dw 0", 0, 0)
+	c.Patch16(tableAddress, uint16(c.Address()))
+	c.Label("table")
+	interrupts := make([]int, len(lines))
+	for i, v := range lines {
+		interrupts[i] = v.Line
+		table := []byte{byte(len(v.Changes))}
+		for _, p := range v.Changes {
+			table = append(table, byte(p.Address), byte(p.Address>>8), byte(p.Value), byte(p.Value>>8))
+		}
+		c.Emit(0, fmt.Sprintf("; line %d, %d pens", v.Line, len(v.Changes)), table...)
+	}
+	// the dma lists are word aligned
+	if c.Address()%2 != 0 {
+		c.Emit(0, "db 0", 0)
+	}
+	c.Patch16(listAddress, uint16(c.Address()))
+	c.Label("list")
+	for _, v := range InterruptList(interrupts) {
+		c.Emit(0, fmt.Sprintf("dw #%.4X ; %s", uint16(v), v.String()), byte(v), byte(v>>8))
+	}
+}
+
+// Loader returns an ascii basic loader of the routine.
+func Loader(routineFile string, mode uint8) string {
+	return fmt.Sprintf("10 MODE %d:MEMORY &%X\r\n20 LOAD\"%s\",&%X\r\n30 CALL &%X\r\n",
+		mode, RoutineAddress-1, routineFile, RoutineAddress, RoutineAddress)
+}
+
+// ExportDma saves the dma list (.DMA) with its listing (.DMS), the routine
+// (.RST) with its listing (.ASM) and the basic loader (-DMA.BAS).
+func ExportDma(filePath string, gradients []Gradient, mode uint8, cfg *config.MartineConfig) error {
+	_, lines, err := Changes(gradients)
+	if err != nil {
+		return err
+	}
+	interrupts := make([]int, len(lines))
+	for i, v := range lines {
+		interrupts[i] = v.Line
+	}
+	list := InterruptList(interrupts)
+	listPath := cfg.AmsdosFullPath(filePath, ".DMA")
+	fmt.Fprintf(os.Stdout, "Saving dma list (%s) %d instructions for %d lines\n", listPath, len(list), len(lines))
+	if err := amsdos.SaveAmsdosFile(listPath, ".DMA", list.Bytes(), 2, 0, 0, 0); err != nil {
+		return err
+	}
+	cfg.AddFile(listPath)
+	if err := amsdos.SaveStringOSFile(cfg.AmsdosFullPath(filePath, ".DMS"), list.Asm()); err != nil {
+		return err
+	}
+
+	routine, listing, err := Routine(gradients, mode)
+	if err != nil {
+		return err
+	}
+	routinePath := cfg.AmsdosFullPath(filePath, ".RST")
+	fmt.Fprintf(os.Stdout, "Saving dma routine (%s) %d bytes\n", routinePath, len(routine))
+	if err := amsdos.SaveAmsdosFile(routinePath, ".RST", routine, 2, 0, RoutineAddress, RoutineAddress); err != nil {
+		return err
+	}
+	cfg.AddFile(routinePath)
+	if err := amsdos.SaveStringOSFile(cfg.AmsdosFullPath(filePath, ".ASM"), listing); err != nil {
+		return err
+	}
+
+	loaderPath := filepath.Join(cfg.OutputPath, "-DMA.BAS")
+	if err := amsdos.SaveStringOSFile(loaderPath, Loader(filepath.Base(routinePath), mode)); err != nil {
+		return err
+	}
+	cfg.AddFile(loaderPath)
+	return nil
+}
@@ -0,0 +1,56 @@
+package plusdma
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestInterruptList(t *testing.T) {
+	l := InterruptList([]int{10, 11, 12, 13, 20, 21})
+	expected := List{Pause(9), Repeat(3), Int | Loop, Pause(6), Int, Int, Stop}
+	if len(l) != len(expected) {
+		t.Fatalf("expected %v and gets %v", expected, l)
+	}
+	for i := range l {
+		if l[i] != expected[i] {
+			t.Fatalf("instruction %d expected %s and gets %s", i, expected[i], l[i])
+		}
+	}
+	if !bytes.Equal(List{Pause(9), Load(7, 0x3F)}.Bytes(), []byte{0x09, 0x10, 0x3F, 0x07}) {
+		t.Fatalf("unexpected list bytes")
+	}
+	if (Int | Loop).String() != "loop:int" {
+		t.Fatalf("unexpected mnemonic %s", (Int | Loop).String())
+	}
+}
+
+func TestKeyframes(t *testing.T) {
+	g, err := ParseKeyframes(BorderPen, "110:F00,100:000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Line != 100 || len(g.Colors) != 11 {
+		t.Fatalf("expected 11 colors from the line 100 and gets %d from %d", len(g.Colors), g.Line)
+	}
+	if v := plusValue(g.Colors[5]); v != 0x0080 {
+		t.Fatalf("expected the middle red #080 and gets #%.3X", v)
+	}
+	if _, err := ParseKeyframes(BorderPen, "100:FF00"); err != ErrorBadKeyframe {
+		t.Fatalf("expected a bad keyframe error and gets %v", err)
+	}
+
+	initial, lines, err := Changes([]Gradient{g})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(initial) != 1 || initial[0].Address != 0x6420 || initial[0].Value != 0 {
+		t.Fatalf("unexpected initial border color %v", initial)
+	}
+	// the red increases at each line
+	if len(lines) == 0 || lines[0].Line != 101 {
+		t.Fatalf("unexpected changes %v", lines)
+	}
+	if _, _, err := Routine([]Gradient{g}, 0); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,252 @@
+package plusraster
+
+import (
+	"errors"
+	"fmt"
+	"image/color"
+	"os"
+	"path/filepath"
+
+	"github.com/jeromelesaux/martine/config"
+	"github.com/jeromelesaux/martine/constants"
+	"github.com/jeromelesaux/martine/export/amsdos"
+	"github.com/jeromelesaux/martine/export/impdraw/plusdma"
+	"github.com/jeromelesaux/martine/export/impdraw/raster"
+	"github.com/jeromelesaux/martine/export/spritehard"
+)
+
+var (
+	ErrorTooManyChanges = errors.New("too many palette changes on the line")
+	ErrorBadLine        = errors.New("palette change out of the frame")
+	ErrorRoutineTooLong = errors.New("routine overwrites the firmware memory")
+)
+
+const (
+	// RoutineAddress is the loading and execution address of the routine.
+	RoutineAddress = 0x8000
+	// MaxChangesByLine is the number of pens which can be changed by the
+	// interruption in the horizontal border of a line.
+	MaxChangesByLine = 3
+	// first displayed line after the start of the vsync (R4=38, R7=30)
+	firstLineDelay = 72
+	// highest address available under the firmware
+	himem = 0xA67B
+)
+
+// PenChange is a new color of the pen from the line.
+type PenChange struct {
+	Line  int
+	Pen   int
+	Color color.Color
+}
+
+// Sprite is a hardware sprite displayed with a magnification of 4 in width
+// (one sprite pixel by mode 0 pixel) at the position in mode 0 pixels.
+type Sprite struct {
+	X, Y int
+	Data spritehard.SpriteHard
+}
+
+// Screen contains the palette of the first line, the palette changes of the
+// following lines, the sprites and their palette (15 colors).
+type Screen struct {
+	Palette       color.Palette
+	Changes       []PenChange
+	Sprites       []Sprite
+	SpritePalette color.Palette
+}
+
+func plusValue(c color.Color) uint16 {
+	cp := constants.NewCpcPlusColor(c)
+	return cp.Value()
+}
+
+func (c *plusCode) setColor(address int, col color.Color) {
+	v := plusValue(col)
+	c.Emit(3, fmt.Sprintf("ld hl,#%.4X", v), 0x21, byte(v), byte(v>>8))
+	c.Emit(5, fmt.Sprintf("ld (#%.4X),hl", address), 0x22, byte(address), byte(address>>8))
+}
+
+// plusCode adds the asic instructions to the raster code.
+type plusCode struct {
+	*raster.Code
+}
+
+// Routine returns the routine which unlocks the asic, copies the sprites,
+// then changes the pens with the dma list of the channel 0 at every frame.
+// The dma channels only write the registers of the sound chip, so the list
+// raises an interruption at the end of the line before each change and the
+// interruption writes the new colors in the asic palette (see plusdma).
+func Routine(s Screen, mode uint8) ([]byte, string, error) {
+	initial, lines, err := Changes(s)
+	if err != nil {
+		return nil, "", err
+	}
+	c := &plusCode{raster.NewCode(RoutineAddress)}
+	c.Emit(1, "di", 0xF3)
+	c.UnlockAsic()
+	c.Comment("unpack the sprites in the asic, two pixels by byte")
+	spritesAddress := 0
+	if len(s.Sprites) > 0 {
+		spritesAddress = c.Address() + 1
+		c.Emit(3, "ld hl,sprites", 0x21, 0, 0)
+		c.Emit(3, "ld de,#4000", 0x11, 0x00, 0x40)
+		size := len(s.Sprites) * 128
+		c.Emit(3, fmt.Sprintf("ld bc,#%.4X", size), 0x01, byte(size), byte(size>>8))
+		c.Label("unpack")
+		c.Emit(2, "ld a,(hl)", 0x7E)
+		c.Emit(4, "rrca:rrca:rrca:rrca", 0x0F, 0x0F, 0x0F, 0x0F)
+		c.Emit(2, "and #0F", 0xE6, 0x0F)
+		c.Emit(2, "ld (de),a", 0x12)
+		c.Emit(2, "inc de", 0x13)
+		c.Emit(2, "ld a,(hl)", 0x7E)
+		c.Emit(2, "and #0F", 0xE6, 0x0F)
+		c.Emit(2, "ld (de),a", 0x12)
+		c.Emit(2, "inc de", 0x13)
+		c.Emit(2, "inc hl", 0x23)
+		c.Emit(2, "dec bc", 0x0B)
+		c.Emit(1, "ld a,b", 0x78)
+		c.Emit(1, "or c", 0xB1)
+		c.Emit(29*size-26, "jr nz,unpack", 0x20, 0xEC)
+	}
+	for i := 0; i < 16; i++ {
+		address := 0x6000 + i*8
+		var x, y int
+		var mag byte
+		if i < len(s.Sprites) {
+			// x in mode 2 pixels, magnification x4 in width, x1 in height
+			x, y, mag = s.Sprites[i].X*4, s.Sprites[i].Y, 0x0D
+		}
+		c.Emit(3, fmt.Sprintf("ld hl,%d", x), 0x21, byte(x), byte(x>>8))
+		c.Emit(5, fmt.Sprintf("ld (#%.4X),hl", address), 0x22, byte(address), byte(address>>8))
+		c.Emit(3, fmt.Sprintf("ld hl,%d", y), 0x21, byte(y), byte(y>>8))
+		c.Emit(5, fmt.Sprintf("ld (#%.4X),hl", address+2), 0x22, byte(address+2), byte((address+2)>>8))
+		c.Emit(2, fmt.Sprintf("ld a,#%.2X", mag), 0x3E, mag)
+		c.Emit(4, fmt.Sprintf("ld (#%.4X),a", address+4), 0x32, byte(address+4), byte((address+4)>>8))
+	}
+	for i, col := range s.SpritePalette {
+		c.setColor(0x6422+i*2, col)
+	}
+	c.setColor(0x6420, color.Black)
+	plusdma.EmitChanges(c.Code, initial, lines, mode)
+
+	if spritesAddress != 0 {
+		c.Patch16(spritesAddress, uint16(c.Address()))
+		c.Label("sprites")
+	}
+	for i, sp := range s.Sprites {
+		c.Emit(0, fmt.Sprintf("; sprite %d (%d,%d) 128 bytes", i, sp.X, sp.Y), packSprite(sp.Data)...)
+	}
+	if c.Address() > himem {
+		return nil, "", ErrorRoutineTooLong
+	}
+	return c.Bytes(), c.Listing(), nil
+}
+
+// packSprite returns the pixels of the sprite, two pixels by byte.
+func packSprite(s spritehard.SpriteHard) []byte {
+	packed := make([]byte, len(s.Data)/2)
+	for i := range packed {
+		packed[i] = s.Data[i*2]<<4 | s.Data[i*2+1]&0x0F
+	}
+	return packed
+}
+
+// Changes returns the colors of the pens at the start of the frame and the
+// changes of the pens by line of the frame, counted from the start of the
+// vsync, the changes of a screen line are made at the end of the previous
+// line.
+func Changes(s Screen) ([]plusdma.PenChange, []plusdma.LineChanges, error) {
+	initial := make([]plusdma.PenChange, 0, len(s.Palette))
+	for pen, col := range s.Palette {
+		initial = append(initial, plusdma.PenChange{Address: uint16(0x6400 + pen*2), Value: plusValue(col)})
+	}
+	lines := make([]plusdma.LineChanges, 0)
+	for i := 0; i < len(s.Changes); {
+		j := i
+		for j < len(s.Changes) && s.Changes[j].Line == s.Changes[i].Line {
+			j++
+		}
+		if j-i > MaxChangesByLine {
+			return nil, nil, ErrorTooManyChanges
+		}
+		line := plusdma.LineChanges{Line: firstLineDelay + s.Changes[i].Line - 1}
+		if line.Line < 1 || line.Line >= plusdma.FrameLines || (len(lines) > 0 && line.Line <= lines[len(lines)-1].Line) {
+			return nil, nil, ErrorBadLine
+		}
+		for ; i < j; i++ {
+			line.Changes = append(line.Changes, plusdma.PenChange{Address: uint16(0x6400 + s.Changes[i].Pen*2), Value: plusValue(s.Changes[i].Color)})
+		}
+		lines = append(lines, line)
+	}
+	return initial, lines, nil
+}
+
+// DmaList returns the dma list of the channel 0 raising the interruptions of
+// the palette changes.
+func DmaList(s Screen) (plusdma.List, error) {
+	_, lines, err := Changes(s)
+	if err != nil {
+		return nil, err
+	}
+	interrupts := make([]int, len(lines))
+	for i, v := range lines {
+		interrupts[i] = v.Line
+	}
+	return plusdma.InterruptList(interrupts), nil
+}
+
+// Loader returns an ascii basic loader of the screen and the routine.
+func Loader(screenFile, routineFile string, mode uint8) string {
+	return fmt.Sprintf("10 MODE %d:MEMORY &%X\r\n20 LOAD\"%s\",&C000\r\n30 LOAD\"%s\",&%X\r\n40 CALL &%X\r\n",
+		mode, RoutineAddress-1, screenFile, routineFile, RoutineAddress, RoutineAddress)
+}
+
+// ExportPlusRaster saves the sprites (.SPR), the dma list of the palette
+// changes (.DMA) with its listing (.DMS), the routine (.RST) with its listing
+// (.ASM) and the basic loader (-PLUS.BAS).
+func ExportPlusRaster(filePath string, s Screen, mode uint8, cfg *config.MartineConfig) error {
+	spr := spritehard.SprImpdraw{Data: make([]spritehard.SpriteHard, 0)}
+	for _, v := range s.Sprites {
+		spr.Data = append(spr.Data, v.Data)
+	}
+	if err := spritehard.Spr(filePath, spr, cfg); err != nil {
+		return err
+	}
+	cfg.AddFile(cfg.AmsdosFullPath(filePath, ".SPR"))
+
+	list, err := DmaList(s)
+	if err != nil {
+		return err
+	}
+	listPath := cfg.AmsdosFullPath(filePath, ".DMA")
+	fmt.Fprintf(os.Stdout, "Saving dma list (%s) %d instructions for %d changes\n", listPath, len(list), len(s.Changes))
+	if err := amsdos.SaveAmsdosFile(listPath, ".DMA", list.Bytes(), 2, 0, 0, 0); err != nil {
+		return err
+	}
+	cfg.AddFile(listPath)
+	if err := amsdos.SaveStringOSFile(cfg.AmsdosFullPath(filePath, ".DMS"), list.Asm()); err != nil {
+		return err
+	}
+
+	routine, listing, err := Routine(s, mode)
+	if err != nil {
+		return err
+	}
+	routinePath := cfg.AmsdosFullPath(filePath, ".RST")
+	fmt.Fprintf(os.Stdout, "Saving raster routine (%s) %d bytes\n", routinePath, len(routine))
+	if err := amsdos.SaveAmsdosFile(routinePath, ".RST", routine, 2, 0, RoutineAddress, RoutineAddress); err != nil {
+		return err
+	}
+	cfg.AddFile(routinePath)
+	if err := amsdos.SaveStringOSFile(cfg.AmsdosFullPath(filePath, ".ASM"), listing); err != nil {
+		return err
+	}
+
+	loaderPath := filepath.Join(cfg.OutputPath, "-PLUS.BAS")
+	if err := amsdos.SaveStringOSFile(loaderPath, Loader(filepath.Base(cfg.AmsdosFullPath(filePath, ".SCR")), filepath.Base(routinePath), mode)); err != nil {
+		return err
+	}
+	cfg.AddFile(loaderPath)
+	return nil
+}
@@ -0,0 +1,74 @@
+package plusraster
+
+import (
+	"bytes"
+	"image/color"
+	"testing"
+
+	"github.com/jeromelesaux/martine/export/impdraw/plusdma"
+	"github.com/jeromelesaux/martine/export/impdraw/raster"
+)
+
+func TestRoutine(t *testing.T) {
+	p := make(color.Palette, 16)
+	for i := range p {
+		p[i] = color.NRGBA{R: uint8(i * 16), A: 0xff}
+	}
+	s := Screen{
+		Palette: p,
+		Changes: []PenChange{
+			{Line: 10, Pen: 1, Color: color.NRGBA{G: 0xF0, A: 0xff}},
+			{Line: 10, Pen: 2, Color: color.NRGBA{B: 0xF0, A: 0xff}},
+			{Line: 11, Pen: 1, Color: color.NRGBA{R: 0xF0, A: 0xff}},
+		},
+		Sprites:       []Sprite{{X: 8, Y: 20}},
+		SpritePalette: color.Palette{color.White},
+	}
+	s.Sprites[0].Data.Data[0], s.Sprites[0].Data.Data[1] = 1, 2
+	code, _, err := Routine(s, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	i := bytes.Index(code, raster.AsicUnlockSequence)
+	if i < 0 {
+		t.Fatalf("expected the asic unlock sequence in the routine")
+	}
+	if sequence := int(code[i-11]) | int(code[i-10])<<8; sequence != RoutineAddress+i {
+		t.Fatalf("expected the sequence at #%.4X and gets #%.4X", RoutineAddress+i, sequence)
+	}
+	if !bytes.HasSuffix(code, packSprite(s.Sprites[0].Data)) {
+		t.Fatalf("expected the sprite data at the end of the routine")
+	}
+	if packSprite(s.Sprites[0].Data)[0] != 0x12 {
+		t.Fatalf("expected two pixels by byte")
+	}
+
+	initial, lines, err := Changes(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the changes of the line 10 are made at the end of the previous line
+	if len(initial) != 16 || len(lines) != 2 || lines[0].Line != firstLineDelay+9 || len(lines[0].Changes) != 2 || lines[1].Line != firstLineDelay+10 {
+		t.Fatalf("unexpected changes %v %v", initial, lines)
+	}
+	if lines[0].Changes[0].Address != 0x6402 || lines[0].Changes[0].Value != 0x0F00 {
+		t.Fatalf("unexpected first change %v", lines[0].Changes[0])
+	}
+	list, err := DmaList(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := plusdma.List{plusdma.Pause(firstLineDelay + 9), plusdma.Int, plusdma.Int, plusdma.Stop}
+	if !bytes.Equal(list.Bytes(), expected.Bytes()) {
+		t.Fatalf("expected the dma list %v and gets %v", expected, list)
+	}
+	if !bytes.Contains(code, list.Bytes()) || !bytes.Contains(code, []byte{0x32, 0x0F, 0x6C}) {
+		t.Fatalf("expected the dma list and the start of the channel 0 in the routine")
+	}
+
+	s.Changes = append(s.Changes, PenChange{Line: 10, Pen: 3, Color: color.Black}, PenChange{Line: 10, Pen: 4, Color: color.Black})
+	s.Changes[2], s.Changes[4] = s.Changes[4], s.Changes[2]
+	if _, _, err := Routine(s, 0); err != ErrorTooManyChanges {
+		t.Fatalf("expected too many changes error and gets %v", err)
+	}
+}
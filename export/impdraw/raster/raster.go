@@ -0,0 +1,181 @@
+// Package raster assembles the Z80 routines of the raster effects, the
+// duration of each instruction is counted in nops (1 nop is 1µs, a line lasts
+// 64 nops).
+package raster
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// NopsByLine is the duration of a line.
+	NopsByLine = 64
+	// HaltSyncLine is the line of the interruption waited by HaltSync after
+	// ResetInterrupts, counted from the start of the vsync.
+	HaltSyncLine = 54
+)
+
+// AsicUnlockSequence is sent to the crtc to unlock the asic of the cpc plus.
+var AsicUnlockSequence = []byte{0xFF, 0x00, 0xFF, 0x77, 0xB3, 0x51, 0xA8, 0xD4, 0x62, 0x39, 0x9C, 0x46, 0x2B, 0x15, 0x8A, 0xCD, 0xEE}
+
+// Code keeps the opcodes, the listing and the duration of the emitted
+// instructions.
+type Code struct {
+	org     int
+	bytes   []byte
+	listing []string
+	nops    int
+}
+
+// NewCode returns an empty routine assembled at the org address.
+func NewCode(org int) *Code {
+	return &Code{org: org, listing: []string{fmt.Sprintf("\torg #%.4X", org)}}
+}
+
+// Emit appends the opcodes of the instruction which lasts nops.
+func (c *Code) Emit(nops int, asm string, b ...byte) {
+	c.listing = append(c.listing, fmt.Sprintf("\t%-24s ; #%.4X", asm, c.Address()))
+	c.bytes = append(c.bytes, b...)
+	c.nops += nops
+}
+
+func (c *Code) Label(name string) {
+	c.listing = append(c.listing, name+":")
+}
+
+func (c *Code) Comment(text string) {
+	c.listing = append(c.listing, "\t; "+text)
+}
+
+// Address returns the address of the next instruction.
+func (c *Code) Address() int {
+	return c.org + len(c.bytes)
+}
+
+// Nops returns the duration since the last ResetNops.
+func (c *Code) Nops() int {
+	return c.nops
+}
+
+func (c *Code) ResetNops() {
+	c.nops = 0
+}
+
+// Patch16 replaces the word at the address.
+func (c *Code) Patch16(address int, value uint16) {
+	c.bytes[address-c.org] = byte(value)
+	c.bytes[address-c.org+1] = byte(value >> 8)
+}
+
+func (c *Code) Bytes() []byte {
+	return c.bytes
+}
+
+func (c *Code) Listing() string {
+	return strings.Join(c.listing, "\n") + "\n"
+}
+
+// Delay waits the number of nops with a de loop (7 nops by iteration) and
+// the remaining nops.
+func (c *Code) Delay(nops int) {
+	if nops >= 9 {
+		n := (nops - 2) / 7
+		if n > 0xFFFF {
+			n = 0xFFFF
+		}
+		c.Emit(3, fmt.Sprintf("ld de,#%.4X", n), 0x11, byte(n), byte(n>>8))
+		c.Emit(2, "dec de", 0x1B)
+		c.Emit(1, "ld a,d", 0x7A)
+		c.Emit(1, "or e", 0xB3)
+		// the de loop lasts 7*n+2 nops (the last jr is not taken)
+		c.Emit(7*n+2-3-2-1-1, "jr nz,$-3", 0x20, 0xFB)
+		nops -= 7*n + 2
+	}
+	for ; nops > 0; nops-- {
+		c.Emit(1, "nop", 0x00)
+	}
+}
+
+// WaitVsync waits the end then the start of the vsync and resets the
+// duration, b is set to #7F (gate array).
+func (c *Code) WaitVsync() {
+	c.Emit(2, "ld b,#F5", 0x06, 0xF5)
+	c.Comment("wait the end then the start of the vsync")
+	c.Emit(4, "in a,(c)", 0xED, 0x78)
+	c.Emit(1, "rra", 0x1F)
+	c.Emit(3, "jr c,$-3", 0x38, 0xFB)
+	c.Emit(4, "in a,(c)", 0xED, 0x78)
+	c.Emit(1, "rra", 0x1F)
+	c.Emit(3, "jr nc,$-3", 0x30, 0xFB)
+	c.ResetNops()
+	c.Emit(2, "ld b,#7F", 0x06, 0x7F)
+}
+
+// SetMode sets the screen mode (b must be #7F).
+func (c *Code) SetMode(mode uint8) {
+	c.Emit(2, fmt.Sprintf("ld a,#%.2X", 0x8C|mode), 0x3E, 0x8C|mode)
+	c.Emit(4, "out (c),a", 0xED, 0x79)
+}
+
+// SetInk sets the hardware color of the pen (b must be #7F).
+func (c *Code) SetInk(pen int, hardware byte) {
+	c.Emit(2, fmt.Sprintf("ld a,#%.2X", pen), 0x3E, byte(pen))
+	c.Emit(4, "out (c),a", 0xED, 0x79)
+	c.Emit(2, fmt.Sprintf("ld a,#%.2X", hardware), 0x3E, hardware)
+	c.Emit(4, "out (c),a", 0xED, 0x79)
+}
+
+// LoadFile loads the file at the address with the firmware (the filename is
+// stored at the address returned and must be patched).
+func (c *Code) LoadFile(length int, address uint16) int {
+	c.Emit(2, fmt.Sprintf("ld b,%d", length), 0x06, byte(length))
+	nameAddress := c.Address() + 1
+	c.Emit(3, "ld hl,filename", 0x21, 0, 0)
+	c.Emit(3, "ld de,#9000", 0x11, 0x00, 0x90)
+	c.Emit(5, "call #BC77", 0xCD, 0x77, 0xBC)
+	c.Emit(3, fmt.Sprintf("ld hl,#%.4X", address), 0x21, byte(address), byte(address>>8))
+	c.Emit(5, "call #BC83", 0xCD, 0x83, 0xBC)
+	c.Emit(5, "call #BC7A", 0xCD, 0x7A, 0xBC)
+	return nameAddress
+}
+
+// UnlockAsic unlocks the asic and maps its registers from #4000 to #7FFF.
+func (c *Code) UnlockAsic() {
+	c.Comment("unlock the asic")
+	c.Emit(3, fmt.Sprintf("ld bc,#BC%.2X", len(AsicUnlockSequence)), 0x01, byte(len(AsicUnlockSequence)), 0xBC)
+	// the sequence follows the 12 bytes of the loop
+	sequence := c.Address() + 12
+	c.Emit(3, fmt.Sprintf("ld hl,#%.4X", sequence), 0x21, byte(sequence), byte(sequence>>8))
+	c.Emit(2, "ld a,(hl)", 0x7E)
+	c.Emit(4, "out (c),a", 0xED, 0x79)
+	c.Emit(2, "inc hl", 0x23)
+	c.Emit(1, "dec c", 0x0D)
+	c.Emit(3, "jr nz,$-5", 0x20, 0xF9)
+	c.Emit(3, fmt.Sprintf("jr $+%d", len(AsicUnlockSequence)+2), 0x18, byte(len(AsicUnlockSequence)))
+	c.Emit(0, "db #FF,#00,#FF,#77,#B3,#51,#A8,#D4,#62,#39,#9C,#46,#2B,#15,#8A,#CD,#EE", AsicUnlockSequence...)
+	c.Emit(3, "ld bc,#7FB8", 0x01, 0xB8, 0x7F)
+	c.Emit(4, "out (c),c", 0xED, 0x49)
+}
+
+// ResetInterrupts must follow WaitVsync, it resets the interruption counter
+// of the gate array and the pending interruption, the next interruption
+// occurs at HaltSyncLine (b must be #7F).
+func (c *Code) ResetInterrupts(mode uint8) {
+	c.Emit(2, fmt.Sprintf("ld a,#%.2X", 0x9C|mode), 0x3E, 0x9C|mode)
+	c.Emit(4, "out (c),a", 0xED, 0x79)
+}
+
+// HaltSync waits the interruption at HaltSyncLine with halt to remove the
+// jitter of the vsync polling. The interruption handler must be "ei:ret" and
+// the interruptions are disabled after.
+func (c *Code) HaltSync() {
+	c.Emit(1, "ei", 0xFB)
+	// the interruption lasts 5 nops (rst #38) then 4 nops (ei:ret)
+	halt := HaltSyncLine*NopsByLine + 9 - c.Nops()
+	if halt < 1 {
+		halt = 1
+	}
+	c.Emit(halt, "halt", 0x76)
+	c.Emit(1, "di", 0xF3)
+}
@@ -10,12 +10,13 @@ type JsonSlice struct {
 }
 
 type Json struct {
-	Label           string   `json:"label"`
-	Width           int      `json:"width"`
-	Height          int      `json:"height"`
-	Screen          []string `json:"screen"`
-	Palette         []string `json:"palette"`
-	HardwarePalette []string `json:"hardwarepalette"`
+	Label           string     `json:"label"`
+	Width           int        `json:"width"`
+	Height          int        `json:"height"`
+	Screen          []string   `json:"screen"`
+	Palette         []string   `json:"palette"`
+	HardwarePalette []string   `json:"hardwarepalette"`
+	Collision       *Collision `json:"collision,omitempty"`
 }
 
 func NewJson(label string, width int, height int, screen []string, palette []string, hardwarepalette []string) *Json {
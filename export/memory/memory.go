@@ -0,0 +1,345 @@
+package memory
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+var (
+	ErrorOverlap      = errors.New("memory areas overlap")
+	ErrorNoFreeMemory = errors.New("no free memory to place the item")
+	ErrorBankNotFound = errors.New("bank not available on this machine")
+	ErrorOutOfWindow  = errors.New("address out of the bank window")
+)
+
+type Machine int
+
+var (
+	Cpc464  Machine = 464
+	Cpc6128 Machine = 6128
+)
+
+// BankWindowStart and BankWindowEnd are the limits of the window where the
+// extra banks (4 to 7) of the 6128 are connected with the C4 to C7
+// configurations.
+const (
+	BankWindowStart = 0x4000
+	BankWindowEnd   = 0x8000
+	BankSize        = 0x4000
+	MainBank        = 0
+	AnyBank         = -1
+)
+
+// RamConfiguration is a gate array ram configuration (C0 to C7), it gives
+// the physical blocks of 16Ko seen at #0000, #4000, #8000 and #C000.
+type RamConfiguration uint8
+
+var RamConfigurations = [8][4]int{
+	{0, 1, 2, 3}, // C0
+	{0, 1, 2, 7}, // C1
+	{4, 5, 6, 7}, // C2
+	{0, 3, 2, 7}, // C3
+	{0, 4, 2, 3}, // C4
+	{0, 5, 2, 3}, // C5
+	{0, 6, 2, 3}, // C6
+	{0, 7, 2, 3}, // C7
+}
+
+// Blocks returns the physical blocks seen by the Z80 in this configuration.
+func (r RamConfiguration) Blocks() [4]int {
+	return RamConfigurations[r&7]
+}
+
+// GateArrayValue returns the value to send to the gate array (out #7Fxx).
+func (r RamConfiguration) GateArrayValue() uint8 {
+	return 0xC0 | uint8(r&7)
+}
+
+func (r RamConfiguration) String() string {
+	return fmt.Sprintf("C%d", uint8(r&7))
+}
+
+// BankConfiguration returns the configuration connecting the extra bank
+// (4 to 7) in the #4000 window.
+func BankConfiguration(bank int) RamConfiguration {
+	if bank < 4 || bank > 7 {
+		return 0
+	}
+	return RamConfiguration(bank)
+}
+
+type ScreenArea int
+
+var (
+	NoScreen       ScreenArea = 0
+	ScreenC000     ScreenArea = 1
+	Screen4000     ScreenArea = 2
+	DoubleScreen   ScreenArea = 3 // #4000 and #C000 swapped
+	OverscanScreen ScreenArea = 4 // martine overscan #0170 (header) to #8000
+)
+
+type ItemKind int
+
+var (
+	DataItem   ItemKind = 0
+	CodeItem   ItemKind = 1
+	SpriteBank ItemKind = 2
+)
+
+// Area is a reserved memory area [Start, End[ in a bank.
+type Area struct {
+	Name  string
+	Start int
+	End   int
+	Bank  int
+}
+
+// Item is a block to place in the memory, Address -1 means that the planner
+// chooses the address, Bank is AnyBank, MainBank or an extra bank (4 to 7).
+type Item struct {
+	Name    string
+	Size    int
+	Address int
+	Bank    int
+	Kind    ItemKind
+	Align   int
+}
+
+func NewItem(name string, size int, kind ItemKind) *Item {
+	return &Item{Name: name, Size: size, Address: -1, Bank: AnyBank, Kind: kind, Align: 1}
+}
+
+// Placement is the result of the planner for an item.
+type Placement struct {
+	Item    *Item
+	Address uint16
+	Bank    int
+	Config  RamConfiguration
+}
+
+func (p *Placement) End() int {
+	return int(p.Address) + p.Item.Size
+}
+
+// Map is the memory layout of a machine with the reserved areas and the
+// items placed.
+type Map struct {
+	Machine    Machine
+	Screen     ScreenArea
+	Reserved   []*Area
+	Placements []*Placement
+}
+
+func NewMap(machine Machine, screen ScreenArea) *Map {
+	m := &Map{
+		Machine:    machine,
+		Screen:     screen,
+		Reserved:   make([]*Area, 0),
+		Placements: make([]*Placement, 0),
+	}
+	m.Reserve("firmware", 0x0000, 0x0040, MainBank)
+	m.Reserve("system", 0xA67C, 0xC000, MainBank)
+	switch screen {
+	case ScreenC000:
+		m.Reserve("screen", 0xC000, 0x10000, MainBank)
+	case Screen4000:
+		m.Reserve("screen", 0x4000, 0x8000, MainBank)
+	case DoubleScreen:
+		m.Reserve("screen", 0x4000, 0x8000, MainBank)
+		m.Reserve("screen", 0xC000, 0x10000, MainBank)
+	case OverscanScreen:
+		m.Reserve("screen", 0x0170, 0x8000, MainBank)
+	}
+	return m
+}
+
+// Banks returns the extra banks of the machine.
+func (m *Map) Banks() []int {
+	if m.Machine == Cpc6128 {
+		return []int{4, 5, 6, 7}
+	}
+	return []int{}
+}
+
+func (m *Map) hasBank(bank int) bool {
+	if bank == MainBank {
+		return true
+	}
+	for _, v := range m.Banks() {
+		if v == bank {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *Map) Reserve(name string, start, end, bank int) {
+	m.Reserved = append(m.Reserved, &Area{Name: name, Start: start, End: end, Bank: bank})
+}
+
+// used returns the used areas of the bank sorted by address.
+func (m *Map) used(bank int) []*Area {
+	areas := make([]*Area, 0)
+	for _, v := range m.Reserved {
+		if v.Bank == bank {
+			areas = append(areas, v)
+		}
+	}
+	for _, v := range m.Placements {
+		if v.Bank == bank {
+			areas = append(areas, &Area{Name: v.Item.Name, Start: int(v.Address), End: v.End(), Bank: bank})
+		}
+	}
+	sort.Slice(areas, func(i, j int) bool { return areas[i].Start < areas[j].Start })
+	return areas
+}
+
+// limits returns the Z80 address limits of the bank.
+func (m *Map) limits(bank int) (int, int) {
+	if bank != MainBank {
+		return BankWindowStart, BankWindowEnd
+	}
+	return 0, 0x10000
+}
+
+// conflict returns the area overlapping [start, end[ in the bank.
+func (m *Map) conflict(bank, start, end int) *Area {
+	for _, v := range m.used(bank) {
+		if start < v.End && v.Start < end {
+			return v
+		}
+	}
+	return nil
+}
+
+// codeAllowed checks that the code stays visible while the extra banks are
+// connected in the #4000 window.
+func (m *Map) codeAllowed(it *Item, bank, start, end int) bool {
+	if it.Kind != CodeItem || bank != MainBank || len(m.Banks()) == 0 {
+		return true
+	}
+	return end <= BankWindowStart || start >= BankWindowEnd
+}
+
+func align(v, a int) int {
+	if a <= 1 {
+		return v
+	}
+	return (v + a - 1) / a * a
+}
+
+// firstFit returns the first free address for the item in the bank.
+func (m *Map) firstFit(it *Item, bank int) int {
+	min, max := m.limits(bank)
+	addr := align(min, it.Align)
+	for addr+it.Size <= max {
+		c := m.conflict(bank, addr, addr+it.Size)
+		if c == nil {
+			if m.codeAllowed(it, bank, addr, addr+it.Size) {
+				return addr
+			}
+			// jump after the bank window
+			addr = align(BankWindowEnd, it.Align)
+			continue
+		}
+		addr = align(c.End, it.Align)
+	}
+	return -1
+}
+
+func (m *Map) place(it *Item, address, bank int) *Placement {
+	p := &Placement{Item: it, Address: uint16(address), Bank: bank}
+	if bank != MainBank {
+		p.Config = BankConfiguration(bank)
+	}
+	m.Placements = append(m.Placements, p)
+	return p
+}
+
+// Place puts the items in the memory, the items with a fixed address are
+// placed first and checked against the other areas, then the others are
+// placed from the biggest to the smallest in the first free area of the
+// main memory and then of the extra banks.
+func (m *Map) Place(items []*Item) error {
+	free := make([]*Item, 0)
+	for _, it := range items {
+		if it.Address == -1 {
+			free = append(free, it)
+			continue
+		}
+		bank := it.Bank
+		if bank == AnyBank {
+			bank = MainBank
+		}
+		if !m.hasBank(bank) {
+			return fmt.Errorf("%w : bank %d for (%s)", ErrorBankNotFound, bank, it.Name)
+		}
+		min, max := m.limits(bank)
+		if it.Address < min || it.Address+it.Size > max {
+			return fmt.Errorf("%w : (%s) #%.4X-#%.4X", ErrorOutOfWindow, it.Name, it.Address, it.Address+it.Size)
+		}
+		if c := m.conflict(bank, it.Address, it.Address+it.Size); c != nil {
+			return fmt.Errorf("%w : (%s) #%.4X-#%.4X and (%s) #%.4X-#%.4X in bank %d",
+				ErrorOverlap,
+				it.Name, it.Address, it.Address+it.Size,
+				c.Name, c.Start, c.End,
+				bank)
+		}
+		m.place(it, it.Address, bank)
+	}
+	sort.SliceStable(free, func(i, j int) bool { return free[i].Size > free[j].Size })
+	for _, it := range free {
+		banks := []int{MainBank}
+		switch {
+		case it.Bank == AnyBank && it.Kind != CodeItem:
+			banks = append(banks, m.Banks()...)
+		case it.Bank != AnyBank:
+			if !m.hasBank(it.Bank) {
+				return fmt.Errorf("%w : bank %d for (%s)", ErrorBankNotFound, it.Bank, it.Name)
+			}
+			banks = []int{it.Bank}
+		}
+		placed := false
+		for _, bank := range banks {
+			if addr := m.firstFit(it, bank); addr != -1 {
+				m.place(it, addr, bank)
+				placed = true
+				break
+			}
+		}
+		if !placed {
+			return fmt.Errorf("%w : (%s) size #%.4X", ErrorNoFreeMemory, it.Name, it.Size)
+		}
+	}
+	sort.SliceStable(m.Placements, func(i, j int) bool {
+		if m.Placements[i].Bank != m.Placements[j].Bank {
+			return m.Placements[i].Bank < m.Placements[j].Bank
+		}
+		return m.Placements[i].Address < m.Placements[j].Address
+	})
+	return nil
+}
+
+// Placement returns the placement of the item named name.
+func (m *Map) Placement(name string) *Placement {
+	for _, v := range m.Placements {
+		if v.Item.Name == name {
+			return v
+		}
+	}
+	return nil
+}
+
+// Free returns the number of free bytes in the bank.
+func (m *Map) Free(bank int) int {
+	min, max := 0, 0x10000
+	if bank != MainBank {
+		min, max = 0, BankSize
+	}
+	free := max - min
+	for _, v := range m.used(bank) {
+		free -= v.End - v.Start
+	}
+	return free
+}
@@ -0,0 +1,81 @@
+package memory
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/jeromelesaux/martine/export/amsdos"
+)
+
+func TestPlaceOverlap(t *testing.T) {
+	m := NewMap(Cpc464, ScreenC000)
+	a := NewItem("title", 0x4000, DataItem)
+	a.Address = 0x4000
+	b := NewItem("sprites", 0x1000, DataItem)
+	b.Address = 0x7800
+	err := m.Place([]*Item{a, b})
+	if !errors.Is(err, ErrorOverlap) {
+		t.Fatalf("expected overlap error and gets %v", err)
+	}
+}
+
+func TestPlaceBanks(t *testing.T) {
+	m := NewMap(Cpc6128, ScreenC000)
+	items := []*Item{
+		NewItem("player", 0x800, CodeItem),
+		NewItem("anim1", 0x4000, DataItem),
+		NewItem("anim2", 0x4000, DataItem),
+		NewItem("anim3", 0x4000, DataItem),
+	}
+	if err := m.Place(items); err != nil {
+		t.Fatal(err)
+	}
+	t.Log(m.Report())
+	player := m.Placement("player")
+	if player.Bank != MainBank || (player.End() > BankWindowStart && int(player.Address) < BankWindowEnd) {
+		t.Fatalf("expected player code out of the bank window and gets #%.4X", player.Address)
+	}
+	if p := m.Placement("anim3"); p.Bank == MainBank || p.Address != BankWindowStart || p.Config.GateArrayValue() != 0xC0+uint8(p.Bank) {
+		t.Fatalf("expected anim3 in a bank and gets bank %d #%.4X", p.Bank, p.Address)
+	}
+	if !strings.Contains(m.Symbols(RasmSymbol), "anim3_bank equ #C4") {
+		t.Fatalf("expected bank symbol and gets %s", m.Symbols(RasmSymbol))
+	}
+}
+
+func TestPlaceNoFreeMemory(t *testing.T) {
+	m := NewMap(Cpc464, ScreenC000)
+	if err := m.Place([]*Item{NewItem("big", 0xB000, DataItem)}); !errors.Is(err, ErrorNoFreeMemory) {
+		t.Fatalf("expected no free memory error and gets %v", err)
+	}
+}
+
+func TestPatchAsm(t *testing.T) {
+	code := "loadingaddress equ #200\norg loadingaddress\nsave'disc.bin',#200, end - start,DSK,'delta.dsk'\nsave'bank4.bin',#4000,bank4_end-bank4_start,DSK,'delta.dsk'"
+	code = PatchAsm(code, "loadingaddress", 0x1000)
+	if !strings.Contains(code, "loadingaddress equ #1000") || !strings.Contains(code, "save'disc.bin',#1000,") {
+		t.Fatalf("address not patched : %s", code)
+	}
+	if !strings.Contains(code, "save'bank4.bin',#4000,") {
+		t.Fatalf("bank address patched : %s", code)
+	}
+}
+
+func TestPatchAmsdosHeader(t *testing.T) {
+	if err := amsdos.SaveAmsdosFile("TEST.WIN", ".WIN", []byte{1, 2, 3}, 2, 0, 0x4000, 0x4000); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove("TEST.WIN")
+	if err := PatchAmsdosHeader("TEST.WIN", 0x8000, 0x8000); err != nil {
+		t.Fatal(err)
+	}
+	header, err := AmsdosHeader("TEST.WIN")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if header.Address != 0x8000 || header.Exec != 0x8000 {
+		t.Fatalf("expected address #8000 and gets #%.4X", header.Address)
+	}
+}
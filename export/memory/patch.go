@@ -0,0 +1,90 @@
+package memory
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+
+	"github.com/jeromelesaux/m4client/cpc"
+)
+
+var ErrorNoAmsdosHeader = errors.New("no amsdos header")
+
+// AmsdosHeader reads the amsdos header of the file.
+func AmsdosHeader(filePath string) (*cpc.CpcHead, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	header := &cpc.CpcHead{}
+	if err := binary.Read(f, binary.LittleEndian, header); err != nil {
+		return nil, ErrorNoAmsdosHeader
+	}
+	if header.Checksum != uint16(header.ComputedChecksum16()) {
+		return nil, ErrorNoAmsdosHeader
+	}
+	return header, nil
+}
+
+// PatchAmsdosHeader sets the loading and execution addresses in the amsdos
+// header of the file.
+func PatchAmsdosHeader(filePath string, loadingAddress, executionAddress uint16) error {
+	header, err := AmsdosHeader(filePath)
+	if err != nil {
+		return fmt.Errorf("file (%s) error :%w", filePath, err)
+	}
+	b, err := os.ReadFile(filePath)
+	if err != nil {
+		return err
+	}
+	header.Address = loadingAddress
+	header.Exec = executionAddress
+	header.Checksum = uint16(header.ComputedChecksum16())
+	var buf bytes.Buffer
+	if err := binary.Write(&buf, binary.LittleEndian, header); err != nil {
+		return err
+	}
+	copy(b, buf.Bytes())
+	return os.WriteFile(filePath, b, 0644)
+}
+
+var saveAddress = regexp.MustCompile(`(?mi)^(\s*save\s*'[^']*'\s*,\s*)([#&$]?[0-9a-f]+)`)
+
+// asmValue returns the value of an hexadecimal (#, & or $ prefix) or decimal
+// number of the source code.
+func asmValue(v string) (int64, error) {
+	switch v[0] {
+	case '#', '&', '$':
+		return strconv.ParseInt(v[1:], 16, 32)
+	}
+	return strconv.ParseInt(v, 10, 32)
+}
+
+// PatchAsm replaces the value of the label (label equ value) and the address
+// of the save directives at the previous value of the label, the other save
+// directives (the banks for instance) keep their address.
+func PatchAsm(code, label string, address uint16) string {
+	equ := regexp.MustCompile(`(?mi)^(\s*` + regexp.QuoteMeta(label) + `\s+equ\s+)(\S+)`)
+	m := equ.FindStringSubmatch(code)
+	if m == nil {
+		return code
+	}
+	value := fmt.Sprintf("#%.4X", address)
+	code = equ.ReplaceAllString(code, "${1}"+value)
+	previous, err := asmValue(m[2])
+	if err != nil {
+		return code
+	}
+	return saveAddress.ReplaceAllStringFunc(code, func(save string) string {
+		sm := saveAddress.FindStringSubmatch(save)
+		if v, err := asmValue(sm[2]); err != nil || v != previous {
+			return save
+		}
+		return sm[1] + value
+	})
+}
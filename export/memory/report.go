@@ -0,0 +1,62 @@
+package memory
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Report returns a visual memory map : one line per 4Ko, one character per
+// 128 bytes ('.' free, '#' firmware and system, 'S' screen and a letter per
+// item) followed by the placements list.
+func (m *Map) Report() string {
+	const cell = 128
+	const line = 0x1000
+	var b strings.Builder
+	symbols := make(map[string]byte)
+	letters := "ABCDEFGHIJKLMNOPQRTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
+	for i, p := range m.Placements {
+		symbols[p.Item.Name] = letters[i%len(letters)]
+	}
+	char := func(bank, addr int) byte {
+		for _, v := range m.Reserved {
+			if v.Bank == bank && addr < v.End && addr+cell > v.Start {
+				if v.Name == "screen" {
+					return 'S'
+				}
+				return '#'
+			}
+		}
+		for _, p := range m.Placements {
+			if p.Bank == bank && addr < p.End() && addr+cell > int(p.Address) {
+				return symbols[p.Item.Name]
+			}
+		}
+		return '.'
+	}
+	banks := append([]int{MainBank}, m.Banks()...)
+	for _, bank := range banks {
+		min, max := 0, 0x10000
+		if bank == MainBank {
+			fmt.Fprintf(&b, "Main memory (free #%.4X)\n", m.Free(bank))
+		} else {
+			min, max = BankWindowStart, BankWindowEnd
+			fmt.Fprintf(&b, "Bank %d (%s, free #%.4X)\n", bank, BankConfiguration(bank), m.Free(bank))
+		}
+		for addr := min; addr < max; addr += line {
+			fmt.Fprintf(&b, "#%.4X ", addr)
+			for a := addr; a < addr+line; a += cell {
+				b.WriteByte(char(bank, a))
+			}
+			b.WriteByte('\n')
+		}
+		b.WriteByte('\n')
+	}
+	for _, p := range m.Placements {
+		bank := "main"
+		if p.Bank != MainBank {
+			bank = fmt.Sprintf("bank %d (%s #%.2X)", p.Bank, p.Config, p.Config.GateArrayValue())
+		}
+		fmt.Fprintf(&b, "%c %-16s #%.4X-#%.4X size #%.4X %s\n", symbols[p.Item.Name], p.Item.Name, p.Address, p.End()-1, p.Item.Size, bank)
+	}
+	return b.String()
+}
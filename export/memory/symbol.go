@@ -0,0 +1,68 @@
+package memory
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+type SymbolFormat string
+
+var (
+	RasmSymbol      SymbolFormat = "rasm"
+	SjasmplusSymbol SymbolFormat = "sjasmplus"
+	WinapeSymbol    SymbolFormat = "winape"
+)
+
+// Label returns a label usable by the assemblers from an item name.
+func Label(name string) string {
+	var b strings.Builder
+	for i, c := range name {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c == '_':
+			b.WriteRune(c)
+		case c >= '0' && c <= '9':
+			if i == 0 {
+				b.WriteRune('_')
+			}
+			b.WriteRune(c)
+		default:
+			b.WriteRune('_')
+		}
+	}
+	return b.String()
+}
+
+func symbol(format SymbolFormat, label string, value int) string {
+	hex := fmt.Sprintf("%.4X", value)
+	if value < 0x100 {
+		hex = fmt.Sprintf("%.2X", value)
+	}
+	switch format {
+	case SjasmplusSymbol:
+		return label + ": EQU 0x" + hex
+	case WinapeSymbol:
+		return label + " equ &" + hex
+	default:
+		return label + " equ #" + hex
+	}
+}
+
+// Symbols returns the symbol file of the placements : the address, the
+// size and for the extra banks the gate array value to connect the bank.
+func (m *Map) Symbols(format SymbolFormat) string {
+	eol := "\n"
+	if runtime.GOOS == "windows" {
+		eol = "\r\n"
+	}
+	out := fmt.Sprintf("; memory map CPC %d%s", m.Machine, eol)
+	for _, p := range m.Placements {
+		label := Label(p.Item.Name)
+		out += symbol(format, label, int(p.Address)) + eol
+		out += symbol(format, label+"_size", p.Item.Size) + eol
+		if p.Bank != MainBank {
+			out += symbol(format, label+"_bank", int(p.Config.GateArrayValue())) + eol
+		}
+	}
+	return out
+}
@@ -0,0 +1,43 @@
+package ocpartstudio
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/jeromelesaux/martine/config"
+	"github.com/jeromelesaux/martine/export/amsdos"
+	"github.com/jeromelesaux/martine/export/compression"
+)
+
+// ScrGeometry saves the memory of the screen of the geometry of the
+// configuration loaded at the page of the screen (.SCR), the two pages of an
+// overscan screen from #C000 are saved in .SC1 (#C000) and .SC2 (#0000). The
+// source code setting the crtc is saved in _crtc.asm.
+func ScrGeometry(filePath string, data []byte, cfg *config.MartineConfig) error {
+	g := cfg.Geometry
+	type part struct {
+		extension string
+		address   uint16
+		data      []byte
+	}
+	parts := []part{{".SCR", uint16(g.Page()), data}}
+	if g.Overscan() && g.Page() == 0xC000 {
+		parts = []part{{".SC1", 0xC000, data[:0x4000]}, {".SC2", 0x0000, data[0x4000:]}}
+	}
+	for _, p := range parts {
+		osFilepath := cfg.AmsdosFullPath(filePath, p.extension)
+		fmt.Fprintf(os.Stdout, "Saving screen file (%s) at #%.4X, %d bytes x %d lines\n", osFilepath, p.address, g.LineWidth(), g.Lines())
+		d, _ := compression.Compress(p.data, cfg.Compression)
+		if !cfg.NoAmsdosHeader {
+			if err := amsdos.SaveAmsdosFile(osFilepath, p.extension, d, 2, 0, p.address, 0); err != nil {
+				return err
+			}
+		} else {
+			if err := amsdos.SaveOSFile(osFilepath, d); err != nil {
+				return err
+			}
+		}
+		cfg.AddFile(osFilepath)
+	}
+	return amsdos.SaveStringOSFile(cfg.OsFullPath(filePath, "_crtc.asm"), cfg.Geometry.CrtcAsm())
+}
@@ -1580,6 +1580,89 @@ func FlashLoader(screenFilename1, screenFilename2 string, p1, p2 color.Palette,
 	return nil
 }
 
+/*
+temporal flash routine at #8000, one palette by frame
+di
+frame: ld hl,table
+ld e,frames
+next: ld b,#f5
+in a,(c) : rra : jr c,$-3 // end of the vsync
+in a,(c) : rra : jr nc,$-3 // start of the vsync
+ld b,#7f
+xor a
+ld d,16
+ink: out (c),a : ld c,(hl) : out (c),c
+inc hl : inc a
+dec d : jr nz,ink
+dec e : jr nz,next
+jr frame
+table: 16 hardware values by frame
+*/
+var temporalFlashRoutine = []byte{
+	0xF3,
+	0x21, 0x26, 0x80,
+	0x1E, 0x02,
+	0x06, 0xF5,
+	0xED, 0x78, 0x1F, 0x38, 0xFB,
+	0xED, 0x78, 0x1F, 0x30, 0xFB,
+	0x06, 0x7F,
+	0xAF,
+	0x16, 0x10,
+	0xED, 0x79, 0x4E, 0xED, 0x49,
+	0x23, 0x3C,
+	0x15, 0x20, 0xF6,
+	0x1D, 0x20, 0xE2,
+	0x18, 0xDB,
+}
+
+const temporalFlashFramesOffset = 5
+
+// TemporalLoader saves the routine which changes the palette at each frame
+// (.FLA) and the basic loader (-FLASH.BAS) of the screen.
+func TemporalLoader(filePath string, palettes []color.Palette, mode uint8, cfg *config.MartineConfig) error {
+	routine := make([]byte, len(temporalFlashRoutine))
+	copy(routine, temporalFlashRoutine)
+	routine[temporalFlashFramesOffset] = byte(len(palettes))
+	for _, p := range palettes {
+		table := make([]byte, 16)
+		for i := range table {
+			table[i] = 0x54
+		}
+		for i := 0; i < len(p) && i < 16; i++ {
+			v, err := constants.HardwareValues(p[i])
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error while getting the hardware values for color %v, error :%v\n", p[i], err)
+				continue
+			}
+			table[i] = v[0]
+		}
+		routine = append(routine, table...)
+	}
+
+	routinePath := cfg.AmsdosFullPath(filePath, ".FLA")
+	if !cfg.NoAmsdosHeader {
+		if err := amsdos.SaveAmsdosFile(routinePath, ".FLA", routine, 2, 0, 0x8000, 0x8000); err != nil {
+			return err
+		}
+	} else {
+		if err := amsdos.SaveOSFile(routinePath, routine); err != nil {
+			return err
+		}
+	}
+	cfg.AddFile(routinePath)
+
+	loader := fmt.Sprintf("10 MODE %d:MEMORY &7FFF\r\n20 LOAD\"%s\",&C000\r\n30 LOAD\"%s\",&8000\r\n40 CALL &8000\r\n",
+		mode,
+		filepath.Base(cfg.AmsdosFullPath(filePath, ".SCR")),
+		filepath.Base(routinePath))
+	basicPath := filepath.Join(cfg.OutputPath, "-FLASH.BAS")
+	if err := amsdos.SaveStringOSFile(basicPath, loader); err != nil {
+		return err
+	}
+	cfg.AddFile(basicPath)
+	return nil
+}
+
 func EgxLoader(filePath string, p color.Palette, mode1, mode2 uint8, cfg *config.MartineConfig) error {
 	var out string
 	for i := 0; i < len(p); i++ {
@@ -9,10 +9,12 @@ import (
 	"image/png"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 
 	"github.com/jeromelesaux/martine/config"
 	"github.com/jeromelesaux/martine/constants"
+	"github.com/jeromelesaux/martine/convert/address"
 	ci "github.com/jeromelesaux/martine/convert/image"
 	"github.com/jeromelesaux/martine/convert/sprite"
 	impPalette "github.com/jeromelesaux/martine/export/impdraw/palette"
@@ -198,3 +200,18 @@ func concatSprites(filepaths []string, sizeScreen, spriteSize constants.Size, sc
 	}
 	return board, newPalette, nil
 }
+
+// bc26Routine is the routine bc26 of the players computing the address of the
+// next line of the screen at #C000 with characters of 8 lines.
+var bc26Routine = regexp.MustCompile(`bc26\n(?:.*\n)*?\s*res 3,h\n\s*ret\n`)
+
+// withGeometry replaces the routine bc26 of the player by the next line
+// routine of the screen geometry.
+func withGeometry(sourceCode string, g address.Geometry) (string, error) {
+	routine, err := g.NextLineRoutine()
+	if err != nil {
+		return sourceCode, err
+	}
+	routine = strings.ReplaceAll(routine, "next_line", "bc26")
+	return bc26Routine.ReplaceAllLiteralString(sourceCode, routine), nil
+}
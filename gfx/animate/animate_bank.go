@@ -0,0 +1,299 @@
+package animate
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/jeromelesaux/martine/export/ascii"
+	"github.com/jeromelesaux/martine/gfx/errors"
+)
+
+const (
+	// deltaLoadingAddress is the loading address of the delta player
+	deltaLoadingAddress = 0x200
+	// deltaCodeSize is the estimated size of the code of the delta player
+	deltaCodeSize = 0x400
+	// deltaTableSize is the size of the tables of the player by frame (delta
+	// address, bank and timings)
+	deltaTableSize = 6
+	// deltaFirmwareAddress is the start of the memory used by the firmware
+	deltaFirmwareAddress = 0xA600
+	// deltaBankAddress is the window of the banks of the 6128
+	deltaBankAddress = 0x4000
+	deltaBankSize    = 0x4000
+	// deltaBufferAddress is the depack buffer of the banked player
+	deltaBufferAddress = 0x8000
+	// deltaLoaderAddress is the loader of the banked player followed by the
+	// 2K buffer of the firmware, under the firmware
+	deltaLoaderAddress = 0x9D00
+	deltaLoaderBuffer  = 0x9E00
+	// deltaMainBank selects the main memory at #4000
+	deltaMainBank = 0xC0
+)
+
+// DeltaBanks are the values of the gate array (&7Fxx) which select the banks
+// of the 6128 at #4000.
+var DeltaBanks = []byte{0xC4, 0xC5, 0xC6, 0xC7}
+
+// DeltaLayout is the placement of the deltas in the memory, Bank contains the
+// value of the gate array selecting the memory of each delta (#C0 for the
+// main memory) and Origin the address of the deltas of the main memory
+// placed above the data of the player (0 if they follow it). The deltas stay
+// in the main memory up to the firmware if they fit, otherwise the data of
+// the player is limited to #4000 and the deltas fill the main memory left
+// before being spread over the banks.
+type DeltaLayout struct {
+	Bank   []byte
+	Origin []int
+	Usage  map[byte]int
+	Free   map[byte]int
+	Banked bool
+}
+
+// deltaArea is a free area of the main memory of the banked player.
+type deltaArea struct {
+	origin int
+	free   int
+}
+
+// NewDeltaLayout places the deltas of the sizes after the player, its
+// mainSize bytes of data and its depack buffer.
+func NewDeltaLayout(mainSize int, sizes []int, buffer int) (DeltaLayout, error) {
+	l := DeltaLayout{Bank: make([]byte, len(sizes)), Origin: make([]int, len(sizes)), Usage: make(map[byte]int), Free: make(map[byte]int)}
+	total := deltaLoadingAddress + deltaCodeSize + mainSize + buffer
+	for _, v := range sizes {
+		total += v
+	}
+	if total <= deltaFirmwareAddress {
+		for i, v := range sizes {
+			l.Bank[i] = deltaMainBank
+			l.Usage[deltaMainBank] += v
+		}
+		l.Free[deltaMainBank] = deltaFirmwareAddress - total
+		return l, nil
+	}
+	l.Banked = true
+	// the player and its tables stay under the window of the banks, the
+	// deltas fill the memory left after them, the main memory of the window
+	// and the memory after the depack buffer up to the loader
+	areas := []deltaArea{
+		{origin: 0, free: deltaBankAddress - (deltaLoadingAddress + deltaCodeSize + mainSize)},
+		{origin: deltaBankAddress, free: deltaBankSize},
+		{origin: deltaBufferAddress + buffer, free: deltaLoaderAddress - (deltaBufferAddress + buffer)},
+	}
+	if areas[0].free < 0 || areas[2].free < 0 {
+		return l, errors.ErrorDeltaMemoryOverflow
+	}
+	for _, b := range DeltaBanks {
+		l.Free[b] = deltaBankSize
+	}
+	for i, v := range sizes {
+		placed := false
+		for j := range areas {
+			if v <= areas[j].free {
+				l.Bank[i] = deltaMainBank
+				l.Origin[i] = areas[j].origin
+				l.Usage[deltaMainBank] += v
+				areas[j].free -= v
+				placed = true
+				break
+			}
+		}
+		for _, b := range DeltaBanks {
+			if placed {
+				break
+			}
+			if v <= l.Free[b] {
+				l.Bank[i] = b
+				l.Usage[b] += v
+				l.Free[b] -= v
+				placed = true
+			}
+		}
+		if !placed {
+			return l, errors.ErrorDeltaMemoryOverflow
+		}
+	}
+	for _, a := range areas {
+		l.Free[deltaMainBank] += a.free
+	}
+	return l, nil
+}
+
+// Report prints the usage of the main memory and the banks.
+func (l DeltaLayout) Report() {
+	fmt.Fprintf(os.Stdout, "Main memory : %d bytes of deltas, %d bytes free\n", l.Usage[deltaMainBank], l.Free[deltaMainBank])
+	if !l.Banked {
+		return
+	}
+	for _, b := range DeltaBanks {
+		fmt.Fprintf(os.Stdout, "Bank &7F%.2X : %d bytes of deltas, %d bytes free\n", b, l.Usage[b], l.Free[b])
+	}
+}
+
+// origins returns the sorted addresses of the deltas of the main memory
+// placed above the data of the player.
+func (l DeltaLayout) origins() []int {
+	origins := make([]int, 0)
+	seen := make(map[int]bool)
+	for i, v := range l.Origin {
+		if l.Bank[i] != deltaMainBank || v == 0 || seen[v] {
+			continue
+		}
+		seen[v] = true
+		origins = append(origins, v)
+	}
+	sort.Ints(origins)
+	return origins
+}
+
+// code returns the deltas following the data of the player, the sections of
+// the main memory above it and the sections of the banks with their loader,
+// each section is saved in its own file of the dsk.
+func (l DeltaLayout) code(names []string, data [][]byte, dsk string) (string, string, string) {
+	var mainCode, upperCode, bankCode string
+	for i, name := range names {
+		if l.Bank[i] != deltaMainBank || l.Origin[i] != 0 {
+			continue
+		}
+		mainCode += name + ":\n"
+		if len(data[i]) != 0 {
+			mainCode += ascii.FormatAssemblyDatabyte(data[i], "\n")
+		}
+	}
+	if !l.Banked {
+		return mainCode, "", ""
+	}
+	mainCode += "table_bank:\n"
+	mainCode += ascii.FormatAssemblyDatabyte(l.Bank, "\n")
+	for _, origin := range l.origins() {
+		upperCode += fmt.Sprintf("\n;--- memoire centrale #%.4x ---\norg #%.4x\nmain%.4x_start\n", origin, origin, origin)
+		for i, name := range names {
+			if l.Bank[i] != deltaMainBank || l.Origin[i] != origin {
+				continue
+			}
+			upperCode += name + ":\n"
+			upperCode += ascii.FormatAssemblyDatabyte(data[i], "\n")
+		}
+		upperCode += fmt.Sprintf("main%.4x_end\n", origin)
+		upperCode += fmt.Sprintf("save'main%.4x.bin',#%.4x, main%.4x_end - main%.4x_start,DSK,'%s'\n", origin, origin, origin, origin, dsk)
+	}
+	for _, b := range DeltaBanks {
+		if l.Usage[b] == 0 {
+			continue
+		}
+		n := b - deltaMainBank
+		bankCode += fmt.Sprintf("\n;--- banque &7F%.2X : %d octets ---\nbank %d\norg #%.4x\nbank%d_start\n", b, l.Usage[b], n, deltaBankAddress, n)
+		for i, name := range names {
+			if l.Bank[i] != b {
+				continue
+			}
+			bankCode += name + ":\n"
+			bankCode += ascii.FormatAssemblyDatabyte(data[i], "\n")
+		}
+		bankCode += fmt.Sprintf("bank%d_end\n", n)
+		bankCode += fmt.Sprintf("save'bank%d.bin',#%.4x, bank%d_end - bank%d_start,DSK,'%s'\n", n, deltaBankAddress, n, n, dsk)
+	}
+	bankCode += l.loaderCode(dsk)
+	return mainCode, upperCode, bankCode
+}
+
+// loaderCode returns the binary loader of the banked player, it selects each
+// bank and loads its file at #4000, loads the files of the main memory and the
+// player last and runs it. The loader and the buffer of the firmware stay
+// under the firmware where no delta is loaded.
+func (l DeltaLayout) loaderCode(dsk string) string {
+	files := ""
+	for _, b := range DeltaBanks {
+		if l.Usage[b] != 0 {
+			files += loaderFile(b, fmt.Sprintf("#%.4x", deltaBankAddress), fmt.Sprintf("bank%d.bin", b-deltaMainBank))
+		}
+	}
+	for _, origin := range l.origins() {
+		files += loaderFile(deltaMainBank, fmt.Sprintf("#%.4x", origin), fmt.Sprintf("main%.4x.bin", origin))
+	}
+	files += loaderFile(deltaMainBank, "loadingaddress", "disc.bin")
+	code := strings.Replace(deltaLoaderCode, "$LOADER$", fmt.Sprintf("#%.4x", deltaLoaderAddress), 1)
+	code = strings.Replace(code, "$BUFFER$", fmt.Sprintf("#%.4x", deltaLoaderBuffer), 1)
+	code = strings.Replace(code, "$FILES$", files, 1)
+	return code + fmt.Sprintf("save'loader.bin',#%.4x, loader_end - loader_start,DSK,'%s'\n", deltaLoaderAddress, dsk)
+}
+
+// loaderFile returns the entry of the file in the table of the loader.
+func loaderFile(bank byte, address, filename string) string {
+	return fmt.Sprintf("\tdb #%.2x\n\tdw %s\n\tdb %d,\"%s\"\n", bank, address, len(filename), filename)
+}
+
+// Loader returns the ascii basic loader of the banked player which runs the
+// binary loader, empty if the deltas stay in the main memory.
+func (l DeltaLayout) Loader() string {
+	if !l.Banked {
+		return ""
+	}
+	return fmt.Sprintf("10 MEMORY &%X:LOAD\"loader.bin\",&%X:CALL &%X\r\n", deltaLoaderAddress-1, deltaLoaderAddress, deltaLoaderAddress)
+}
+
+// withBanks selects the bank of the delta in the routine next_delta of the
+// player.
+func (l DeltaLayout) withBanks(sourceCode string) string {
+	if !l.Banked {
+		return sourceCode
+	}
+	return strings.Replace(sourceCode, "table_next:\n\tld (table_index+1),a\n", "table_next:\n\tld (table_index+1),a\n"+bankSelectRoutine, 1)
+}
+
+var bankSelectRoutine = `	push af ; selection de la banque du delta
+	ld e,a
+	ld d,0
+	ld hl,table_bank
+	add hl,de
+	ld c,(hl)
+	ld b,#7F
+	out (c),c
+	pop af
+`
+
+var deltaLoaderCode = `
+;--- chargeur des fichiers ---
+org $LOADER$
+loader_start
+	ld hl,loader_files
+loader_next
+	ld a,(hl) ; selection de la banque, 0 en fin de table
+	or a
+	jr z,loader_run
+	ld b,#7F
+	out (c),a
+	inc hl
+	ld e,(hl) ; adresse de chargement
+	inc hl
+	ld d,(hl)
+	inc hl
+	ld b,(hl) ; longueur du nom du fichier
+	inc hl
+	push de
+	push hl
+	ld e,b
+	ld d,0
+	add hl,de
+	ld (loader_file+1),hl
+	pop hl
+	ld de,$BUFFER$
+	call #BC77 ; cas in open
+	pop hl
+	jr nc,loader_file
+	call #BC83 ; cas in direct
+	call #BC7A ; cas in close
+loader_file
+	ld hl,0
+	jr loader_next
+loader_run
+	ld bc,#7FC0
+	out (c),c
+	jp loadingaddress
+loader_files
+$FILES$	db 0
+loader_end
+`
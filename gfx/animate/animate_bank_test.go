@@ -0,0 +1,113 @@
+package animate
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestDeltaLayout(t *testing.T) {
+	l, err := NewDeltaLayout(0x4000, []int{0x1000, 0x1000}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.Banked || l.Loader() != "" {
+		t.Fatalf("the deltas fit in the main memory")
+	}
+
+	sizes := make([]int, 9)
+	for i := range sizes {
+		sizes[i] = 0x1800
+	}
+	l, err = NewDeltaLayout(0x2000, sizes, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// one delta after the player, two in the main memory at #4000 and one
+	// after the depack buffer before the banks
+	if !l.Banked || l.Usage[deltaMainBank] != 4*0x1800 || l.Usage[0xC4] != 2*0x1800 || l.Usage[0xC7] != 0 {
+		t.Fatalf("unexpected layout %v %v", l.Bank, l.Usage)
+	}
+	if l.Origin[0] != 0 || l.Origin[1] != deltaBankAddress || l.Origin[2] != deltaBankAddress || l.Origin[3] != deltaBufferAddress {
+		t.Fatalf("unexpected origins %v", l.Origin)
+	}
+	main, upper, banks := l.code([]string{"d0", "d1", "d2", "d3", "d4", "d5", "d6", "d7", "d8"}, make([][]byte, 9), "delta.dsk")
+	if !strings.Contains(main, "d0:") || strings.Contains(main, "d1:") ||
+		!strings.Contains(upper, "org #4000\nmain4000_start\nd1:") || !strings.Contains(upper, "org #8000\nmain8000_start\nd3:") ||
+		!strings.Contains(banks, "save'bank6.bin'") || strings.Contains(banks, "bank7") {
+		t.Fatalf("unexpected sections %s %s %s", main, upper, banks)
+	}
+	if l.Loader() != "10 MEMORY &9CFF:LOAD\"loader.bin\",&9D00:CALL &9D00\r\n" {
+		t.Fatalf("unexpected loader %s", l.Loader())
+	}
+	if strings.Contains(banks, "\"bank7.bin\"") {
+		t.Fatalf("the empty bank is loaded %s", banks)
+	}
+	for _, b := range append([]byte{deltaMainBank}, DeltaBanks...) {
+		if l.Free[b] < 0 {
+			t.Fatalf("bank &7F%.2X overflows", b)
+		}
+	}
+	for _, template := range []string{deltaScreenCodeDelta, deltaScreenCodeDeltaV2, deltaScreenCompressCodeDelta, deltaScreenCompressCodeDeltaV2,
+		deltaScreenCodeDeltaPlus, deltaScreenCompressCodeDeltaPlus, deltaCodeDelta, depackRoutine} {
+		if !strings.Contains(l.withBanks(template), "table_bank") {
+			t.Fatalf("the bank selection is missing in the player")
+		}
+	}
+
+	if _, err := NewDeltaLayout(0x2000, append(sizes, 0x1800, 0x1800, 0x1800, 0x1800), 0); err == nil {
+		t.Fatalf("expected an error for the deltas exceeding the memory")
+	}
+}
+
+func TestDeltaLayoutFourBanks(t *testing.T) {
+	sizes := make([]int, 22)
+	names := make([]string, len(sizes))
+	for i := range sizes {
+		sizes[i] = 0x1000
+		names[i] = fmt.Sprintf("d%d", i)
+	}
+	l, err := NewDeltaLayout(0x2000, sizes, 0x800)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, b := range DeltaBanks {
+		if l.Usage[b] != deltaBankSize {
+			t.Fatalf("expected the bank &7F%.2X to be full %v", b, l.Usage)
+		}
+	}
+	_, upper, banks := l.code(names, make([][]byte, len(sizes)), "delta.dsk")
+	// each section of the main memory above the player is saved in its own file
+	for _, section := range []string{
+		"org #4000\nmain4000_start\nd1:",
+		"main4000_end\nsave'main4000.bin',#4000, main4000_end - main4000_start,DSK,'delta.dsk'\n",
+		"org #8800\nmain8800_start\nd5:",
+		"main8800_end\nsave'main8800.bin',#8800, main8800_end - main8800_start,DSK,'delta.dsk'\n",
+	} {
+		if !strings.Contains(upper, section) {
+			t.Fatalf("expected %q in the sections %s", section, upper)
+		}
+	}
+	// the loader selects each bank, loads the main memory and the player last
+	files := []string{
+		"db #c4\n\tdw #4000\n\tdb 9,\"bank4.bin\"\n",
+		"db #c5\n\tdw #4000\n\tdb 9,\"bank5.bin\"\n",
+		"db #c6\n\tdw #4000\n\tdb 9,\"bank6.bin\"\n",
+		"db #c7\n\tdw #4000\n\tdb 9,\"bank7.bin\"\n",
+		"db #c0\n\tdw #4000\n\tdb 12,\"main4000.bin\"\n",
+		"db #c0\n\tdw #8800\n\tdb 12,\"main8800.bin\"\n",
+		"db #c0\n\tdw loadingaddress\n\tdb 8,\"disc.bin\"\n",
+		"save'loader.bin',#9d00, loader_end - loader_start,DSK,'delta.dsk'\n",
+	}
+	last := 0
+	for _, file := range files {
+		i := strings.Index(banks, file)
+		if i < last {
+			t.Fatalf("expected %q in the loader after the previous files %s", file, banks)
+		}
+		last = i
+	}
+	if !strings.HasPrefix(l.Loader(), "10 MEMORY &9CFF:") || strings.Count(l.Loader(), "\r\n") != 1 {
+		t.Fatalf("unexpected basic loader %s", l.Loader())
+	}
+}
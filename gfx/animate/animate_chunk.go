@@ -0,0 +1,292 @@
+package animate
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+
+	"github.com/jeromelesaux/martine/config"
+	"github.com/jeromelesaux/martine/export/amsdos"
+	"github.com/jeromelesaux/martine/export/ascii"
+	"github.com/jeromelesaux/martine/gfx/errors"
+	"github.com/jeromelesaux/martine/gfx/transformation"
+)
+
+// ChunkPlayback contains the chunk frames of a playback. First is the index of
+// the frame which draws the step 0 on the cleared screen, Init the index of
+// the frame which prepares the second buffer and Table the index of the frame
+// of each step of the loop.
+type ChunkPlayback struct {
+	Playback Playback
+	Frames   []transformation.ChunkFrame
+	Table    []int
+	First    int
+	Init     int
+	Palette  color.Palette
+	IsSprite bool
+}
+
+// ChunkPackingPlayback converts the images and encodes the frames of the
+// playback with the cheapest chunks for the cost.
+func ChunkPackingPlayback(images []image.Image, cfg *config.MartineConfig, initialAddress uint16, mode uint8, p Playback, cost transformation.DeltaCost) (ChunkPlayback, error) {
+	cp := ChunkPlayback{Playback: p, Init: -1}
+	f, err := convertDeltaFrames(images, cfg, initialAddress, mode)
+	if err != nil {
+		return cp, err
+	}
+	cp.Palette, cp.IsSprite = f.palette, f.isSprite
+	segments := transformation.DeltaSegments(f.isSprite, f.size, len(f.raw[0]), f.x0, f.y0, f.geometry)
+	encode := func(from, to []byte) int {
+		frame := transformation.DeltaChunks(from, to, segments, !f.isSprite, cost)
+		cp.Frames = append(cp.Frames, frame)
+		return len(cp.Frames) - 1
+	}
+	indexes := make(map[[2]int]int)
+	steps := p.Steps()
+	// the screen is cleared by the mode
+	cp.First = encode(make([]byte, len(f.raw[0])), f.raw[steps[0]])
+	if p.Buffers == 2 {
+		cp.Init = encode(f.raw[steps[0]], f.raw[steps[1]])
+		indexes[[2]int{steps[0], steps[1]}] = cp.Init
+	}
+	for _, t := range p.Transitions() {
+		i, ok := indexes[t]
+		if !ok {
+			i = encode(f.raw[t[0]], f.raw[t[1]])
+			indexes[t] = i
+			count := cp.Frames[i].Count()
+			fmt.Fprintf(os.Stdout, "Frame [%d] to [%d] : %d bytes, %d nops, %d patches, %d runs, %d zx0 blocks, %d keyframes\n",
+				t[0], t[1], len(cp.Frames[i].Bytes()), cp.Frames[i].Nops(),
+				count[transformation.ChunkPatch], count[transformation.ChunkRun], count[transformation.ChunkZx0], count[transformation.ChunkKeyframe])
+		}
+		cp.Table = append(cp.Table, i)
+	}
+	return cp, nil
+}
+
+// ChunkPackingGifPlayback encodes the frames of the gif file with the
+// cheapest chunks for the cost and saves the player source code.
+func ChunkPackingGifPlayback(gifFilepath string, cfg *config.MartineConfig, initialAddress uint16, mode uint8, topology DeltaTopology, graph string, double bool, cost transformation.DeltaCost) error {
+	images, err := gifFrames(gifFilepath)
+	if err != nil {
+		return err
+	}
+	p, err := NewPlayback(topology, len(images), graph, double)
+	if err != nil {
+		return err
+	}
+	cp, err := ChunkPackingPlayback(images, cfg, initialAddress, mode, p, cost)
+	if err != nil {
+		return err
+	}
+	code, err := ExportChunkPlayback(cp, cfg, mode)
+	if err != nil {
+		return err
+	}
+	filename := string(cfg.OsFilename(".asm"))
+	return amsdos.SaveStringOSFile(cfg.OutputPath+string(filepath.Separator)+filename, code)
+}
+
+// ExportChunkPlayback returns the source code of the player of the chunk
+// frames with its data.
+func ExportChunkPlayback(cp ChunkPlayback, cfg *config.MartineConfig, mode uint8) (string, error) {
+	double := cp.Playback.Buffers == 2
+	var dataCode string
+	size := 0
+	for i, f := range cp.Frames {
+		data := f.Bytes()
+		size += len(data) + 2
+		dataCode += fmt.Sprintf("frame%.2d:\n", i)
+		dataCode += ascii.FormatAssemblyDatabyte(data, "\n")
+	}
+	if double && playbackLoadingAddress+playbackCodeSize+size > 0x4000 {
+		return "", errors.ErrorPlaybackTooLong
+	}
+	frameIndex := make([]string, len(cp.Table))
+	for i, v := range cp.Table {
+		frameIndex[i] = fmt.Sprintf("frame%.2d", v)
+	}
+	dataCode += "table_frame:\n"
+	ascii.ByteToken = "dw"
+	dataCode += ascii.FormatAssemblyString(frameIndex, "\n")
+	ascii.ByteToken = "db"
+	dataCode += "palette:\n" + ascii.ByteToken + " "
+	dataCode += ascii.FormatAssemblyBasicPalette(cp.Palette, "\n")
+
+	var code string
+	code += fmt.Sprintf("; chunk player, %d steps, %d buffers, frames %v\n", len(cp.Table), cp.Playback.Buffers, cp.Playback.Steps())
+	code += fmt.Sprintf("loadingaddress equ #%.4x\n", playbackLoadingAddress)
+	code += fmt.Sprintf("linewidth equ #%.4x\n", cfg.Geometry.Page()+cfg.Geometry.LineWidth())
+	code += fmt.Sprintf("nbsteps equ %d\n", len(cp.Table))
+	code += fmt.Sprintf("nbcolors equ %d\n", len(cp.Palette))
+	code += "org loadingaddress\nrun loadingaddress\n\nstart\n"
+	code += fmt.Sprintf("\tld a,%d\n\tcall #BC0E\n\tcall palettefirmware\n\tcall xvbl\n", mode)
+	code += fmt.Sprintf("\tld hl,frame%.2d\n\tcall chunk\n", cp.First)
+	if double {
+		code += "; second frame in the buffer #4000\n"
+		code += "\tld hl,#C000\n\tld de,#4000\n\tld bc,#4000\n\tldir\n"
+		code += fmt.Sprintf("\tcall swap_buffer\n\tld hl,frame%.2d\n\tcall chunk\n\tcall swap_buffer\n", cp.Init)
+	}
+	code += "\nmainloop\n"
+	if double {
+		code += "\tcall swap_buffer\n"
+	}
+	code += "\tcall next_frame\n\tcall xvbl\n"
+	if double {
+		code += "\tld a,(buffer)\n\tcall #BC08 ; display the patched buffer\n"
+	}
+	code += "\tjp mainloop\n"
+	if double {
+		code += "\n; the chunks are written in the other buffer\nswap_buffer\n\tld a,(buffer)\n\txor #80\n\tld (buffer),a\n" +
+			"\tld a,(bufferxor+1)\n\txor #80\n\tld (bufferxor+1),a\n\tret\n"
+	}
+	code += chunkRoutine
+	code += playbackRoutines
+	code += dataCode
+	code += "\nbuffer db #C0\n"
+	code += "\nend\n"
+	code += "\nsave'disc.bin',#200, end - start,DSK,'delta.dsk'"
+	return code, nil
+}
+
+var chunkRoutine = `
+;--- routine des chunks -------------------------------
+next_frame:
+step_index:
+	ld a,-1
+	inc a
+	cp nbsteps
+	jr c, step_next
+	xor a
+step_next:
+	ld (step_index+1),a
+	ld l,a
+	ld h,0
+	add hl,hl
+	ld de,table_frame
+	add hl,de
+	ld a,(hl)
+	inc hl
+	ld h,(hl)
+	ld l,a
+chunk ; hl = chunks de la frame
+	ld a,(hl)
+	inc hl
+	or a
+	ret z
+	dec a
+	jr z,chunk_patch
+	dec a
+	jr z,chunk_run
+	dec a
+	jr z,chunk_zx0
+	; image complete compressee
+	ld e,0
+	ld a,#C0
+	call bufferxor
+	call Depack
+	jr chunk
+chunk_patch
+	call chunk_address
+	ld b,(hl) ; nombre d'octets
+	inc hl
+patch_loop
+	push de
+	ld a,(hl) ; position dans le span
+	inc hl
+	add a,e
+	ld e,a
+	jr nc,patch_poke
+	inc d
+patch_poke
+	ld a,(hl)
+	inc hl
+	ld (de),a
+	pop de
+	djnz patch_loop
+	jr chunk
+chunk_run
+	call chunk_address
+	ld c,(hl) ; longueur du span
+	inc hl
+	ld b,0
+	ldir
+	jr chunk
+chunk_zx0
+	call chunk_address
+	call Depack
+	jr chunk
+
+; de = adresse du span dans le buffer
+chunk_address
+	ld e,(hl)
+	inc hl
+	ld a,(hl)
+	inc hl
+bufferxor
+	xor #00
+	ld d,a
+	ret
+
+;
+; Decompactage ZX0
+; HL = source
+; DE = destination
+;
+Depack:
+	ld bc,#ffff ; preserve default offset 1
+	push bc
+	inc bc
+	ld a,#80
+dzx0s_literals:
+	call dzx0s_elias ; obtain length
+	ldir ; copy literals
+	add a,a ; copy from last offset or new offset?
+	jr c,dzx0s_new_offset
+	call dzx0s_elias ; obtain length
+dzx0s_copy:
+	ex (sp),hl ; preserve source,restore offset
+	push hl ; preserve offset
+	add hl,de ; calculate destination - offset
+	ldir ; copy from offset
+	pop hl ; restore offset
+	ex (sp),hl ; preserve offset,restore source
+	add a,a ; copy from literals or new offset?
+	jr nc,dzx0s_literals
+dzx0s_new_offset:
+	call dzx0s_elias ; obtain offset MSB
+	ld b,a
+	pop af ; discard last offset
+	xor a ; adjust for negative offset
+	sub c
+	ret z ; Plus d'octets a traiter = fini
+	ld c,a
+	ld a,b
+	ld b,c
+	ld c,(hl) ; obtain offset LSB
+	inc hl
+	rr b ; last offset bit becomes first length bit
+	rr c
+	push bc ; preserve new offset
+	ld bc,1 ; obtain length
+	call nc,dzx0s_elias_backtrack
+	inc bc
+	jr dzx0s_copy
+dzx0s_elias:
+	inc c ; interlaced Elias gamma coding
+dzx0s_elias_loop:
+	add a,a
+	jr nz,dzx0s_elias_skip
+	ld a,(hl) ; load another group of 8 bits
+	inc hl
+	rla
+dzx0s_elias_skip:
+	ret c
+dzx0s_elias_backtrack:
+	add a,a
+	rl c
+	rl b
+	jr dzx0s_elias_loop
+`
@@ -31,8 +31,6 @@ var (
 
 func DeltaPackingMemory(images []image.Image, cfg *config.MartineConfig, initialAddress uint16, mode uint8) ([]*transformation.DeltaCollection, [][]byte, color.Palette, error) {
 	var isSprite bool = true
-	maxImages := 22
-	var pad int = 1
 	var err error
 	var palette color.Palette
 	if !cfg.CustomDimension && !cfg.SpriteHard {
@@ -41,11 +39,8 @@ func DeltaPackingMemory(images []image.Image, cfg *config.MartineConfig, initial
 	if len(images) <= 1 {
 		return nil, nil, palette, fmt.Errorf("need more than one image to proceed")
 	}
-	if len(images) > maxImages {
-		fmt.Fprintf(os.Stderr, "Warning gif exceed 30 images. Will corrupt the number of images.")
-		pad = len(images) / maxImages
-	}
 	rawImages := make([][]byte, 0)
+	frames := make([]image.Image, 0)
 	deltaData := make([]*transformation.DeltaCollection, 0)
 
 	var raw []byte
@@ -57,18 +52,18 @@ func DeltaPackingMemory(images []image.Image, cfg *config.MartineConfig, initial
 	if err != nil {
 		return nil, nil, palette, err
 	}
-	for i := 0; i < len(images); i += pad {
+	for i := 0; i < len(images); i++ {
 		in := images[i]
 		raw, _, _, _, err = gfx.ApplyOneImage(in, cfg, int(mode), palette, mode)
 		if err != nil {
 			return nil, nil, palette, err
 		}
 		rawImages = append(rawImages, raw)
+		frames = append(frames, in)
 		fmt.Printf("Image [%d] proceed\n", i)
 	}
 
-	lineOctetsWidth := cfg.LineWidth
-	x0, y0, err := transformation.CpcCoordinates(initialAddress, 0xC000, lineOctetsWidth)
+	x0, y0, err := transformation.CpcCoordinates(initialAddress, uint16(cfg.Geometry.Page()), cfg.Geometry)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "error while computing cpc coordinates :%v\n", err)
 	}
@@ -78,6 +73,12 @@ func DeltaPackingMemory(images []image.Image, cfg *config.MartineConfig, initial
 	if isSprite {
 		realSize.Width = realSize.ModeWidth(mode)
 	}
+	if cfg.StableDithering {
+		rawImages, err = stableRawImages(frames, rawImages, cfg, mode, palette, isSprite, *realSize, x0, y0, cfg.Geometry)
+		if err != nil {
+			return nil, nil, palette, err
+		}
+	}
 	var lastImage []byte
 	for i := 0; i < len(rawImages)-1; i++ {
 		fmt.Printf("Compare image [%d] with [%d] ", i, i+1)
@@ -87,22 +88,24 @@ func DeltaPackingMemory(images []image.Image, cfg *config.MartineConfig, initial
 			return nil, nil, palette, errors.ErrorSizeDiffers
 		}
 		lastImage = d2
-		dc := transformation.Delta(d1, d2, isSprite, *realSize, mode, uint16(x0), uint16(y0), lineOctetsWidth)
+		dc := transformation.Delta(d1, d2, isSprite, *realSize, mode, uint16(x0), uint16(y0), cfg.Geometry)
 		deltaData = append(deltaData, dc)
 		fmt.Printf("%d bytes differ from the both images\n", len(dc.Items))
 	}
 	fmt.Printf("Compare image [%d] with [%d] ", len(rawImages)-1, 0)
 	d1 := lastImage
 	d2 := rawImages[0]
-	dc := transformation.Delta(d1, d2, isSprite, *realSize, mode, uint16(x0), uint16(y0), lineOctetsWidth)
+	dc := transformation.Delta(d1, d2, isSprite, *realSize, mode, uint16(x0), uint16(y0), cfg.Geometry)
 	deltaData = append(deltaData, dc)
 	fmt.Printf("%d bytes differ from the both images\n", len(dc.Items))
 	return deltaData, rawImages, palette, nil
 }
 
-func DeltaPacking(gitFilepath string, cfg *config.MartineConfig, initialAddress uint16, mode uint8, exportVersion DeltaExportFormat) error {
+// DeltaPacking saves the delta player of the gif file, the frames are
+// displayed during their gif delays, cues sets the cue points of the frames
+// (ex 0:intro,12:drop).
+func DeltaPacking(gitFilepath string, cfg *config.MartineConfig, initialAddress uint16, mode uint8, exportVersion DeltaExportFormat, cues string) error {
 	isSprite := true
-	maxImages := 22
 	if !cfg.CustomDimension && !cfg.SpriteHard {
 		isSprite = false
 	}
@@ -116,15 +119,18 @@ func DeltaPacking(gitFilepath string, cfg *config.MartineConfig, initialAddress
 		return err
 	}
 	images := ConvertToImage(*gifImages)
-	var pad int = 1
+	timings := GifTimings(gifImages)
 	if len(images) <= 1 {
 		return fmt.Errorf("need more than one image to proceed")
 	}
-	if len(images) > maxImages {
-		fmt.Fprintf(os.Stderr, "Warning gif exceed 30 images. Will corrupt the number of images.")
-		pad = len(images) / maxImages
+	if cfg.FilloutGif {
+		timings = timings[1 : len(timings)-1]
+	}
+	if err := ParseCues(timings, cues); err != nil {
+		return err
 	}
 	rawImages := make([][]byte, 0)
+	frames := make([]image.Image, 0)
 	deltaData := make([]*transformation.DeltaCollection, 0)
 	var palette color.Palette
 	var raw []byte
@@ -138,13 +144,14 @@ func DeltaPacking(gitFilepath string, cfg *config.MartineConfig, initialAddress
 		if err != nil {
 			return err
 		}
-		for i := 0; i < len(imgs); i += pad {
+		for i := 0; i < len(imgs); i++ {
 			in := imgs[i]
 			raw, _, _, _, err = gfx.ApplyOneImage(in, cfg, int(mode), palette, mode)
 			if err != nil {
 				return err
 			}
 			rawImages = append(rawImages, raw)
+			frames = append(frames, in)
 			fmt.Printf("Image [%d] proceed\n", i)
 		}
 	} else {
@@ -152,7 +159,7 @@ func DeltaPacking(gitFilepath string, cfg *config.MartineConfig, initialAddress
 		if err != nil {
 			return err
 		}
-		for i := 0; i < len(images); i += pad {
+		for i := 0; i < len(images); i++ {
 			in := images[i]
 			raw, _, _, _, err = gfx.ApplyOneImage(in, cfg, int(mode), palette, mode)
 			if err != nil {
@@ -163,11 +170,11 @@ func DeltaPacking(gitFilepath string, cfg *config.MartineConfig, initialAddress
 				return err
 			}
 			rawImages = append(rawImages, raw)
+			frames = append(frames, in)
 			fmt.Printf("Image [%d] proceed\n", i)
 		}
 	}
-	lineOctetsWidth := cfg.LineWidth
-	x0, y0, err := transformation.CpcCoordinates(initialAddress, 0xC000, lineOctetsWidth)
+	x0, y0, err := transformation.CpcCoordinates(initialAddress, uint16(cfg.Geometry.Page()), cfg.Geometry)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "error while computing cpc coordinates :%v\n", err)
 	}
@@ -175,6 +182,12 @@ func DeltaPacking(gitFilepath string, cfg *config.MartineConfig, initialAddress
 	fmt.Printf("Let's go deltapacking raw images\n")
 	realSize := &constants.Size{Width: cfg.Size.Width, Height: cfg.Size.Height}
 	realSize.Width = realSize.ModeWidth(mode)
+	if cfg.StableDithering {
+		rawImages, err = stableRawImages(frames, rawImages, cfg, mode, palette, isSprite, *realSize, x0, y0, cfg.Geometry)
+		if err != nil {
+			return err
+		}
+	}
 	var lastImage []byte
 	for i := 0; i < len(rawImages)-1; i++ {
 		fmt.Printf("Compare image [%d] with [%d] ", i, i+1)
@@ -184,18 +197,18 @@ func DeltaPacking(gitFilepath string, cfg *config.MartineConfig, initialAddress
 			return errors.ErrorSizeDiffers
 		}
 		lastImage = d2
-		dc := transformation.Delta(d1, d2, isSprite, *realSize, mode, uint16(x0), uint16(y0), lineOctetsWidth)
+		dc := transformation.Delta(d1, d2, isSprite, *realSize, mode, uint16(x0), uint16(y0), cfg.Geometry)
 		deltaData = append(deltaData, dc)
 		fmt.Printf("%d bytes differ from the both images\n", len(dc.Items))
 	}
 	fmt.Printf("Compare image [%d] with [%d] ", len(rawImages)-1, 0)
 	d1 := lastImage
 	d2 := rawImages[0]
-	dc := transformation.Delta(d1, d2, isSprite, *realSize, mode, uint16(x0), uint16(y0), lineOctetsWidth)
+	dc := transformation.Delta(d1, d2, isSprite, *realSize, mode, uint16(x0), uint16(y0), cfg.Geometry)
 	deltaData = append(deltaData, dc)
 	fmt.Printf("%d bytes differ from the both images\n", len(dc.Items))
 	filename := string(cfg.OsFilename(".asm"))
-	return exportDeltaAnimate(rawImages[0], deltaData, palette, isSprite, cfg, initialAddress, mode, cfg.OutputPath+string(filepath.Separator)+filename, exportVersion)
+	return exportDeltaAnimate(rawImages[0], deltaData, palette, isSprite, cfg, initialAddress, mode, cfg.OutputPath+string(filepath.Separator)+filename, exportVersion, timings)
 }
 
 func ConvertToImage(g gif.GIF) []*image.NRGBA {
@@ -234,10 +247,16 @@ func filloutGif(g gif.GIF, cfg *config.MartineConfig) []image.Image {
 	return c
 }
 
-func ExportDeltaAnimate(imageReference []byte, delta []*transformation.DeltaCollection, palette color.Palette, isSprite bool, cfg *config.MartineConfig, initialAddress uint16, mode uint8, exportVersion DeltaExportFormat) (string, error) {
+// ExportDeltaAnimate returns the source code of the delta player, the frames
+// are displayed during their timings if set, and the basic loader of the
+// banks if the deltas do not fit in the main memory.
+func ExportDeltaAnimate(imageReference []byte, delta []*transformation.DeltaCollection, palette color.Palette, isSprite bool, cfg *config.MartineConfig, initialAddress uint16, mode uint8, exportVersion DeltaExportFormat, timings []FrameTiming) (string, string, error) {
 	var sourceCode string
 	var dataCode string
 	var deltaIndex []string
+	var deltaData [][]byte
+	var deltaSizes []int
+	var referenceSize, bufferSize int
 	var code string
 	nbDelta := len(delta)
 	if !isSprite {
@@ -271,8 +290,13 @@ func ExportDeltaAnimate(imageReference []byte, delta []*transformation.DeltaColl
 		fmt.Fprintf(os.Stdout, "Using Zx0 cruncher")
 		data := zx0.Encode(imageReference)
 		dataCode += ascii.FormatAssemblyDatabyte(data, "\n")
+		referenceSize = len(data)
+		if isSprite {
+			bufferSize = len(imageReference)
+		}
 	} else {
 		dataCode += ascii.FormatAssemblyDatabyte(imageReference, "\n")
+		referenceSize = len(imageReference)
 	}
 	// copy of all delta
 	for i := 0; i < len(delta); i++ {
@@ -282,30 +306,39 @@ func ExportDeltaAnimate(imageReference []byte, delta []*transformation.DeltaColl
 		if exportVersion == DeltaExportV1 {
 			data, err = dc.Marshall()
 			if err != nil {
-				return "", err
+				return "", "", err
 			}
 		} else {
 			v2 := transformation.DeltaCollectionV2{DeltaCollection: dc}
 			data, err = v2.Marshall()
 			if err != nil {
-				return "", err
+				return "", "", err
 			}
 		}
 		name := fmt.Sprintf("delta%.2d", i)
-		dataCode += name + ":\n"
 		if cfg.Compression != compression.NONE {
 			fmt.Fprintf(os.Stdout, "Using Zx0 cruncher")
+			if len(data) > bufferSize {
+				bufferSize = len(data)
+			}
 			if dc.OccurencePerFrame != 0 {
-				d := zx0.Encode(data)
-				dataCode += ascii.FormatAssemblyDatabyte(d, "\n")
+				data = zx0.Encode(data)
 			} else {
+				data = nil
 				nbDelta--
 			}
-		} else {
-			dataCode += ascii.FormatAssemblyDatabyte(data, "\n")
 		}
 		deltaIndex = append(deltaIndex, name)
+		deltaData = append(deltaData, data)
+		deltaSizes = append(deltaSizes, len(data))
 	}
+	layout, err := NewDeltaLayout(referenceSize+len(delta)*deltaTableSize+len(palette), deltaSizes, bufferSize)
+	layout.Report()
+	if err != nil {
+		return "", "", err
+	}
+	mainDelta, upperDelta, bankDelta := layout.code(deltaIndex, deltaData, "martine-animate.dsk")
+	dataCode += mainDelta
 	dataCode += "table_delta:\n"
 	ascii.ByteToken = "dw"
 	dataCode += ascii.FormatAssemblyString(deltaIndex, "\n")
@@ -329,7 +362,7 @@ func ExportDeltaAnimate(imageReference []byte, delta []*transformation.DeltaColl
 	header = strings.Replace(header, "$NBDELTA$", nbDeltaLabel, 1)
 
 	// replace char large for the screen
-	charLarge := fmt.Sprintf("#%.4x", 0xC000+cfg.LineWidth)
+	charLarge := fmt.Sprintf("#%.4x", cfg.Geometry.Page()+cfg.Geometry.LineWidth())
 	header = strings.Replace(header, "$LIGNELARGE$", charLarge, 1)
 
 	// replace heigth
@@ -353,15 +386,30 @@ func ExportDeltaAnimate(imageReference []byte, delta []*transformation.DeltaColl
 	// replace mode
 	header = strings.Replace(header, "$SETMODE$", modeSet, 1)
 
-	code += header
+	header, timingData, err := withTiming(header, timings)
+	if err != nil {
+		return "", "", err
+	}
+	header, err = withGeometry(header, cfg.Geometry)
+	if err != nil {
+		return "", "", err
+	}
+	code += layout.withBanks(header)
+	code += timingData
 	code += dataCode
 	if cfg.Compression != compression.NONE {
-		code += "\nbuffer:\n"
+		if layout.Banked {
+			code += fmt.Sprintf("\nbuffer equ #%.4x\n", deltaBufferAddress)
+		} else {
+			code += "\nbuffer:\n"
+		}
 	}
 	code += "\nend\n"
 	code += "\nsave'disc.bin',#200, end - start,DSK,'martine-animate.dsk'"
+	code += upperDelta
+	code += bankDelta
 
-	return code, nil
+	return code, layout.Loader(), nil
 }
 
 func exportDeltaAnimate(
@@ -374,10 +422,14 @@ func exportDeltaAnimate(
 	mode uint8,
 	filename string,
 	exportVersion DeltaExportFormat,
+	timings []FrameTiming,
 ) error {
 	var sourceCode string = deltaCodeDelta
 	var dataCode string
 	var deltaIndex []string
+	var deltaData [][]byte
+	var deltaSizes []int
+	var referenceSize, bufferSize int
 	var code string
 	nbDelta := len(delta)
 	if exportVersion == DeltaExportV2 {
@@ -405,8 +457,13 @@ func exportDeltaAnimate(
 		fmt.Fprintf(os.Stdout, "Using Zx0 cruncher")
 		data := zx0.Encode(imageReference)
 		dataCode += ascii.FormatAssemblyDatabyte(data, "\n")
+		referenceSize = len(data)
+		if isSprite {
+			bufferSize = len(imageReference)
+		}
 	} else {
 		dataCode += ascii.FormatAssemblyDatabyte(imageReference, "\n")
+		referenceSize = len(imageReference)
 	}
 	// copy of all delta
 	for i := 0; i < len(delta); i++ {
@@ -426,21 +483,29 @@ func exportDeltaAnimate(
 			}
 		}
 		name := fmt.Sprintf("delta%.2d", i)
-		dataCode += name + ":\n"
 		if cfg.Compression != compression.NONE {
 			fmt.Fprintf(os.Stdout, "Using Zx0 cruncher")
+			if len(data) > bufferSize {
+				bufferSize = len(data)
+			}
 			if dc.OccurencePerFrame != 0 {
-				d := zx0.Encode(data)
-				dataCode += ascii.FormatAssemblyDatabyte(d, "\n")
-				dataCode += ascii.FormatAssemblyDatabyte(d, "\n")
+				data = zx0.Encode(data)
 			} else {
+				data = nil
 				nbDelta--
 			}
-		} else {
-			dataCode += ascii.FormatAssemblyDatabyte(data, "\n")
 		}
 		deltaIndex = append(deltaIndex, name)
+		deltaData = append(deltaData, data)
+		deltaSizes = append(deltaSizes, len(data))
 	}
+	layout, err := NewDeltaLayout(referenceSize+len(delta)*deltaTableSize+len(palette), deltaSizes, bufferSize)
+	layout.Report()
+	if err != nil {
+		return err
+	}
+	mainDelta, upperDelta, bankDelta := layout.code(deltaIndex, deltaData, "delta.dsk")
+	dataCode += mainDelta
 	dataCode += "table_delta:\n"
 	ascii.ByteToken = "dw"
 	dataCode += ascii.FormatAssemblyString(deltaIndex, "\n")
@@ -465,7 +530,7 @@ func exportDeltaAnimate(
 	header = strings.Replace(header, "$NBDELTA$", nbDeltaLabel, 1)
 
 	// replace char large for the screen
-	charLarge := fmt.Sprintf("#%.4x", 0xC000+cfg.LineWidth)
+	charLarge := fmt.Sprintf("#%.4x", cfg.Geometry.Page()+cfg.Geometry.LineWidth())
 	header = strings.Replace(header, "$LIGNELARGE$", charLarge, 1)
 
 	// replace heigth
@@ -489,14 +554,36 @@ func exportDeltaAnimate(
 	// replace mode
 	header = strings.Replace(header, "$SETMODE$", modeSet, 1)
 
-	code += header
+	header, timingData, err := withTiming(header, timings)
+	if err != nil {
+		return err
+	}
+	header, err = withGeometry(header, cfg.Geometry)
+	if err != nil {
+		return err
+	}
+	code += layout.withBanks(header)
+	code += timingData
 	code += dataCode
 	code += "\nend\n"
 	code += "\nsave'disc.bin',#200, end - start,DSK,'delta.dsk'"
 	if cfg.Compression != compression.NONE {
-		code += "\nbuffer dw 0\n"
+		if layout.Banked {
+			code += fmt.Sprintf("\nbuffer equ #%.4x\n", deltaBufferAddress)
+		} else {
+			code += "\nbuffer dw 0\n"
+		}
 	}
+	code += upperDelta
+	code += bankDelta
 
+	if loader := layout.Loader(); loader != "" {
+		loaderPath := filepath.Join(filepath.Dir(filename), "DELTA.BAS")
+		fmt.Fprintf(os.Stdout, "Saving the basic loader of the banks (%s)\n", loaderPath)
+		if err := amsdos.SaveStringOSFile(loaderPath, loader); err != nil {
+			return err
+		}
+	}
 	return amsdos.SaveStringOSFile(filename, code)
 }
 
@@ -52,7 +52,7 @@ func TestInternalDelta(t *testing.T) {
 		OneRow:          false,
 		FilloutGif:      false,
 	}
-	err := DeltaPacking("../../samples/coke.gif", ex, 0xc010, 1, DeltaExportV1)
+	err := DeltaPacking("../../samples/coke.gif", ex, 0xc010, 1, DeltaExportV1, "")
 	if err != nil {
 		t.Fatal(err)
 	}
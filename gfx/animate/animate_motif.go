@@ -4,131 +4,504 @@ import (
 	"fmt"
 	"image"
 	"image/color"
-	"image/gif"
 	"os"
 	"path/filepath"
+	"sort"
 
-	"github.com/disintegration/imaging"
 	"github.com/jeromelesaux/martine/config"
-	"github.com/jeromelesaux/martine/constants"
-	ci "github.com/jeromelesaux/martine/convert/image"
-	"github.com/jeromelesaux/martine/convert/sprite"
 	"github.com/jeromelesaux/martine/export/amsdos"
 	"github.com/jeromelesaux/martine/export/ascii"
+	"github.com/jeromelesaux/martine/gfx/errors"
 	"github.com/jeromelesaux/martine/gfx/transformation"
-	zx0 "github.com/jeromelesaux/zx0/encode"
 )
 
-func DeltaMotif(gitFilepath string, cfg *config.MartineConfig, threshold int, initialAddress uint16, mode uint8) error {
-	fr, err := os.Open(gitFilepath)
-	if err != nil {
-		return err
-	}
-	defer fr.Close()
-	gifImages, err := gif.DecodeAll(fr)
-	if err != nil {
-		return err
+const (
+	// motifWidth and motifHeight are the size of a motif in bytes and lines
+	motifWidth  = 2
+	motifHeight = 8
+	// motifLiteral is followed by the bytes of the tile
+	motifLiteral = 0xFE
+	// motifPatch is followed by the residual of the tile on the screen
+	motifPatch = 0xFF
+	// motifMaxDictionary is the maximum number of motifs of the dictionary
+	motifMaxDictionary = motifLiteral
+	// motifClustering is the maximum number of iterations of the clustering
+	motifClustering = 8
+)
+
+// motifTile is a tile of motifWidth bytes on motifHeight lines of the frames,
+// the tiles on the right and bottom edges may be partial.
+type motifTile struct {
+	address uint16
+	offsets []int // offset of each byte in the raw data, -1 outside the frame
+}
+
+func (t motifTile) complete() bool {
+	for _, o := range t.offsets {
+		if o == -1 {
+			return false
+		}
 	}
-	images := ConvertToImage(*gifImages)
-	// convertion de la palette en mode 1
-	size := constants.Mode1
+	return true
+}
 
-	screens := make([]*image.NRGBA, 0)
-	// resize all images
-	for _, v := range images {
-		out := ci.Resize(v, size, imaging.NearestNeighbor)
-		screens = append(screens, out)
+func (t motifTile) bytes(raw []byte) []byte {
+	b := make([]byte, len(t.offsets))
+	for i, o := range t.offsets {
+		if o != -1 {
+			b[i] = raw[o]
+		}
 	}
+	return b
+}
 
-	// downgrading palette
-	customPalette, _, err := ci.DowngradingPalette(screens[0], size, cfg.CpcPlus)
-	if err != nil {
-		return err
+// motifTiles cuts the sprite at (x0,y0) or the screen #C000 in tiles.
+func motifTiles(f deltaFrames) []motifTile {
+	width, lines := f.size.Width, 0
+	if width != 0 {
+		lines = len(f.raw[0]) / width
 	}
-	// converting all screens
-	for index, v := range screens {
-		_, out := ci.DowngradingWithPalette(v, customPalette)
-		screens[index] = out
+	line := func(y int) (int, uint16) {
+		return y * f.size.Width, uint16(transformation.DeltaAddress(f.x0, f.y0+y, f.geometry) + f.geometry.Page())
 	}
-
-	// recuperation des motifs
-	a := transformation.AnalyzeTilesBoard(screens[0], constants.Size{Width: 4, Height: 4})
-	refBoard := a.ReduceTilesNumber(float64(threshold))
-	btc := make([][]transformation.BoardTile, 0)
-	btc = append(btc, refBoard)
-	refTiles := transformation.GetUniqTiles(refBoard)
-	err = a.Image("../../test/motifs/first.png", refBoard, a.ImageSize)
-	if err != nil {
-		return err
+	if !f.isSprite {
+		width, lines = f.geometry.LineWidth(), f.geometry.CharLines()*(0x800/f.geometry.LineWidth())
+		line = func(y int) (int, uint16) {
+			offset := transformation.DeltaAddress(0, y, f.geometry)
+			return offset, uint16(f.geometry.Page() + offset)
+		}
 	}
-	// application des motifs sur toutes les images
-	for i := 1; i < len(screens); i++ {
-		ab := transformation.AnalyzeTilesBoardWithTiles(screens[i], constants.Size{Width: 4, Height: 4}, refTiles)
-		board := ab.BoardTiles
-		btc = append(btc, board)
-		err = ab.Image(fmt.Sprintf("../../test/motifs/%.2d.png", i), board, a.ImageSize)
-		if err != nil {
-			continue
+	tiles := make([]motifTile, 0)
+	for y := 0; y < lines; y += motifHeight {
+		for x := 0; x < width; x += motifWidth {
+			_, address := line(y)
+			t := motifTile{address: address + uint16(x), offsets: make([]int, motifWidth*motifHeight)}
+			for j := 0; j < motifHeight; j++ {
+				offset, _ := line(y + j)
+				for i := 0; i < motifWidth; i++ {
+					t.offsets[j*motifWidth+i] = -1
+					if y+j < lines && x+i < width && offset+x+i < len(f.raw[0]) {
+						t.offsets[j*motifWidth+i] = offset + x + i
+					}
+				}
+			}
+			tiles = append(tiles, t)
 		}
 	}
+	return tiles
+}
 
-	motifs := make([][]byte, 0)
+func motifDistance(a, b []byte) int {
+	d := 0
+	for i := range a {
+		if a[i] != b[i] {
+			d++
+		}
+	}
+	return d
+}
 
-	/* conversion des sprites en mode cpc */
-	for i := 0; i < len(refTiles); i++ {
-		sprt := (&refTiles[i]).Image()
-		data, _, _, _ := sprite.ToSprite(sprt, customPalette, refTiles[i].Size, 1, cfg)
-		motifs = append(motifs, data)
+// motifResidual returns the count and the position (line<<4 + byte) and
+// value of the bytes of the target differing from the tile.
+func motifResidual(t motifTile, from, to []byte) []byte {
+	r := []byte{0}
+	for i, o := range t.offsets {
+		if o != -1 && from[i] != to[i] {
+			r = append(r, byte((i/motifWidth)<<4+i%motifWidth), to[i])
+			r[0]++
+		}
 	}
+	return r
+}
 
-	/* calcul des coordonnées */
-	deltas := make([][]byte, 0)
-	for _, v := range btc {
-		// nbCoords := size.Width * size.Height / 2 / 4
-		delta := make([]byte, 0)
-		index := 0
-		pixel := 0
-		for j := 0; j < size.Height; j += 4 {
-			for i := 0; i < size.Width; i += 4 {
-				var pos int
-				if t := transformation.GetTile(v, i, j); t != nil {
-					pos = transformation.GetTilePostion(t, refTiles)
+// MotifDictionary learns a dictionary of size motifs over the tiles by
+// clustering (k-modes on the bytes), the tiles are weighted by their count.
+func MotifDictionary(tiles [][]byte, size int) [][]byte {
+	counts := make(map[string]int)
+	uniq := make([][]byte, 0)
+	for _, t := range tiles {
+		if counts[string(t)] == 0 {
+			uniq = append(uniq, t)
+		}
+		counts[string(t)]++
+	}
+	sort.SliceStable(uniq, func(i, j int) bool { return counts[string(uniq[i])] > counts[string(uniq[j])] })
+	if size > len(uniq) {
+		size = len(uniq)
+	}
+	dictionary := make([][]byte, size)
+	for i := range dictionary {
+		dictionary[i] = append([]byte{}, uniq[i]...)
+	}
+	if size == 0 {
+		return dictionary
+	}
+	assignment := make([]int, len(uniq))
+	for iteration := 0; iteration < motifClustering; iteration++ {
+		changed := iteration == 0
+		for i, t := range uniq {
+			best := 0
+			for m := range dictionary {
+				if motifDistance(dictionary[m], t) < motifDistance(dictionary[best], t) {
+					best = m
 				}
-				if index == 2 {
-					delta = append(delta, byte(pixel))
-					pixel = 0
-					index = 0
+			}
+			if assignment[i] != best {
+				assignment[i] = best
+				changed = true
+			}
+		}
+		if !changed {
+			break
+		}
+		// the motif becomes the most frequent byte of each position
+		votes := make([][motifWidth * motifHeight][256]int, len(dictionary))
+		for i, t := range uniq {
+			for p, v := range t {
+				votes[assignment[i]][p][v] += counts[string(t)]
+			}
+		}
+		for m := range dictionary {
+			for p := range dictionary[m] {
+				for v, n := range votes[m][p] {
+					if n > votes[m][p][dictionary[m][p]] {
+						dictionary[m][p] = byte(v)
+					}
 				}
-				if index == 0 {
-					pixel += (pos << 4)
-				} else {
-					pixel += pos
+			}
+		}
+	}
+	return dictionary
+}
+
+// motifFrame encodes the changed tiles of the transition as references to a
+// motif plus residual, literals or residuals on the screen, the cheapest.
+func motifFrame(tiles []motifTile, from, to []byte, dictionary [][]byte) []byte {
+	frame := make([]byte, 0)
+	for _, t := range tiles {
+		current, target := t.bytes(from), t.bytes(to)
+		if motifDistance(current, target) == 0 {
+			continue
+		}
+		encoded := append([]byte{motifPatch}, motifResidual(t, current, target)...)
+		if t.complete() {
+			if len(target)+1 < len(encoded) {
+				encoded = append([]byte{motifLiteral}, target...)
+			}
+			for m, motif := range dictionary {
+				if r := motifResidual(t, motif, target); len(r)+1 < len(encoded) {
+					encoded = append([]byte{byte(m)}, r...)
 				}
-				index++
 			}
 		}
-		deltas = append(deltas, delta)
+		frame = append(frame, byte(t.address), byte(t.address>>8))
+		frame = append(frame, encoded...)
 	}
-	filename := string(cfg.OsFilename(".asm"))
-	return exportDeltaMotif(deltas, motifs, customPalette, cfg, cfg.OutputPath+string(filepath.Separator)+filename)
+	// the high byte of the address 0 ends the frame
+	return append(frame, 0, 0)
+}
+
+// MotifAnimation is an animation encoded with a dictionary of motifs. First
+// draws the frame 0 on the cleared screen and Frames[i] transforms the frame
+// i into the frame i+1 (the last one into the frame 0).
+type MotifAnimation struct {
+	Dictionary [][]byte
+	First      []byte
+	Frames     [][]byte
+	Palette    color.Palette
+	IsSprite   bool
+}
+
+// Size returns the size in bytes of the dictionary and the frames.
+func (m MotifAnimation) Size() int {
+	size := len(m.First) + len(m.Dictionary)*motifWidth*motifHeight
+	for _, f := range m.Frames {
+		size += len(f)
+	}
+	return size
 }
 
-func exportDeltaMotif(images [][]byte, motifs [][]byte, p color.Palette, cfg *config.MartineConfig, filename string) error {
-	var deltaCode string
-	for i := 0; i < len(images); i++ {
-		deltaCode += fmt.Sprintf("delta%.2d\n", i)
-		encoded := zx0.Encode(images[i])
-		deltaCode += ascii.FormatAssemblyDatabyte(encoded, "\n")
+// MotifReport compares the size of the motif encoding with the delta packing
+// V1 and V2.
+type MotifReport struct {
+	Motif, DeltaV1, DeltaV2 int
+	Dictionary              int
+}
+
+// Winner returns the name of the smallest encoding.
+func (r MotifReport) Winner() string {
+	if r.Motif <= r.DeltaV1 && r.Motif <= r.DeltaV2 {
+		return "motif"
+	}
+	if r.DeltaV2 < r.DeltaV1 {
+		return "delta V2"
+	}
+	return "delta V1"
+}
+
+// motifTraining returns the changed tiles of the transitions and the tiles of
+// the first frame, the dictionary is learned over them.
+func motifTraining(f deltaFrames, tiles []motifTile) [][]byte {
+	training := make([][]byte, 0)
+	for i := range f.raw {
+		from := f.raw[(i+len(f.raw)-1)%len(f.raw)]
+		for _, t := range tiles {
+			target := t.bytes(f.raw[i])
+			if t.complete() && (motifDistance(t.bytes(from), target) != 0 || i == 0) {
+				training = append(training, target)
+			}
+		}
+	}
+	return training
+}
+
+// motifEncode encodes the frames with a dictionary of size motifs.
+func motifEncode(f deltaFrames, tiles []motifTile, training [][]byte, size int) MotifAnimation {
+	m := MotifAnimation{Palette: f.palette, IsSprite: f.isSprite}
+	m.Dictionary = MotifDictionary(training, size)
+	m.First = motifFrame(tiles, make([]byte, len(f.raw[0])), f.raw[0], m.Dictionary)
+	for i := range f.raw {
+		m.Frames = append(m.Frames, motifFrame(tiles, f.raw[i], f.raw[(i+1)%len(f.raw)], m.Dictionary))
+	}
+	return m
+}
+
+// MotifPacking converts the images and encodes them with the dictionary of
+// motifs of the size (up to maxDictionary motifs) which minimises the total
+// bytes, the report compares it with the delta packing.
+func MotifPacking(images []image.Image, cfg *config.MartineConfig, initialAddress uint16, mode uint8, maxDictionary int) (MotifAnimation, MotifReport, error) {
+	var r MotifReport
+	f, err := convertDeltaFrames(images, cfg, initialAddress, mode)
+	if err != nil {
+		return MotifAnimation{}, r, err
+	}
+	if maxDictionary <= 0 || maxDictionary > motifMaxDictionary {
+		maxDictionary = motifMaxDictionary
+	}
+	tiles := motifTiles(f)
+	training := motifTraining(f, tiles)
+	var best MotifAnimation
+	for size := 0; ; size = size*2 + 4 {
+		if size > maxDictionary {
+			size = maxDictionary
+		}
+		m := motifEncode(f, tiles, training, size)
+		fmt.Fprintf(os.Stdout, "Dictionary of %d motifs : %d bytes\n", len(m.Dictionary), m.Size())
+		if best.Frames == nil || m.Size() < best.Size() {
+			best = m
+		}
+		if size == maxDictionary || len(m.Dictionary) < size {
+			break
+		}
+	}
+	r.Motif, r.Dictionary = best.Size(), len(best.Dictionary)
+	r.DeltaV1, r.DeltaV2 = len(f.raw[0]), len(f.raw[0])
+	for i := range f.raw {
+		dc := transformation.Delta(f.raw[i], f.raw[(i+1)%len(f.raw)], f.isSprite, f.size, mode, uint16(f.x0), uint16(f.y0), f.geometry)
+		v1, err := dc.Marshall()
+		if err != nil {
+			return best, r, err
+		}
+		v2, err := (&transformation.DeltaCollectionV2{DeltaCollection: dc}).Marshall()
+		if err != nil {
+			return best, r, err
+		}
+		r.DeltaV1 += len(v1)
+		r.DeltaV2 += len(v2)
 	}
+	fmt.Fprintf(os.Stdout, "Motif : %d bytes (%d motifs), delta V1 : %d bytes, delta V2 : %d bytes, %s wins\n",
+		r.Motif, r.Dictionary, r.DeltaV1, r.DeltaV2, r.Winner())
+	return best, r, nil
+}
 
-	for i := 0; i < len(motifs); i++ {
-		deltaCode += fmt.Sprintf("motif%.2d\n", i)
-		deltaCode += ascii.FormatAssemblyDatabyte(motifs[i], "\n")
+// DeltaMotif encodes the gif file with a dictionary of motifs of at most
+// maxDictionary motifs (0 for no limit) and saves the player source code.
+func DeltaMotif(gitFilepath string, cfg *config.MartineConfig, maxDictionary int, initialAddress uint16, mode uint8) error {
+	images, err := gifFrames(gitFilepath)
+	if err != nil {
+		return err
+	}
+	m, _, err := MotifPacking(images, cfg, initialAddress, mode, maxDictionary)
+	if err != nil {
+		return err
+	}
+	code, err := ExportMotifAnimation(m, cfg, mode)
+	if err != nil {
+		return err
 	}
+	filename := string(cfg.OsFilename(".asm"))
+	return amsdos.SaveStringOSFile(cfg.OutputPath+string(filepath.Separator)+filename, code)
+}
 
-	deltaCode += "palette:\n" + ascii.ByteToken + " "
-	deltaCode += ascii.FormatAssemblyBasicPalette(p, "\n")
+// ExportMotifAnimation returns the source code of the motif player with its
+// dictionary and frames.
+func ExportMotifAnimation(m MotifAnimation, cfg *config.MartineConfig, mode uint8) (string, error) {
+	if playbackLoadingAddress+playbackCodeSize+m.Size() > deltaFirmwareAddress {
+		return "", errors.ErrorDeltaMemoryOverflow
+	}
+	var dataCode string
+	motifIndex := make([]string, len(m.Dictionary))
+	for i, v := range m.Dictionary {
+		motifIndex[i] = fmt.Sprintf("motif%.2d", i)
+		dataCode += motifIndex[i] + ":\n"
+		dataCode += ascii.FormatAssemblyDatabyte(v, "\n")
+	}
+	dataCode += "motif_first:\n"
+	dataCode += ascii.FormatAssemblyDatabyte(m.First, "\n")
+	frameIndex := make([]string, len(m.Frames))
+	for i, v := range m.Frames {
+		frameIndex[i] = fmt.Sprintf("frame%.2d", i)
+		dataCode += frameIndex[i] + ":\n"
+		dataCode += ascii.FormatAssemblyDatabyte(v, "\n")
+	}
+	ascii.ByteToken = "dw"
+	dataCode += "table_frame:\n"
+	dataCode += ascii.FormatAssemblyString(frameIndex, "\n")
+	dataCode += "table_motif:\n"
+	if len(motifIndex) != 0 {
+		dataCode += ascii.FormatAssemblyString(motifIndex, "\n")
+	}
+	ascii.ByteToken = "db"
+	dataCode += "palette:\n" + ascii.ByteToken + " "
+	dataCode += ascii.FormatAssemblyBasicPalette(m.Palette, "\n")
 
-	//	fmt.Printf("%s", deltaCode)
-	return amsdos.SaveStringOSFile(filename, deltaCode)
+	var code string
+	code += fmt.Sprintf("; motif player, %d motifs, %d frames\n", len(m.Dictionary), len(m.Frames))
+	code += fmt.Sprintf("loadingaddress equ #%.4x\n", playbackLoadingAddress)
+	code += fmt.Sprintf("linewidth equ #%.4x\n", cfg.Geometry.Page()+cfg.Geometry.LineWidth())
+	code += fmt.Sprintf("nbsteps equ %d\n", len(m.Frames))
+	code += fmt.Sprintf("nbcolors equ %d\n", len(m.Palette))
+	code += fmt.Sprintf("tilewidth equ %d\ntileheight equ %d\n", motifWidth, motifHeight)
+	code += "org loadingaddress\nrun loadingaddress\n\nstart\n"
+	code += fmt.Sprintf("\tld a,%d\n\tcall #BC0E\n\tcall palettefirmware\n\tcall xvbl\n", mode)
+	code += "\tld hl,motif_first\n\tcall motif_frame\n"
+	code += "\nmainloop\n\tcall xvbl\n\tcall next_frame\n\tjp mainloop\n"
+	code += motifRoutine
+	routines, err := withGeometry(playbackRoutines, cfg.Geometry)
+	if err != nil {
+		return "", err
+	}
+	code += routines
+	code += dataCode
+	code += "\nend\n"
+	code += "\nsave'disc.bin',#200, end - start,DSK,'motif.dsk'"
+	return code, nil
 }
+
+var motifRoutine = `
+;--- routine des motifs -------------------------------
+next_frame:
+step_index:
+	ld a,-1
+	inc a
+	cp nbsteps
+	jr c, step_next
+	xor a
+step_next:
+	ld (step_index+1),a
+	ld l,a
+	ld h,0
+	add hl,hl
+	ld de,table_frame
+	add hl,de
+	ld a,(hl)
+	inc hl
+	ld h,(hl)
+	ld l,a
+motif_frame ; hl = tiles de la frame
+	ld e,(hl) ; adresse ecran de la tile
+	inc hl
+	ld d,(hl)
+	inc hl
+	ld a,d
+	or a
+	ret z
+	ld (tile_address),de
+	ld a,(hl) ; motif, litteral ou residu
+	inc hl
+	cp #FF
+	jr z,motif_residual
+	cp #FE
+	jr z,motif_literal
+	push hl
+	ld l,a
+	ld h,0
+	add hl,hl
+	ld de,table_motif
+	add hl,de
+	ld a,(hl)
+	inc hl
+	ld h,(hl)
+	ld l,a
+	call motif_copy
+	pop hl
+	jr motif_residual
+motif_literal
+	call motif_copy
+	jr motif_frame
+motif_residual
+	ld b,(hl) ; nombre d'octets differents du motif
+	inc hl
+	ld a,b
+	or a
+	jr z,motif_frame
+residual_loop
+	ld a,(hl) ; ligne<<4 + octet dans la tile
+	inc hl
+	push hl
+	push bc
+	call tile_byte
+	pop bc
+	ex de,hl
+	pop hl
+	ld a,(hl)
+	inc hl
+	ld (de),a
+	djnz residual_loop
+	jr motif_frame
+
+; copie tilewidth octets sur tileheight lignes de hl vers la tile
+motif_copy
+	ld de,(tile_address)
+	ld a,tileheight
+motif_copy_line
+	push af
+	push de
+	ld bc,tilewidth
+	ldir
+	ex (sp),hl
+	call bc26
+	ex de,hl
+	pop hl
+	pop af
+	dec a
+	jr nz,motif_copy_line
+	ret
+
+; a = ligne<<4 + octet, hl = adresse ecran de l'octet de la tile
+tile_byte
+	ld hl,(tile_address)
+	ld c,a
+	rrca
+	rrca
+	rrca
+	rrca
+	and #0F
+	jr z,tile_byte_x
+	ld b,a
+tile_byte_line
+	push bc
+	call bc26
+	pop bc
+	djnz tile_byte_line
+tile_byte_x
+	ld a,c
+	and #0F
+	add a,l
+	ld l,a
+	ret nc
+	inc h
+	ret
+
+tile_address dw 0
+`
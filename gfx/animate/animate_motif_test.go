@@ -0,0 +1,74 @@
+package animate
+
+import (
+	"testing"
+
+	"github.com/jeromelesaux/martine/constants"
+	"github.com/jeromelesaux/martine/convert/address"
+)
+
+// applyMotifFrame decodes the frame on the screen like the z80 player.
+func applyMotifFrame(screen map[uint16]byte, frame []byte, dictionary [][]byte) {
+	address := func(tile uint16, position byte) uint16 {
+		line := int(position >> 4)
+		return tile + uint16(line%8)*0x800 + uint16(position&0x0F)
+	}
+	for i := 0; frame[i+1] != 0; {
+		tile := uint16(frame[i]) | uint16(frame[i+1])<<8
+		kind := frame[i+2]
+		i += 3
+		if kind == motifLiteral {
+			for p := 0; p < motifWidth*motifHeight; p++ {
+				screen[address(tile, byte((p/motifWidth)<<4+p%motifWidth))] = frame[i+p]
+			}
+			i += motifWidth * motifHeight
+			continue
+		}
+		if kind != motifPatch {
+			for p, v := range dictionary[kind] {
+				screen[address(tile, byte((p/motifWidth)<<4+p%motifWidth))] = v
+			}
+		}
+		n := int(frame[i])
+		i++
+		for j := 0; j < n; j++ {
+			screen[address(tile, frame[i])] = frame[i+1]
+			i += 2
+		}
+	}
+}
+
+func TestMotifPacking(t *testing.T) {
+	// a sprite of 4 bytes on 8 lines at #C000 (lines aligned on the blocks)
+	f := deltaFrames{isSprite: true, size: constants.Size{Width: 4, Height: 8}, geometry: address.DefaultGeometry}
+	for i := 0; i < 3; i++ {
+		raw := make([]byte, 32)
+		for j := range raw {
+			raw[j] = byte((j + i*4) % 7)
+		}
+		f.raw = append(f.raw, raw)
+	}
+	tiles := motifTiles(f)
+	if len(tiles) != 2 || tiles[1].address != 0xC002 || tiles[0].offsets[2] != 4 {
+		t.Fatalf("unexpected tiles %v", tiles)
+	}
+	for size := 0; size < 3; size++ {
+		m := motifEncode(f, tiles, motifTraining(f, tiles), size)
+		screen := make(map[uint16]byte)
+		applyMotifFrame(screen, m.First, m.Dictionary)
+		for i, frame := range m.Frames {
+			applyMotifFrame(screen, frame, m.Dictionary)
+			expected := f.raw[(i+1)%len(f.raw)]
+			for y := 0; y < 8; y++ {
+				for x := 0; x < 4; x++ {
+					if v := screen[uint16(0xC000+y*0x800+x)]; v != expected[y*4+x] {
+						t.Fatalf("dictionary %d frame %d byte (%d,%d) expected #%.2x and gets #%.2x", size, i, x, y, expected[y*4+x], v)
+					}
+				}
+			}
+		}
+	}
+	if d := MotifDictionary([][]byte{{1, 2}, {1, 2}, {1, 3}, {4, 4}}, 1); len(d) != 1 || d[0][0] != 1 || d[0][1] != 2 {
+		t.Fatalf("unexpected dictionary %v", d)
+	}
+}
@@ -0,0 +1,470 @@
+package animate
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/jeromelesaux/martine/config"
+	"github.com/jeromelesaux/martine/constants"
+	"github.com/jeromelesaux/martine/convert/address"
+	"github.com/jeromelesaux/martine/export/amsdos"
+	"github.com/jeromelesaux/martine/export/ascii"
+	"github.com/jeromelesaux/martine/gfx"
+	"github.com/jeromelesaux/martine/gfx/errors"
+	"github.com/jeromelesaux/martine/gfx/transformation"
+)
+
+// DeltaTopology is the order in which the frames of the animation are
+// displayed by the player.
+type DeltaTopology int
+
+const (
+	// SingleBuffer loops over the frames in one screen buffer
+	SingleBuffer DeltaTopology = iota
+	// DoubleBuffer loops over the frames in the screens #C000 and #4000
+	DoubleBuffer
+	// PingPong plays the frames forward then backward
+	PingPong
+	// FrameGraph plays the frames in the order of the graph
+	FrameGraph
+)
+
+const (
+	// playbackLoadingAddress is the loading address of the player
+	playbackLoadingAddress = 0x200
+	// playbackCodeSize is the size reserved for the code of the player
+	playbackCodeSize = 0x200
+)
+
+// ParseDeltaTopology returns the topology of its name (single, double,
+// pingpong or graph).
+func ParseDeltaTopology(name string) (DeltaTopology, error) {
+	switch strings.ToLower(name) {
+	case "", "single":
+		return SingleBuffer, nil
+	case "double":
+		return DoubleBuffer, nil
+	case "pingpong":
+		return PingPong, nil
+	case "graph":
+		return FrameGraph, nil
+	}
+	return SingleBuffer, errors.ErrorBadTopology
+}
+
+// Playback is the loop of frames displayed by the player and the number of
+// screen buffers, the buffers #C000 and #4000 are swapped by the base of the
+// screen.
+type Playback struct {
+	Sequence []int
+	Buffers  int
+}
+
+// NewPlayback returns the playback of the topology for the number of frames.
+// The graph is the list of the frames of the loop (ex 0,1,2,1,0,3) used by
+// the FrameGraph topology. The ping-pong and graph playbacks use the two
+// buffers if double is set.
+func NewPlayback(topology DeltaTopology, frames int, graph string, double bool) (Playback, error) {
+	p := Playback{Buffers: 1}
+	if double {
+		p.Buffers = 2
+	}
+	switch topology {
+	case SingleBuffer, DoubleBuffer:
+		for i := 0; i < frames; i++ {
+			p.Sequence = append(p.Sequence, i)
+		}
+		p.Buffers = 1
+		if topology == DoubleBuffer {
+			p.Buffers = 2
+		}
+	case PingPong:
+		for i := 0; i < frames; i++ {
+			p.Sequence = append(p.Sequence, i)
+		}
+		for i := frames - 2; i > 0; i-- {
+			p.Sequence = append(p.Sequence, i)
+		}
+	case FrameGraph:
+		for _, v := range strings.Split(graph, ",") {
+			frame, err := strconv.Atoi(strings.TrimSpace(v))
+			if err != nil || frame < 0 || frame >= frames {
+				return p, errors.ErrorBadFrameGraph
+			}
+			p.Sequence = append(p.Sequence, frame)
+		}
+	default:
+		return p, errors.ErrorBadTopology
+	}
+	if len(p.Sequence) < 2 {
+		return p, errors.ErrorBadFrameGraph
+	}
+	return p, nil
+}
+
+// Steps returns the frames displayed by the loop of the player. The sequence
+// is repeated until each step uses the same buffer at every loop.
+func (p Playback) Steps() []int {
+	steps := append([]int{}, p.Sequence...)
+	for len(steps)%p.Buffers != 0 {
+		steps = append(steps, p.Sequence...)
+	}
+	return steps
+}
+
+// Transitions returns the source and target frames of the deltas of the
+// loop. The delta i displays the step i+1 in the buffer (i+1)%Buffers which
+// contains the frame displayed Buffers steps before.
+func (p Playback) Transitions() [][2]int {
+	steps := p.Steps()
+	l := len(steps)
+	transitions := make([][2]int, l)
+	for i := range transitions {
+		transitions[i] = [2]int{steps[(i+1-p.Buffers+l)%l], steps[(i+1)%l]}
+	}
+	return transitions
+}
+
+// DeltaPlayback contains the deltas of a playback, the same transition shares
+// its delta. Table is the index of the delta of each step of the loop and Init
+// the index of the delta which prepares the second buffer.
+type DeltaPlayback struct {
+	Playback  Playback
+	Reference []byte
+	Deltas    []*transformation.DeltaCollection
+	Table     []int
+	Init      int
+	Palette   color.Palette
+	IsSprite  bool
+}
+
+// deltaFrames contains the converted frames of an animation and their
+// position on the screen.
+type deltaFrames struct {
+	raw      [][]byte
+	palette  color.Palette
+	isSprite bool
+	size     constants.Size
+	x0, y0   int
+	geometry address.Geometry
+}
+
+// convertDeltaFrames converts all the images with the palette of the first
+// one.
+func convertDeltaFrames(images []image.Image, cfg *config.MartineConfig, initialAddress uint16, mode uint8) (deltaFrames, error) {
+	f := deltaFrames{isSprite: true, geometry: cfg.Geometry}
+	if !cfg.CustomDimension && !cfg.SpriteHard {
+		f.isSprite = false
+	}
+	if len(images) <= 1 {
+		return f, fmt.Errorf("need more than one image to proceed")
+	}
+	var err error
+	_, _, f.palette, _, err = gfx.ApplyOneImage(images[0], cfg, int(mode), f.palette, mode)
+	if err != nil {
+		return f, err
+	}
+	for i, in := range images {
+		raw, _, _, _, err := gfx.ApplyOneImage(in, cfg, int(mode), f.palette, mode)
+		if err != nil {
+			return f, err
+		}
+		f.raw = append(f.raw, raw)
+		fmt.Printf("Image [%d] proceed\n", i)
+	}
+	f.x0, f.y0, err = transformation.CpcCoordinates(initialAddress, uint16(f.geometry.Page()), f.geometry)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error while computing cpc coordinates :%v\n", err)
+	}
+	f.size = constants.Size{Width: cfg.Size.Width, Height: cfg.Size.Height}
+	if f.isSprite {
+		f.size.Width = f.size.ModeWidth(mode)
+	}
+	if cfg.StableDithering {
+		f.raw, err = stableRawImages(images, f.raw, cfg, mode, f.palette, f.isSprite, f.size, f.x0, f.y0, f.geometry)
+		if err != nil {
+			return f, err
+		}
+	}
+	for i := 1; i < len(f.raw); i++ {
+		if len(f.raw[i]) != len(f.raw[0]) {
+			return f, errors.ErrorSizeDiffers
+		}
+	}
+	return f, nil
+}
+
+// DeltaPackingPlayback converts the images and computes the deltas of the
+// playback.
+func DeltaPackingPlayback(images []image.Image, cfg *config.MartineConfig, initialAddress uint16, mode uint8, p Playback) (DeltaPlayback, error) {
+	dp := DeltaPlayback{Playback: p, Init: -1}
+	f, err := convertDeltaFrames(images, cfg, initialAddress, mode)
+	if err != nil {
+		return dp, err
+	}
+	dp.Palette, dp.IsSprite = f.palette, f.isSprite
+
+	indexes := make(map[[2]int]int)
+	delta := func(t [2]int) int {
+		if i, ok := indexes[t]; ok {
+			return i
+		}
+		dc := transformation.Delta(f.raw[t[0]], f.raw[t[1]], f.isSprite, f.size, mode, uint16(f.x0), uint16(f.y0), f.geometry)
+		fmt.Printf("Compare image [%d] with [%d] %d bytes differ from the both images\n", t[0], t[1], len(dc.Items))
+		indexes[t] = len(dp.Deltas)
+		dp.Deltas = append(dp.Deltas, dc)
+		return indexes[t]
+	}
+	steps := p.Steps()
+	dp.Reference = f.raw[steps[0]]
+	if p.Buffers == 2 {
+		// the second buffer is a copy of the first one patched to the step 1
+		dp.Init = delta([2]int{steps[0], steps[1]})
+	}
+	for _, t := range p.Transitions() {
+		dp.Table = append(dp.Table, delta(t))
+	}
+	return dp, nil
+}
+
+// gifFrames returns the images of the gif file.
+func gifFrames(gifFilepath string) ([]image.Image, error) {
+	fr, err := os.Open(gifFilepath)
+	if err != nil {
+		return nil, err
+	}
+	defer fr.Close()
+	gifImages, err := gif.DecodeAll(fr)
+	if err != nil {
+		return nil, err
+	}
+	images := make([]image.Image, 0)
+	for _, v := range ConvertToImage(*gifImages) {
+		images = append(images, v)
+	}
+	return images, nil
+}
+
+// DeltaPackingGifPlayback computes the deltas of the playback of the gif file
+// and saves the player source code.
+func DeltaPackingGifPlayback(gifFilepath string, cfg *config.MartineConfig, initialAddress uint16, mode uint8, topology DeltaTopology, graph string, double bool) error {
+	images, err := gifFrames(gifFilepath)
+	if err != nil {
+		return err
+	}
+	p, err := NewPlayback(topology, len(images), graph, double)
+	if err != nil {
+		return err
+	}
+	dp, err := DeltaPackingPlayback(images, cfg, initialAddress, mode, p)
+	if err != nil {
+		return err
+	}
+	code, err := ExportDeltaPlayback(dp, cfg, initialAddress, mode)
+	if err != nil {
+		return err
+	}
+	filename := string(cfg.OsFilename(".asm"))
+	return amsdos.SaveStringOSFile(cfg.OutputPath+string(filepath.Separator)+filename, code)
+}
+
+// ExportDeltaPlayback returns the source code of the player of the deltas
+// with its data. The data are not compressed. With two buffers, the player
+// patches the hidden buffer then displays it at the vbl and the program must
+// end before the screen #4000.
+func ExportDeltaPlayback(dp DeltaPlayback, cfg *config.MartineConfig, initialAddress uint16, mode uint8) (string, error) {
+	double := dp.Playback.Buffers == 2
+	var dataCode string
+	size := len(dp.Reference)
+	dataCode += "sprite:\n"
+	dataCode += ascii.FormatAssemblyDatabyte(dp.Reference, "\n")
+	for i, dc := range dp.Deltas {
+		data, err := dc.Marshall()
+		if err != nil {
+			return "", err
+		}
+		size += len(data) + 2
+		dataCode += fmt.Sprintf("delta%.2d:\n", i)
+		dataCode += ascii.FormatAssemblyDatabyte(data, "\n")
+	}
+	if double && playbackLoadingAddress+playbackCodeSize+size > 0x4000 {
+		return "", errors.ErrorPlaybackTooLong
+	}
+	deltaIndex := make([]string, len(dp.Table))
+	for i, v := range dp.Table {
+		deltaIndex[i] = fmt.Sprintf("delta%.2d", v)
+	}
+	dataCode += "table_delta:\n"
+	ascii.ByteToken = "dw"
+	dataCode += ascii.FormatAssemblyString(deltaIndex, "\n")
+	ascii.ByteToken = "db"
+	dataCode += "palette:\n" + ascii.ByteToken + " "
+	dataCode += ascii.FormatAssemblyBasicPalette(dp.Palette, "\n")
+
+	var code string
+	code += fmt.Sprintf("; delta player, %d steps, %d buffers, frames %v\n", len(dp.Table), dp.Playback.Buffers, dp.Playback.Steps())
+	code += fmt.Sprintf("large equ %d\n", cfg.Size.ModeWidth(mode))
+	code += fmt.Sprintf("haut equ %d\n", cfg.Size.Height)
+	code += fmt.Sprintf("loadingaddress equ #%.4x\n", playbackLoadingAddress)
+	code += fmt.Sprintf("linewidth equ #%.4x\n", cfg.Geometry.Page()+cfg.Geometry.LineWidth())
+	code += fmt.Sprintf("nbsteps equ %d\n", len(dp.Table))
+	code += fmt.Sprintf("nbcolors equ %d\n", len(dp.Palette))
+	code += "org loadingaddress\nrun loadingaddress\n\nstart\n"
+	code += fmt.Sprintf("\tld a,%d\n\tcall #BC0E\n\tcall palettefirmware\n\tcall xvbl\n", mode)
+	code += "; first frame in the buffer #C000\n"
+	if dp.IsSprite {
+		code += fmt.Sprintf("\tld de,#%.4x\n", initialAddress)
+		code += "\tld hl,sprite\n\tld a,haut\ncopyline\n\tpush af\n\tpush de\n\tld bc,large\n\tldir\n" +
+			"\tex (sp),hl\n\tcall bc26\n\tex de,hl\n\tpop hl\n\tpop af\n\tdec a\n\tjr nz,copyline\n"
+	} else {
+		code += fmt.Sprintf("\tld hl,sprite\n\tld de,#C000\n\tld bc,#%.4x\n\tldir\n", len(dp.Reference))
+	}
+	if double {
+		code += "; second frame in the buffer #4000\n"
+		code += "\tld hl,#C000\n\tld de,#4000\n\tld bc,#4000\n\tldir\n"
+		code += fmt.Sprintf("\tcall swap_buffer\n\tld hl,delta%.2d\n\tcall delta\n\tcall swap_buffer\n", dp.Init)
+	}
+	code += "\nmainloop\n"
+	if double {
+		code += "\tcall swap_buffer\n"
+	}
+	code += "\tcall next_delta\n\tcall xvbl\n"
+	if double {
+		code += "\tld a,(buffer)\n\tcall #BC08 ; display the patched buffer\n"
+	}
+	code += "\tjp mainloop\n"
+	if double {
+		code += "\n; the deltas are poked in the other buffer\nswap_buffer\n\tld a,(buffer)\n\txor #80\n\tld (buffer),a\n"
+		if dp.IsSprite {
+			// the addresses of the sprite deltas are in the screen #C000
+			code += "\txor #C0\n"
+		}
+		code += "\tld (bufferhigh+1),a\n\tret\n"
+	}
+	bufferHigh := 0xC0
+	if dp.IsSprite {
+		bufferHigh = 0
+	}
+	code += fmt.Sprintf(playbackDeltaRoutine, bufferHigh)
+	routines, err := withGeometry(playbackRoutines, cfg.Geometry)
+	if err != nil {
+		return "", err
+	}
+	code += routines
+	code += dataCode
+	code += "\nbuffer db #C0\npixel db 0\n"
+	code += "\nend\n"
+	code += "\nsave'disc.bin',#200, end - start,DSK,'delta.dsk'"
+	return code, nil
+}
+
+var playbackDeltaRoutine = `
+;--- routine de deltapacking --------------------------
+next_delta:
+step_index:
+	ld a,-1
+	inc a
+	cp nbsteps
+	jr c, step_next
+	xor a
+step_next:
+	ld (step_index+1),a
+	ld l,a
+	ld h,0
+	add hl,hl
+	ld de,table_delta
+	add hl,de
+	ld a,(hl)
+	inc hl
+	ld h,(hl)
+	ld l,a
+delta
+	ld a,(hl) ; nombre de byte a poker
+	or a
+	ret z
+	inc hl
+init
+	push af
+	ld a,(hl) ; octet a poker
+	ld (pixel),a
+	inc hl
+	ld c,(hl) ; nbfois
+	inc hl
+	ld b,(hl)
+	inc hl
+poke_octet
+	ld e,(hl)
+	inc hl
+	ld a,(hl)
+	inc hl
+bufferhigh
+	add a,#%.2x ; poids fort du buffer
+	ld d,a
+	ld a,(pixel)
+	ld (de),a
+	dec bc
+	ld a,b
+	or c
+	jr nz, poke_octet
+	pop af
+	dec a
+	jr nz,init
+	ret
+`
+
+var playbackRoutines = `
+;--- attente de plusieurs vbl ---
+xvbl ld e,50
+	call waitvbl
+	dec e
+	jr nz,xvbl+2
+	ret
+
+;---- attente vbl ----------
+waitvbl
+	ld b,#f5
+vbl
+	in a,(c)
+	rra
+	jp nc,vbl
+	ret
+
+;--- application palette firmware -------------
+palettefirmware
+	ld e,nbcolors
+	ld a,0
+	ld hl,palette
+paletteloop
+	ld b,(hl)
+	ld c,b
+	push af
+	push de
+	push hl
+	call #bc32 ; af, de, hl corrupted
+	pop hl
+	pop de
+	pop af
+	inc a
+	inc hl
+	dec e
+	jr nz,paletteloop
+	ret
+
+;---- recuperation de l'adresse de la ligne en dessous ------------
+bc26
+	ld a,h
+	add a,8
+	ld h,a
+	ret nc
+	ld bc,linewidth
+	add hl,bc
+	res 3,h
+	ret
+
+`
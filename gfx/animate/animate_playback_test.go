@@ -0,0 +1,38 @@
+package animate
+
+import (
+	"testing"
+)
+
+func TestPlaybackTransitions(t *testing.T) {
+	p, err := NewPlayback(DoubleBuffer, 3, "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// odd number of frames, the loop is played twice to keep the buffers
+	if steps := p.Steps(); len(steps) != 6 {
+		t.Fatalf("expected 6 steps and gets %v", steps)
+	}
+	// each buffer is patched from the frame displayed two steps before
+	expected := [][2]int{{2, 1}, {0, 2}, {1, 0}, {2, 1}, {0, 2}, {1, 0}}
+	for i, v := range p.Transitions() {
+		if v != expected[i] {
+			t.Fatalf("transition %d expected %v and gets %v", i, expected[i], v)
+		}
+	}
+
+	p, err = NewPlayback(PingPong, 4, "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = [][2]int{{0, 1}, {1, 2}, {2, 3}, {3, 2}, {2, 1}, {1, 0}}
+	for i, v := range p.Transitions() {
+		if v != expected[i] {
+			t.Fatalf("transition %d expected %v and gets %v", i, expected[i], v)
+		}
+	}
+
+	if _, err := NewPlayback(FrameGraph, 3, "0,1,4", false); err == nil {
+		t.Fatalf("expected an error for the frame 4")
+	}
+}
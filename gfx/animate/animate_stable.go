@@ -0,0 +1,142 @@
+package animate
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"os"
+
+	"github.com/disintegration/imaging"
+	"github.com/jeromelesaux/martine/config"
+	"github.com/jeromelesaux/martine/constants"
+	"github.com/jeromelesaux/martine/convert/address"
+	ci "github.com/jeromelesaux/martine/convert/image"
+	"github.com/jeromelesaux/martine/gfx"
+	"github.com/jeromelesaux/martine/gfx/filter"
+	"github.com/jeromelesaux/martine/gfx/transformation"
+)
+
+// StableReport compares the size of the deltas of the frames converted one by
+// one and of the frames converted with the stable dithering.
+type StableReport struct {
+	NaiveSize  int
+	StableSize int
+}
+
+// Saved returns the number of bytes saved by the stable conversion.
+func (s StableReport) Saved() int {
+	return s.NaiveSize - s.StableSize
+}
+
+func (s StableReport) String() string {
+	return fmt.Sprintf("deltas naive conversion %d bytes, stable conversion %d bytes, saved %d bytes", s.NaiveSize, s.StableSize, s.Saved())
+}
+
+// StableConversion converts the frames with the same palette. The threshold
+// map of the ordered dithering is aligned on the screen with the offset (in
+// pixels) and each pixel keeps the ink of the previous frame while its source
+// color differs less than cfg.StableThreshold on each channel.
+func StableConversion(images []image.Image, cfg *config.MartineConfig, mode uint8, palette color.Palette, offset image.Point) ([][]byte, error) {
+	rawImages := make([][]byte, 0, len(images))
+	var previous, previousDithered *image.NRGBA
+	for i, in := range images {
+		resized := ci.Resize(in, cfg.Size, cfg.ResizingAlgo)
+		if cfg.Reducer > -1 {
+			resized = ci.Reducer(resized, cfg.Reducer)
+		}
+		dithered := ditherFrame(resized, palette, cfg, mode, offset)
+		if previous != nil {
+			var kept int
+			b := dithered.Bounds()
+			for y := b.Min.Y; y < b.Max.Y; y++ {
+				for x := b.Min.X; x < b.Max.X; x++ {
+					if colorChanged(resized.NRGBAAt(x, y), previous.NRGBAAt(x, y), cfg.StableThreshold) {
+						continue
+					}
+					dithered.Set(x, y, previousDithered.NRGBAAt(x, y))
+					kept++
+				}
+			}
+			fmt.Fprintf(os.Stdout, "Image [%d] keeps %d pixels of the previous image\n", i, kept)
+		}
+		raw, _, _, err := gfx.TransformDowngraded(imaging.Clone(dithered), palette, cfg, mode)
+		if err != nil {
+			return rawImages, err
+		}
+		rawImages = append(rawImages, raw)
+		previous = resized
+		previousDithered = dithered
+	}
+	return rawImages, nil
+}
+
+// ditherFrame returns the frame with only the inks of the palette.
+func ditherFrame(in *image.NRGBA, p color.Palette, cfg *config.MartineConfig, mode uint8, offset image.Point) *image.NRGBA {
+	if cfg.DitheringAlgo != -1 {
+		switch cfg.DitheringType {
+		case constants.ErrorDiffusionDither:
+			return filter.PaletteDithering(in, cfg.DitheringMatrix, p, gfx.DiffusionOptions(cfg, mode))
+		case constants.OrderedDither:
+			return filter.PositionalDitheringAt(in, filter.PatternAlgorithm(cfg.DitheringPattern), cfg.DitheringMatrix, p, offset)
+		}
+	}
+	_, out := ci.DowngradingWithPalette(imaging.Clone(in), p)
+	return out
+}
+
+func colorChanged(c1, c2 color.NRGBA, threshold int) bool {
+	return absDiff(c1.R, c2.R) > threshold || absDiff(c1.G, c2.G) > threshold || absDiff(c1.B, c2.B) > threshold
+}
+
+func absDiff(a, b uint8) int {
+	if a > b {
+		return int(a - b)
+	}
+	return int(b - a)
+}
+
+// ScreenOffset returns the position in pixels of the address on the screen.
+func ScreenOffset(x0, y0 int, mode uint8) image.Point {
+	switch mode {
+	case 0:
+		return image.Point{X: x0 * 2, Y: y0}
+	case 1:
+		return image.Point{X: x0 * 4, Y: y0}
+	default:
+		return image.Point{X: x0 * 8, Y: y0}
+	}
+}
+
+// DeltasSize returns the size of the marshalled deltas between the frames
+// (the last frame is compared with the first one).
+func DeltasSize(rawImages [][]byte, isSprite bool, size constants.Size, mode uint8, x0, y0 uint16, g address.Geometry) int {
+	var total int
+	for i := 0; i < len(rawImages); i++ {
+		next := rawImages[(i+1)%len(rawImages)]
+		if len(rawImages[i]) != len(next) {
+			continue
+		}
+		dc := transformation.Delta(rawImages[i], next, isSprite, size, mode, x0, y0, g)
+		b, err := dc.Marshall()
+		if err != nil {
+			continue
+		}
+		total += len(b)
+	}
+	return total
+}
+
+// stableRawImages converts again the frames with the stable dithering and
+// displays the delta size saved compared with the raw images.
+func stableRawImages(frames []image.Image, rawImages [][]byte, cfg *config.MartineConfig, mode uint8, palette color.Palette, isSprite bool, size constants.Size, x0, y0 int, g address.Geometry) ([][]byte, error) {
+	stableImages, err := StableConversion(frames, cfg, mode, palette, ScreenOffset(x0, y0, mode))
+	if err != nil {
+		return rawImages, err
+	}
+	report := StableReport{
+		NaiveSize:  DeltasSize(rawImages, isSprite, size, mode, uint16(x0), uint16(y0), g),
+		StableSize: DeltasSize(stableImages, isSprite, size, mode, uint16(x0), uint16(y0), g),
+	}
+	fmt.Fprintf(os.Stdout, "Stable dithering %s\n", report)
+	return stableImages, nil
+}
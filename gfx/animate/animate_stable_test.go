@@ -0,0 +1,63 @@
+package animate
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/disintegration/imaging"
+	"github.com/jeromelesaux/martine/config"
+	"github.com/jeromelesaux/martine/constants"
+	"github.com/jeromelesaux/martine/convert/address"
+	"github.com/jeromelesaux/martine/gfx/filter"
+)
+
+func stableFrame(shift uint8) image.Image {
+	img := image.NewNRGBA(image.Rect(0, 0, 32, 32))
+	for y := 0; y < 32; y++ {
+		for x := 0; x < 32; x++ {
+			v := uint8(x*6 + y*2)
+			// a small noise which changes between the frames
+			n := uint8((x*7+y*13)%3) + shift
+			img.Set(x, y, color.NRGBA{R: v + n, G: v/2 + n, B: 255 - v, A: 0xff})
+		}
+	}
+	return img
+}
+
+func TestStableConversion(t *testing.T) {
+	cfg := config.NewMartineConfig("", "")
+	cfg.Size = constants.Size{Width: 32, Height: 32, ColorsAvailable: 4}
+	cfg.CustomDimension = true
+	cfg.ResizingAlgo = imaging.NearestNeighbor
+	cfg.Reducer = -1
+	cfg.DitheringAlgo = 0
+	cfg.DitheringType = constants.ErrorDiffusionDither
+	cfg.DitheringMatrix = filter.FloydSteinberg
+	cfg.DitheringMultiplier = 1
+	palette := color.Palette{constants.Black.Color, constants.Blue.Color, constants.BrightRed.Color, constants.BrightWhite.Color}
+	frames := []image.Image{stableFrame(0), stableFrame(2), stableFrame(1)}
+
+	cfg.StableThreshold = -1
+	naive, err := StableConversion(frames, cfg, 1, palette, image.Point{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.StableThreshold = 8
+	stable, err := StableConversion(frames, cfg, 1, palette, image.Point{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	size := constants.Size{Width: 8, Height: 32}
+	report := StableReport{
+		NaiveSize:  DeltasSize(naive, true, size, 1, 0, 0, address.DefaultGeometry),
+		StableSize: DeltasSize(stable, true, size, 1, 0, 0, address.DefaultGeometry),
+	}
+	if report.Saved() <= 0 {
+		t.Fatalf("expected smaller deltas with the stable conversion, %s", report)
+	}
+	if !bytes.Equal(stable[0], stable[1]) {
+		t.Fatalf("expected the same bytes for the frames under the threshold")
+	}
+}
@@ -0,0 +1,230 @@
+package animate
+
+import (
+	"fmt"
+	"image/gif"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jeromelesaux/martine/export/ascii"
+	"github.com/jeromelesaux/martine/gfx/errors"
+)
+
+// DefaultFrameVbl is the duration of a frame without timing, the legacy
+// player waits for the next vbl.
+const DefaultFrameVbl = 1
+
+var cueName = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// FrameTiming is the duration in vbls (1/50s) of a frame of the animation and
+// its optional cue point.
+type FrameTiming struct {
+	Vbl int    `json:"vbl"`
+	Cue string `json:"cue,omitempty"`
+}
+
+// DelayToVbl converts a gif delay (1/100s) in vbls.
+func DelayToVbl(delay int) int {
+	vbl := delay / 2
+	if vbl < 1 {
+		return 1
+	}
+	if vbl > 255 {
+		return 255
+	}
+	return vbl
+}
+
+// DefaultTimings returns the timings of the frames of the legacy player.
+func DefaultTimings(frames int) []FrameTiming {
+	timings := make([]FrameTiming, frames)
+	for i := range timings {
+		timings[i].Vbl = DefaultFrameVbl
+	}
+	return timings
+}
+
+// GifTimings returns the timings of the frames of the gif.
+func GifTimings(g *gif.GIF) []FrameTiming {
+	timings := DefaultTimings(len(g.Image))
+	for i := range timings {
+		if i < len(g.Delay) {
+			timings[i].Vbl = DelayToVbl(g.Delay[i])
+		}
+	}
+	return timings
+}
+
+// ParseCues sets the cue points of the timings from the list frame:name
+// (ex 0:intro,12:drop).
+func ParseCues(timings []FrameTiming, cues string) error {
+	if strings.TrimSpace(cues) == "" {
+		return nil
+	}
+	for _, v := range strings.Split(cues, ",") {
+		values := strings.Split(strings.TrimSpace(v), ":")
+		if len(values) != 2 {
+			return errors.ErrorBadCue
+		}
+		frame, err := strconv.Atoi(values[0])
+		if err != nil || frame < 0 || frame >= len(timings) || !cueName.MatchString(values[1]) {
+			return errors.ErrorBadCue
+		}
+		timings[frame].Cue = values[1]
+	}
+	return nil
+}
+
+// timingCode returns the routines and the data of the timings of the frames,
+// frame_timing is called by the player before each delta.
+func timingCode(timings []FrameTiming) (string, error) {
+	if len(timings) == 0 || len(timings) > 255 {
+		return "", errors.ErrorBadTiming
+	}
+	vbls := make([]byte, len(timings))
+	cueIndex := make([]byte, len(timings))
+	cueFrames := make([]byte, 0)
+	names := make(map[string]bool)
+	code := "\n"
+	for i, t := range timings {
+		if t.Vbl < 1 || t.Vbl > 255 {
+			return "", errors.ErrorBadTiming
+		}
+		vbls[i] = byte(t.Vbl)
+		if t.Cue != "" {
+			if names[t.Cue] {
+				return "", errors.ErrorBadCue
+			}
+			names[t.Cue] = true
+			cueFrames = append(cueFrames, byte(i))
+			cueIndex[i] = byte(len(cueFrames))
+			code += fmt.Sprintf("cue_%s equ %d\n", t.Cue, len(cueFrames))
+		}
+	}
+	code += fmt.Sprintf("nbframes equ %d\n", len(timings))
+	code += timingRoutine
+	code += "timing_vbl:\n"
+	code += ascii.FormatAssemblyDatabyte(vbls, "\n")
+	code += "timing_cue:\n"
+	code += ascii.FormatAssemblyDatabyte(cueIndex, "\n")
+	code += "cue_frames:\n"
+	if len(cueFrames) != 0 {
+		code += ascii.FormatAssemblyDatabyte(cueFrames, "\n")
+	}
+	code += "current_frame db 0\nseek_frame db #FF\nsegment_first db 0\nsegment_last db #FF\n"
+	return code, nil
+}
+
+var timingRoutine = `
+;--- gestion du temps des frames ----------------------
+; attend la duree de la frame affichee, les frames sont
+; enchainees sans attente jusqu'a seek_frame (les deltas
+; sont sequentiels)
+frame_timing
+	ld a,(seek_frame)
+	cp #FF
+	jr z,timing_play
+	ld hl,current_frame
+	cp (hl)
+	jr nz,timing_next ; avance rapide
+	ld a,#FF
+	ld (seek_frame),a
+timing_play
+	ld a,(current_frame)
+	ld c,a
+	ld b,0
+	ld hl,timing_cue
+	add hl,bc
+	ld a,(hl)
+	or a
+	call nz,cue_hook
+	ld a,(current_frame)
+	ld hl,segment_last
+	cp (hl)
+	jr nz,timing_wait
+	ld a,(segment_first)
+	ld (seek_frame),a
+timing_wait
+	ld a,(current_frame)
+	ld c,a
+	ld b,0
+	ld hl,timing_vbl
+	add hl,bc
+	ld e,(hl)
+	call wait_vbls
+timing_next
+	ld a,(current_frame)
+	inc a
+	cp nbframes
+	jr c,timing_store
+	xor a
+timing_store
+	ld (current_frame),a
+	ret
+
+;--- attente de e vbl ---------------------------------
+wait_vbls
+	ld b,#F5
+wait_vbls_end
+	in a,(c)
+	rra
+	jr c,wait_vbls_end
+wait_vbls_start
+	in a,(c)
+	rra
+	jr nc,wait_vbls_start
+	dec e
+	jr nz,wait_vbls_end
+	ret
+
+;--- hooks du player ----------------------------------
+; cue_hook est appele avec a = numero du cue (cue_nom),
+; remplacer le ret par un jp vers la routine (ex synchro
+; d'une musique Arkos Tracker)
+cue_hook
+	ret
+	nop
+	nop
+
+; play_segment : d = premiere frame, e = derniere frame
+; du segment joue en boucle
+play_segment
+	ld a,d
+	ld (segment_first),a
+	ld (seek_frame),a
+	ld a,e
+	ld (segment_last),a
+	ret
+
+; stop_segment : reprend la lecture de toutes les frames
+stop_segment
+	ld a,#FF
+	ld (segment_last),a
+	ret
+
+; jump_cue : a = numero du cue, la lecture reprend a sa
+; frame
+jump_cue
+	ld l,a
+	ld h,0
+	ld de,cue_frames-1
+	add hl,de
+	ld a,(hl)
+	ld (seek_frame),a
+	ret
+;------------------------------------------------------
+`
+
+// withTiming replaces the vbl wait of the legacy player by the timings of
+// the frames.
+func withTiming(sourceCode string, timings []FrameTiming) (string, string, error) {
+	if timings == nil {
+		return sourceCode, "", nil
+	}
+	code, err := timingCode(timings)
+	if err != nil {
+		return sourceCode, "", err
+	}
+	return strings.Replace(sourceCode, "call xvbl\ncall next_delta", "call frame_timing\ncall next_delta", 1), code, nil
+}
@@ -0,0 +1,33 @@
+package animate
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFrameTimings(t *testing.T) {
+	if v := DelayToVbl(10); v != 5 {
+		t.Fatalf("expected 5 vbls for 10/100s and gets %d", v)
+	}
+	if v := DelayToVbl(0); v != 1 {
+		t.Fatalf("expected at least one vbl and gets %d", v)
+	}
+	timings := []FrameTiming{{Vbl: 5}, {Vbl: 7}, {Vbl: 6}}
+	if err := ParseCues(timings, "0:intro,2:drop"); err != nil {
+		t.Fatal(err)
+	}
+	if err := ParseCues(timings, "3:out"); err == nil {
+		t.Fatalf("expected an error for the frame 3")
+	}
+	code, err := timingCode(timings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(code, "cue_drop equ 2") || !strings.Contains(code, "nbframes equ 3") {
+		t.Fatalf("unexpected timing code %s", code)
+	}
+	timings[1].Cue = "intro"
+	if _, err := timingCode(timings); err == nil {
+		t.Fatalf("expected an error for the duplicated cue")
+	}
+}
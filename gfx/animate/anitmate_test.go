@@ -8,10 +8,12 @@ import (
 	"image/png"
 	"io"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/jeromelesaux/martine/config"
 	"github.com/jeromelesaux/martine/constants"
+	"github.com/jeromelesaux/martine/convert/address"
 	"github.com/jeromelesaux/martine/export/amsdos"
 	"github.com/jeromelesaux/zx0/encode"
 )
@@ -29,7 +31,9 @@ func TestAnimate(t *testing.T) {
 }
 
 func TestDeltaMotif(t *testing.T) {
-	err := DeltaMotif("../../samples/coke.gif", &config.MartineConfig{InputPath: "triangles.gif", OutputPath: "."}, 20, 0xc000, 1)
+	cfg := config.NewMartineConfig("../../samples/coke.gif", t.TempDir())
+	cfg.Size = constants.Mode1
+	err := DeltaMotif("../../samples/coke.gif", cfg, 20, 0xc000, 1)
 	if err != nil {
 		t.Fatalf("%v", err)
 	}
@@ -52,6 +56,28 @@ func TestCompressZx0(t *testing.T) {
 	}
 }
 
+func TestWithGeometry(t *testing.T) {
+	for _, template := range []string{deltaScreenCodeDelta, deltaScreenCodeDeltaV2, deltaScreenCompressCodeDelta, deltaScreenCompressCodeDeltaV2,
+		deltaScreenCodeDeltaPlus, deltaScreenCompressCodeDeltaPlus, deltaCodeDelta, playbackRoutines} {
+		for name, g := range address.Geometries {
+			code, err := withGeometry(template, g)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(code, "bc26\n\tld a,h\n\tadd a,#08\n") || strings.Contains(code, "ld bc,linewidth") {
+				t.Fatalf("the routine bc26 of the %s geometry is missing %s", name, code)
+			}
+		}
+	}
+	code, err := withGeometry(playbackRoutines, address.Geometries["r9-3"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(code, "\tand #18\n\tret nz\n") {
+		t.Fatalf("expected the characters of 4 lines %s", code)
+	}
+}
+
 func TestDisplayCode(t *testing.T) {
 	fmt.Printf("%s", depackRoutine)
 }
@@ -20,6 +20,8 @@ import (
 	"github.com/jeromelesaux/martine/gfx/transformation"
 )
 
+// DoDithering dithers the image, the error diffusion is made against the inks
+// of the palette with the diffusion options, or by dithergo if they are nil.
 func DoDithering(in *image.NRGBA,
 	p color.Palette,
 	ditheringAlgo int,
@@ -27,29 +29,55 @@ func DoDithering(in *image.NRGBA,
 	ditheringWithQuantification bool,
 	ditheringMatrix [][]float32,
 	ditheringMultiplier float32,
+	ditheringPattern filter.PatternAlgorithm,
 	isCpcPlus bool,
 	size constants.Size,
+	diffusion *filter.DiffusionOptions,
 ) (*image.NRGBA, color.Palette) {
 	if ditheringAlgo != -1 {
 		switch ditheringType {
 		case constants.ErrorDiffusionDither:
-			if ditheringWithQuantification {
+			switch {
+			case ditheringWithQuantification:
 				in = filter.QuantizeWithDither(in, ditheringMatrix, size.ColorsAvailable, p)
-			} else {
+			case diffusion != nil:
+				opts := *diffusion
+				opts.Multiplier = ditheringMultiplier
+				in = filter.PaletteDithering(in, ditheringMatrix, p, opts)
+			default:
 				in = filter.Dithering(in, ditheringMatrix, ditheringMultiplier)
 			}
 		case constants.OrderedDither:
-			if isCpcPlus {
-				p = ci.ExtractPalette(in, isCpcPlus, 27)
-				in = filter.BayerDiphering(in, ditheringMatrix, p)
-			} else {
-				in = filter.BayerDiphering(in, ditheringMatrix, constants.CpcOldPalette)
-			}
+			in = filter.PositionalDithering(in, ditheringPattern, ditheringMatrix, p)
 		}
 	}
 	return in, p
 }
 
+// DiffusionOptions returns the error diffusion options of the configuration
+// for the screen mode.
+func DiffusionOptions(cfg *config.MartineConfig, mode uint8) filter.DiffusionOptions {
+	opts := filter.NewDiffusionOptions()
+	opts.Multiplier = float32(cfg.DitheringMultiplier)
+	opts.Serpentine = cfg.DitheringSerpentine
+	opts.Clamp = float32(cfg.DitheringErrorClamp)
+	if cfg.DitheringWeights != [3]float64{} {
+		opts.Weights = [3]float32{float32(cfg.DitheringWeights[0]), float32(cfg.DitheringWeights[1]), float32(cfg.DitheringWeights[2])}
+	}
+	opts.PixelRatio = filter.PixelRatio(mode)
+	return opts
+}
+
+// PaletteAwareDiffusion returns the error diffusion options of the
+// configuration if the error is diffused against the inks, nil otherwise.
+func PaletteAwareDiffusion(cfg *config.MartineConfig, mode uint8) *filter.DiffusionOptions {
+	if !cfg.DitheringPaletteAware {
+		return nil
+	}
+	opts := DiffusionOptions(cfg, mode)
+	return &opts
+}
+
 func DoTransformation(in *image.NRGBA,
 	p color.Palette,
 	screenMode uint8,
@@ -69,6 +97,7 @@ func DoTransformation(in *image.NRGBA,
 	rotation3DX0,
 	rotation3DY0,
 	rotation3DType int,
+	projection transformation.Projection,
 	resizingAlgo imaging.ResampleFilter,
 	size constants.Size,
 ) ([]*image.NRGBA, error) {
@@ -97,7 +126,7 @@ func DoTransformation(in *image.NRGBA,
 		}
 	}
 	if rotation3DMode {
-		if images, err = transformation.Rotate3d(in, p, size, screenMode, resizingAlgo, rollIterations, rotation3DX0, rotation3DY0, rotation3DType); err != nil {
+		if images, err = transformation.Rotate3d(in, p, size, screenMode, resizingAlgo, rollIterations, rotation3DX0, rotation3DY0, rotation3DType, projection); err != nil {
 			fmt.Fprintf(os.Stderr, "Error while perform rotation on image error :%v\n", err)
 		}
 	}
@@ -105,6 +134,28 @@ func DoTransformation(in *image.NRGBA,
 	return images, err
 }
 
+// Rotation3DProjection returns the projection of the 3d rotation set in the
+// configuration.
+func Rotation3DProjection(cfg *config.MartineConfig) (transformation.Projection, error) {
+	projection := transformation.DefaultProjection
+	var err error
+	if cfg.Rotation3DAxis != "" {
+		if projection.Axis, err = transformation.ParseAxis(cfg.Rotation3DAxis); err != nil {
+			return projection, err
+		}
+	}
+	if cfg.Rotation3DDistance > 0 {
+		projection.Distance = cfg.Rotation3DDistance
+	}
+	projection.Bilinear = cfg.Rotation3DBilinear
+	if cfg.Rotation3DKeys != "" {
+		if projection.Keys, err = transformation.ParseAngleKeys(cfg.Rotation3DKeys); err != nil {
+			return projection, err
+		}
+	}
+	return projection, nil
+}
+
 func ApplyOneImageAndExport(in image.Image,
 	cfg *config.MartineConfig,
 	filename, picturePath string,
@@ -157,6 +208,15 @@ func ApplyOneImageAndExport(in image.Image,
 		}
 	}
 
+	var transparent [][]bool
+	if cfg.Transparency {
+		transparent = ci.TransparentMask(out, cfg.TransparentKey)
+		ci.FillTransparent(out, transparent)
+	}
+	reserved := len(palette) > 0
+
+	// the downgrading changes the colors of out
+	resized := imaging.Clone(out)
 	if len(palette) > 0 {
 		newPalette, downgraded = ci.DowngradingWithPalette(out, palette)
 	} else {
@@ -177,7 +237,13 @@ func ApplyOneImageAndExport(in image.Image,
 	paletteToSort = fillColorPalette(paletteToSort)
 	newPalette = constants.SortColorsByDistance(paletteToSort)
 
-	out, _ = DoDithering(out, newPalette, cfg.DitheringAlgo, cfg.DitheringType, cfg.DitheringWithQuantification, cfg.DitheringMatrix, float32(cfg.DitheringMultiplier), cfg.CpcPlus, cfg.Size)
+	if cfg.DitheringAlgo != -1 && (cfg.DitheringType == constants.OrderedDither || cfg.DitheringPaletteAware) {
+		// the dithering starts from the resized image and uses the selected inks
+		out, _ = DoDithering(resized, newPalette, cfg.DitheringAlgo, cfg.DitheringType, cfg.DitheringWithQuantification, cfg.DitheringMatrix, float32(cfg.DitheringMultiplier), filter.PatternAlgorithm(cfg.DitheringPattern), cfg.CpcPlus, cfg.Size, PaletteAwareDiffusion(cfg, screenMode))
+		_, downgraded = ci.DowngradingWithPalette(out, newPalette)
+	} else {
+		out, _ = DoDithering(out, newPalette, cfg.DitheringAlgo, cfg.DitheringType, cfg.DitheringWithQuantification, cfg.DitheringMatrix, float32(cfg.DitheringMultiplier), filter.PatternAlgorithm(cfg.DitheringPattern), cfg.CpcPlus, cfg.Size, nil)
+	}
 	if cfg.Saturation > 0 || cfg.Brightness > 0 {
 		palette = ci.EnhanceBrightness(newPalette, cfg.Brightness, cfg.Saturation)
 		newPalette, downgraded = ci.DowngradingWithPalette(out, palette)
@@ -202,17 +268,25 @@ func ApplyOneImageAndExport(in image.Image,
 		newPalette = constants.SortColorsByDistance(paletteToSort)
 	}
 
+	if cfg.Transparency {
+		newPalette, downgraded = ci.ApplyTransparency(downgraded, transparent, newPalette, transparencyColors(cfg, mode), reserved, cfg.TransparentKey, cfg.CpcPlus)
+	}
+
 	fmt.Fprintf(os.Stdout, "Saving downgraded image into (%s)\n", filename+"_down.png")
 	if err := png.Png(filepath.Join(cfg.OutputPath, filename+"_down.png"), downgraded); err != nil {
 		os.Exit(-2)
 	}
 
+	projection, err := Rotation3DProjection(cfg)
+	if err != nil {
+		return err
+	}
 	images, err := DoTransformation(downgraded, newPalette,
 		screenMode, cfg.RollMode, cfg.RotationMode, cfg.Rotation3DMode,
 		cfg.RotationRlaBit, cfg.RotationSlaBit, cfg.RotationRraBit, cfg.RotationSraBit,
 		cfg.RotationKeephighBit, cfg.RotationLosthighBit,
 		cfg.RotationKeeplowBit, cfg.RotationLostlowBit, cfg.RotationIterations,
-		cfg.RollIteration, cfg.Rotation3DX0, cfg.Rotation3DY0, cfg.Rotation3DType, cfg.ResizingAlgo, cfg.Size)
+		cfg.RollIteration, cfg.Rotation3DX0, cfg.Rotation3DY0, cfg.Rotation3DType, projection, cfg.ResizingAlgo, cfg.Size)
 	if err != nil {
 		os.Exit(-2)
 	} else {
@@ -234,6 +308,14 @@ func ApplyOneImageAndExport(in image.Image,
 			return err
 		}
 	} else {
+		if cfg.PreShift && !cfg.SpriteHard {
+			// the zigzag is applied on each shift variant
+			bank := cfg.PreShiftBank
+			if bank < 1 {
+				bank = 1
+			}
+			return sprite.PreShiftAndExport(downgraded, newPalette, screenMode, bank, cfg.PreShiftMask, filename, cfg)
+		}
 		if cfg.ZigZag {
 			// prepare zigzag transformation
 			downgraded = transformation.Zigzag(downgraded)
@@ -271,6 +353,15 @@ func ApplyOneImage(in image.Image,
 		out = ci.Reducer(out, cfg.Reducer)
 	}
 
+	var transparent [][]bool
+	if cfg.Transparency {
+		transparent = ci.TransparentMask(out, cfg.TransparentKey)
+		ci.FillTransparent(out, transparent)
+	}
+	reserved := len(palette) > 0
+
+	// the downgrading changes the colors of out
+	resized := imaging.Clone(out)
 	if len(palette) > 0 {
 		newPalette, downgraded = ci.DowngradingWithPalette(out, palette)
 	} else {
@@ -299,7 +390,13 @@ func ApplyOneImage(in image.Image,
 	}
 	paletteToSort = fillColorPalette(paletteToSort)
 	newPalette = constants.SortColorsByDistance(paletteToSort)
-	out, _ = DoDithering(out, newPalette, cfg.DitheringAlgo, cfg.DitheringType, cfg.DitheringWithQuantification, cfg.DitheringMatrix, float32(cfg.DitheringMultiplier), cfg.CpcPlus, cfg.Size)
+	if cfg.DitheringAlgo != -1 && (cfg.DitheringType == constants.OrderedDither || cfg.DitheringPaletteAware) {
+		// the dithering starts from the resized image and uses the selected inks
+		out, _ = DoDithering(resized, newPalette, cfg.DitheringAlgo, cfg.DitheringType, cfg.DitheringWithQuantification, cfg.DitheringMatrix, float32(cfg.DitheringMultiplier), filter.PatternAlgorithm(cfg.DitheringPattern), cfg.CpcPlus, cfg.Size, PaletteAwareDiffusion(cfg, screenMode))
+		_, downgraded = ci.DowngradingWithPalette(out, newPalette)
+	} else {
+		out, _ = DoDithering(out, newPalette, cfg.DitheringAlgo, cfg.DitheringType, cfg.DitheringWithQuantification, cfg.DitheringMatrix, float32(cfg.DitheringMultiplier), filter.PatternAlgorithm(cfg.DitheringPattern), cfg.CpcPlus, cfg.Size, nil)
+	}
 
 	if cfg.Saturation > 0 || cfg.Brightness > 0 {
 		palette = ci.EnhanceBrightness(newPalette, cfg.Brightness, cfg.Saturation)
@@ -316,6 +413,36 @@ func ApplyOneImage(in image.Image,
 		paletteToSort = fillColorPalette(paletteToSort)
 		newPalette = constants.SortColorsByDistance(paletteToSort)
 	}
+	if cfg.Transparency {
+		newPalette, downgraded = ci.ApplyTransparency(downgraded, transparent, newPalette, transparencyColors(cfg, mode), reserved, cfg.TransparentKey, cfg.CpcPlus)
+	}
+	data, downgraded, lineSize, err := TransformDowngraded(downgraded, newPalette, cfg, screenMode)
+	return data, downgraded, newPalette, lineSize, err
+}
+
+// transparencyColors returns the number of inks of the mode, the ink 0 is
+// the transparent ink.
+func transparencyColors(cfg *config.MartineConfig, mode int) int {
+	if cfg.SpriteHard {
+		return 16
+	}
+	switch mode {
+	case 1:
+		return 4
+	case 2:
+		return 2
+	}
+	return 16
+}
+
+// TransformDowngraded returns the screen, sprite or hard sprite bytes of the
+// image already downgraded with the palette.
+func TransformDowngraded(downgraded *image.NRGBA,
+	newPalette color.Palette,
+	cfg *config.MartineConfig,
+	screenMode uint8,
+) ([]byte, *image.NRGBA, int, error) {
+	var err error
 	var data []byte
 	var lineSize int
 	if !cfg.CustomDimension && !cfg.SpriteHard {
@@ -349,7 +476,7 @@ func ApplyOneImage(in image.Image,
 			}
 		}
 	}
-	return data, downgraded, newPalette, lineSize, err
+	return data, downgraded, lineSize, err
 }
 
 func fillColorPalette(p color.Palette) color.Palette {
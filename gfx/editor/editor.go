@@ -0,0 +1,446 @@
+// Package editor touches up a converted image at the CPC pixel level. The
+// image is kept as ink indices (not colours) so the result is exported
+// without quantising it again.
+package editor
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/jeromelesaux/martine/config"
+	"github.com/jeromelesaux/martine/constants"
+	"github.com/jeromelesaux/martine/convert/address"
+	"github.com/jeromelesaux/martine/convert/export"
+	"github.com/jeromelesaux/martine/convert/palette"
+	"github.com/jeromelesaux/martine/convert/pixel"
+	"github.com/jeromelesaux/martine/convert/sprite"
+)
+
+var (
+	ErrorOutOfImage  = errors.New("position out of the image")
+	ErrorInkNotFound = errors.New("ink not available in the palette")
+)
+
+type Tool int
+
+var (
+	PenTool       Tool = 0
+	FillTool      Tool = 1
+	LineTool      Tool = 2
+	RectangleTool Tool = 3
+	PickerTool    Tool = 4
+)
+
+// change is the previous and new ink of a pixel.
+type change struct {
+	index int
+	old   int
+	new   int
+}
+
+// Editor holds the ink indices of an image in CPC pixels (a pixel of a
+// mode 0 image is a wide pixel).
+type Editor struct {
+	Mode    uint8
+	Width   int
+	Height  int
+	Palette color.Palette
+	Inks    []int
+	undo    [][]change
+	redo    [][]change
+	current []change
+}
+
+// NewEditor gets the ink indices of the downgraded image (one image pixel
+// for one CPC pixel), the colours not found in the palette use the ink 0.
+func NewEditor(in *image.NRGBA, p color.Palette, mode uint8) *Editor {
+	b := in.Bounds()
+	e := &Editor{
+		Mode:    mode,
+		Width:   b.Dx(),
+		Height:  b.Dy(),
+		Palette: p,
+		Inks:    make([]int, b.Dx()*b.Dy()),
+		undo:    make([][]change, 0),
+		redo:    make([][]change, 0),
+	}
+	for y := 0; y < e.Height; y++ {
+		for x := 0; x < e.Width; x++ {
+			ink, err := palette.PalettePosition(in.At(b.Min.X+x, b.Min.Y+y), p)
+			if err != nil {
+				ink = 0
+			}
+			e.Inks[y*e.Width+x] = ink
+		}
+	}
+	return e
+}
+
+// Inside returns true if the position is in the image.
+func (e *Editor) Inside(x, y int) bool {
+	return x >= 0 && y >= 0 && x < e.Width && y < e.Height
+}
+
+// Ink returns the ink of the pixel.
+func (e *Editor) Ink(x, y int) (int, error) {
+	if !e.Inside(x, y) {
+		return 0, ErrorOutOfImage
+	}
+	return e.Inks[y*e.Width+x], nil
+}
+
+// Pick returns the ink under the pixel (palette ink picking).
+func (e *Editor) Pick(x, y int) (int, error) {
+	return e.Ink(x, y)
+}
+
+func (e *Editor) checkInk(ink int) error {
+	if ink < 0 || ink >= len(e.Palette) {
+		return fmt.Errorf("%w : ink %d, palette size %d", ErrorInkNotFound, ink, len(e.Palette))
+	}
+	return nil
+}
+
+func (e *Editor) set(x, y, ink int) {
+	if !e.Inside(x, y) {
+		return
+	}
+	index := y*e.Width + x
+	if e.Inks[index] == ink {
+		return
+	}
+	e.current = append(e.current, change{index: index, old: e.Inks[index], new: ink})
+	e.Inks[index] = ink
+}
+
+// commit saves the changes of the last action in the undo history.
+func (e *Editor) commit() {
+	if len(e.current) == 0 {
+		return
+	}
+	e.undo = append(e.undo, e.current)
+	e.redo = e.redo[:0]
+	e.current = nil
+}
+
+// Pen sets the ink of one pixel.
+func (e *Editor) Pen(x, y, ink int) error {
+	if err := e.checkInk(ink); err != nil {
+		return err
+	}
+	if !e.Inside(x, y) {
+		return ErrorOutOfImage
+	}
+	e.set(x, y, ink)
+	e.commit()
+	return nil
+}
+
+// Stroke sets the ink of one pixel of a pen stroke, the stroke is saved as
+// one action in the history by EndStroke.
+func (e *Editor) Stroke(x, y, ink int) error {
+	if err := e.checkInk(ink); err != nil {
+		return err
+	}
+	if !e.Inside(x, y) {
+		return ErrorOutOfImage
+	}
+	e.set(x, y, ink)
+	return nil
+}
+
+func (e *Editor) EndStroke() {
+	e.commit()
+}
+
+// Line draws a line with the Bresenham algorithm.
+func (e *Editor) Line(x0, y0, x1, y1, ink int) error {
+	if err := e.checkInk(ink); err != nil {
+		return err
+	}
+	dx := int(math.Abs(float64(x1 - x0)))
+	dy := -int(math.Abs(float64(y1 - y0)))
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+	err := dx + dy
+	for {
+		e.set(x0, y0, ink)
+		if x0 == x1 && y0 == y1 {
+			break
+		}
+		e2 := 2 * err
+		if e2 >= dy {
+			err += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			err += dx
+			y0 += sy
+		}
+	}
+	e.commit()
+	return nil
+}
+
+// Rectangle draws the outline of the rectangle or fills it.
+func (e *Editor) Rectangle(x0, y0, x1, y1, ink int, filled bool) error {
+	if err := e.checkInk(ink); err != nil {
+		return err
+	}
+	if x0 > x1 {
+		x0, x1 = x1, x0
+	}
+	if y0 > y1 {
+		y0, y1 = y1, y0
+	}
+	for y := y0; y <= y1; y++ {
+		for x := x0; x <= x1; x++ {
+			if filled || y == y0 || y == y1 || x == x0 || x == x1 {
+				e.set(x, y, ink)
+			}
+		}
+	}
+	e.commit()
+	return nil
+}
+
+// Fill replaces the ink of the area (4-connected) containing the pixel.
+func (e *Editor) Fill(x, y, ink int) error {
+	if err := e.checkInk(ink); err != nil {
+		return err
+	}
+	target, err := e.Ink(x, y)
+	if err != nil {
+		return err
+	}
+	if target == ink {
+		return nil
+	}
+	stack := []image.Point{{X: x, Y: y}}
+	for len(stack) > 0 {
+		p := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if v, err := e.Ink(p.X, p.Y); err != nil || v != target {
+			continue
+		}
+		e.set(p.X, p.Y, ink)
+		stack = append(stack,
+			image.Point{X: p.X + 1, Y: p.Y},
+			image.Point{X: p.X - 1, Y: p.Y},
+			image.Point{X: p.X, Y: p.Y + 1},
+			image.Point{X: p.X, Y: p.Y - 1},
+		)
+	}
+	e.commit()
+	return nil
+}
+
+// Apply uses the tool from (x0,y0) to (x1,y1), the pen, the fill and the
+// picker only use the first position. The picker returns the ink picked.
+func (e *Editor) Apply(t Tool, x0, y0, x1, y1, ink int) (int, error) {
+	switch t {
+	case FillTool:
+		return ink, e.Fill(x0, y0, ink)
+	case LineTool:
+		return ink, e.Line(x0, y0, x1, y1, ink)
+	case RectangleTool:
+		return ink, e.Rectangle(x0, y0, x1, y1, ink, false)
+	case PickerTool:
+		return e.Pick(x0, y0)
+	}
+	return ink, e.Pen(x0, y0, ink)
+}
+
+func (e *Editor) CanUndo() bool {
+	return len(e.undo) > 0
+}
+
+func (e *Editor) CanRedo() bool {
+	return len(e.redo) > 0
+}
+
+// Undo cancels the last action.
+func (e *Editor) Undo() bool {
+	if !e.CanUndo() {
+		return false
+	}
+	changes := e.undo[len(e.undo)-1]
+	e.undo = e.undo[:len(e.undo)-1]
+	for i := len(changes) - 1; i >= 0; i-- {
+		e.Inks[changes[i].index] = changes[i].old
+	}
+	e.redo = append(e.redo, changes)
+	return true
+}
+
+// Redo applies again the last action cancelled.
+func (e *Editor) Redo() bool {
+	if !e.CanRedo() {
+		return false
+	}
+	changes := e.redo[len(e.redo)-1]
+	e.redo = e.redo[:len(e.redo)-1]
+	for _, v := range changes {
+		e.Inks[v.index] = v.new
+	}
+	e.undo = append(e.undo, changes)
+	return true
+}
+
+func (e *Editor) color(ink int) color.Color {
+	if ink < len(e.Palette) && e.Palette[ink] != nil {
+		return e.Palette[ink]
+	}
+	return color.Black
+}
+
+// Image returns the image in CPC pixels.
+func (e *Editor) Image() *image.NRGBA {
+	out := image.NewNRGBA(image.Rect(0, 0, e.Width, e.Height))
+	for y := 0; y < e.Height; y++ {
+		for x := 0; x < e.Width; x++ {
+			out.Set(x, y, e.color(e.Inks[y*e.Width+x]))
+		}
+	}
+	return out
+}
+
+// PixelSize returns the displayed size of a CPC pixel (mode 0 pixels are
+// twice as wide as mode 1 pixels and four times as wide as mode 2 pixels).
+func (e *Editor) PixelSize(zoom int) (int, int) {
+	if zoom < 1 {
+		zoom = 1
+	}
+	switch e.Mode {
+	case 0:
+		return 4 * zoom, 2 * zoom
+	case 1:
+		return 2 * zoom, 2 * zoom
+	}
+	return zoom, 2 * zoom
+}
+
+// Render returns the zoomed image with the pixel aspect of the mode and the
+// grid between the CPC pixels.
+func (e *Editor) Render(zoom int, grid bool) *image.NRGBA {
+	pw, ph := e.PixelSize(zoom)
+	out := image.NewNRGBA(image.Rect(0, 0, e.Width*pw, e.Height*ph))
+	gridColor := color.NRGBA{R: 0x80, G: 0x80, B: 0x80, A: 0xFF}
+	colors := make([]color.NRGBA, len(e.Palette))
+	for i := range colors {
+		colors[i] = color.NRGBAModel.Convert(e.color(i)).(color.NRGBA)
+	}
+	for y := 0; y < e.Height; y++ {
+		for x := 0; x < e.Width; x++ {
+			c := color.NRGBA{A: 0xFF}
+			if ink := e.Inks[y*e.Width+x]; ink < len(colors) {
+				c = colors[ink]
+			}
+			for j := 0; j < ph; j++ {
+				for i := 0; i < pw; i++ {
+					if grid && pw > 2 && (i == 0 || j == 0) {
+						out.SetNRGBA(x*pw+i, y*ph+j, gridColor)
+						continue
+					}
+					out.SetNRGBA(x*pw+i, y*ph+j, c)
+				}
+			}
+		}
+	}
+	return out
+}
+
+// Position returns the CPC pixel under the position (px,py) of the image
+// rendered with the zoom.
+func (e *Editor) Position(px, py, zoom int) (int, int) {
+	pw, ph := e.PixelSize(zoom)
+	return px / pw, py / ph
+}
+
+func (e *Editor) pixels(x, y int) byte {
+	ink := func(i int) int {
+		v, err := e.Ink(x+i, y)
+		if err != nil {
+			return 0
+		}
+		return v
+	}
+	switch e.Mode {
+	case 0:
+		return pixel.PixelMode0(ink(0), ink(1))
+	case 1:
+		return pixel.PixelMode1(ink(0), ink(1), ink(2), ink(3))
+	}
+	return pixel.PixelMode2(ink(0), ink(1), ink(2), ink(3), ink(4), ink(5), ink(6), ink(7))
+}
+
+func (e *Editor) pixelsPerByte() int {
+	switch e.Mode {
+	case 0:
+		return 2
+	case 1:
+		return 4
+	}
+	return 8
+}
+
+// Screen returns the screen memory (#4000 bytes or #8000 bytes in overscan).
+func (e *Editor) Screen(overscan bool) []byte {
+	bw := make([]byte, 0x4000)
+	if overscan {
+		bw = make([]byte, 0x8000)
+	}
+	for y := 0; y < e.Height; y++ {
+		for x := 0; x < e.Width; x += e.pixelsPerByte() {
+			addr := address.CpcScreenAddress(0, x, y, e.Mode, overscan, false)
+			if addr < len(bw) {
+				bw[addr] = e.pixels(x, y)
+			}
+		}
+	}
+	return bw
+}
+
+// Sprite returns the sprite data (line after line) and the size of a line in
+// bytes.
+func (e *Editor) Sprite() ([]byte, int) {
+	lineSize := int(math.Ceil(float64(e.Width) / float64(e.pixelsPerByte())))
+	data := make([]byte, 0, lineSize*e.Height)
+	for y := 0; y < e.Height; y++ {
+		for x := 0; x < e.Width; x += e.pixelsPerByte() {
+			data = append(data, e.pixels(x, y))
+		}
+	}
+	return data, lineSize
+}
+
+// SpriteHard returns the cpc plus hard sprite data (one ink by byte).
+func (e *Editor) SpriteHard() []byte {
+	data := make([]byte, len(e.Inks))
+	for i, v := range e.Inks {
+		data[i] = byte(v)
+	}
+	return data
+}
+
+// Export saves the screen (or the sprite if the configuration has a custom
+// dimension) with the palette without converting the colours again.
+func (e *Editor) Export(filePath string, cfg *config.MartineConfig) error {
+	if !cfg.CustomDimension && !cfg.SpriteHard {
+		return export.Export(filePath, e.Screen(cfg.Overscan), e.Palette, e.Mode, cfg)
+	}
+	size := constants.Size{Width: e.Width, Height: e.Height}
+	if cfg.SpriteHard {
+		return sprite.ExportSprite(e.SpriteHard(), 16, e.Palette, size, e.Mode, filePath, false, cfg)
+	}
+	data, lineSize := e.Sprite()
+	return sprite.ExportSprite(data, lineSize, e.Palette, size, e.Mode, filePath, false, cfg)
+}
@@ -0,0 +1,105 @@
+package editor
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/jeromelesaux/martine/config"
+	"github.com/jeromelesaux/martine/constants"
+	"github.com/jeromelesaux/martine/convert/screen"
+)
+
+var testPalette = color.Palette{
+	constants.Black.Color,
+	constants.BrightWhite.Color,
+	constants.BrightRed.Color,
+	constants.Blue.Color,
+}
+
+func newTestEditor() *Editor {
+	in := image.NewNRGBA(image.Rect(0, 0, 8, 8))
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			in.Set(x, y, testPalette[0])
+		}
+	}
+	return NewEditor(in, testPalette, 1)
+}
+
+func TestUndoRedo(t *testing.T) {
+	e := newTestEditor()
+	if err := e.Pen(1, 1, 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Line(0, 0, 7, 7, 1); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := e.Ink(1, 1); v != 1 {
+		t.Fatalf("expected ink 1 and gets %d", v)
+	}
+	e.Undo()
+	if v, _ := e.Ink(1, 1); v != 2 {
+		t.Fatalf("expected ink 2 after undo and gets %d", v)
+	}
+	e.Undo()
+	if v, _ := e.Ink(1, 1); v != 0 {
+		t.Fatalf("expected ink 0 after undo and gets %d", v)
+	}
+	if e.Undo() {
+		t.Fatalf("expected nothing to undo")
+	}
+	e.Redo()
+	e.Redo()
+	if v, _ := e.Ink(7, 7); v != 1 {
+		t.Fatalf("expected ink 1 after redo and gets %d", v)
+	}
+	if err := e.Pen(0, 7, 3); err != nil {
+		t.Fatal(err)
+	}
+	if e.CanRedo() {
+		t.Fatalf("expected redo history cleared after a new action")
+	}
+}
+
+func TestFill(t *testing.T) {
+	e := newTestEditor()
+	if err := e.Rectangle(2, 2, 5, 5, 1, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Fill(3, 3, 2); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := e.Ink(4, 4); v != 2 {
+		t.Fatalf("expected ink 2 inside the rectangle and gets %d", v)
+	}
+	if v, _ := e.Ink(0, 0); v != 0 {
+		t.Fatalf("expected ink 0 outside the rectangle and gets %d", v)
+	}
+	if v, _ := e.Pick(2, 2); v != 1 {
+		t.Fatalf("expected ink 1 on the outline and gets %d", v)
+	}
+	if err := e.Pen(0, 0, 4); err == nil {
+		t.Fatalf("expected an error for an ink out of the palette")
+	}
+}
+
+func TestScreen(t *testing.T) {
+	e := newTestEditor()
+	if err := e.Rectangle(0, 0, 3, 7, 2, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Line(0, 7, 7, 0, 3); err != nil {
+		t.Fatal(err)
+	}
+	cfg := config.NewMartineConfig("", "")
+	expected := screen.ToMode1(e.Image(), testPalette, cfg)
+	if !bytes.Equal(expected, e.Screen(false)) {
+		t.Fatalf("expected the same screen as the mode 1 conversion")
+	}
+	data, lineSize := e.Sprite()
+	if lineSize != 2 || len(data) != 16 {
+		t.Fatalf("expected 8 lines of 2 bytes and gets %d bytes, line size %d", len(data), lineSize)
+	}
+}
@@ -0,0 +1,198 @@
+package effect
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+
+	"github.com/jeromelesaux/martine/config"
+	"github.com/jeromelesaux/martine/constants"
+	"github.com/jeromelesaux/martine/convert/export"
+	ci "github.com/jeromelesaux/martine/convert/image"
+	"github.com/jeromelesaux/martine/export/impdraw/backraster"
+	"github.com/jeromelesaux/martine/export/png"
+	"github.com/jeromelesaux/martine/gfx/errors"
+)
+
+const (
+	// minimum part of a row covered by the background color
+	backgroundCoverage = 0.25
+	// maximum distance between the background colors of two following rows
+	backgroundStep = 3 * 32 * 32
+	// maximum distance between a pixel and the background color of its row
+	backgroundThreshold = 3 * 40 * 40
+	// minimum number of following rows of a gradient
+	backgroundRows = 8
+)
+
+// DoBackgroundRaster converts the image with a raster on the ink 0 and the
+// border, then saves the screen, the raster table, the routine and its
+// loader.
+func DoBackgroundRaster(in image.Image, filename string, mode uint8, cfg *config.MartineConfig) error {
+	bw, table, p, err := BackgroundRaster(in, filename, mode, cfg)
+	if err != nil {
+		return err
+	}
+	if err := export.Export(filename, bw, p, mode, cfg); err != nil {
+		return err
+	}
+	return backraster.ExportBackRaster(filename, table, hardwareValues(p[1:]), mode, cfg)
+}
+
+// BackgroundRaster detects the vertical gradient of the background of the
+// image, the most used color of the following rows when it changes slowly,
+// and converts it into a table of the ink 0 (one hardware color by line). The
+// other pixels are converted with the remaining inks. It returns the screen
+// bytes, the table and the palette of the first line.
+func BackgroundRaster(in image.Image, filename string, mode uint8, cfg *config.MartineConfig) ([]byte, []byte, color.Palette, error) {
+	size := constants.NewSizeMode(mode, cfg.Overscan)
+	out := ci.Resize(in, size, cfg.ResizingAlgo)
+	if cfg.Reducer > -1 {
+		out = ci.Reducer(out, cfg.Reducer)
+	}
+	width, height := out.Bounds().Dx(), out.Bounds().Dy()
+
+	background := backgroundColors(out)
+	table := make([]byte, height)
+	rasterColors := make([]color.Color, height)
+	distinct := make(map[byte]bool)
+	first := -1
+	for y, c := range background {
+		if c == nil {
+			continue
+		}
+		rasterColors[y] = constants.CpcOldPalette.Convert(c)
+		table[y] = hardwareValues(color.Palette{rasterColors[y]})[0]
+		distinct[table[y]] = true
+		if first == -1 {
+			first = y
+		}
+	}
+	if len(distinct) < 2 {
+		return nil, nil, nil, errors.ErrorNoGradient
+	}
+	// the lines without background keep the color of the line above
+	for y := range table {
+		if background[y] != nil {
+			continue
+		}
+		from := y - 1
+		if y < first {
+			from = first
+		}
+		table[y], rasterColors[y] = table[from], rasterColors[from]
+	}
+	fmt.Fprintf(os.Stdout, "Background raster of %d colors from the line %d\n", len(distinct), first)
+
+	// the remaining inks are chosen on the foreground pixels
+	foreground := make([]color.NRGBA, 0)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if !isBackground(out.NRGBAAt(x, y), background[y]) {
+				foreground = append(foreground, out.NRGBAAt(x, y))
+			}
+		}
+	}
+	p := make(color.Palette, 1, size.ColorsAvailable)
+	if len(foreground) > 0 {
+		packed := image.NewNRGBA(image.Rect(0, 0, len(foreground), 1))
+		for i, c := range foreground {
+			packed.SetNRGBA(i, 0, c)
+		}
+		candidates := make([]color.NRGBA, len(constants.CpcOldPalette))
+		for i, c := range constants.CpcOldPalette {
+			candidates[i] = color.NRGBAModel.Convert(c).(color.NRGBA)
+		}
+		for _, i := range selectColors(imageColors(packed), candidates, size.ColorsAvailable-1) {
+			p = append(p, candidates[i])
+		}
+	}
+	for len(p) < size.ColorsAvailable {
+		p = append(p, color.Black)
+	}
+
+	bytesSize := 0x4000
+	if cfg.Overscan {
+		bytesSize = 0x8000
+	}
+	bw := make([]byte, bytesSize)
+	downgraded := image.NewNRGBA(out.Bounds())
+	firmwareColorUsed := make(map[int]int)
+	for y := 0; y < height; y++ {
+		// the pen 0 gets the raster color of the line
+		p[0] = rasterColors[y]
+		for x := 0; x < width; x++ {
+			c := out.NRGBAAt(x, y)
+			if isBackground(c, background[y]) {
+				downgraded.Set(x, y, p[0])
+			} else {
+				downgraded.Set(x, y, p[p.Index(c)])
+			}
+		}
+		for x := 0; x < width; {
+			switch mode {
+			case 0:
+				bw, firmwareColorUsed = setPixelMode0(downgraded, downgraded, p, x, y, bw, firmwareColorUsed, cfg)
+				x += 2
+			case 1:
+				bw, firmwareColorUsed = setPixelMode1(downgraded, downgraded, p, x, y, bw, firmwareColorUsed, cfg)
+				x += 4
+			default:
+				bw, firmwareColorUsed = setPixelMode2(downgraded, downgraded, p, x, y, bw, firmwareColorUsed, cfg)
+				x += 8
+			}
+		}
+	}
+	p[0] = rasterColors[0]
+	if err := png.Png(filepath.Join(cfg.OutputPath, filename+"_raster.png"), downgraded); err != nil {
+		return bw, table, p, err
+	}
+	return bw, table, p, nil
+}
+
+// backgroundColors returns the background color of each row, nil if the row
+// is not in a gradient.
+func backgroundColors(in *image.NRGBA) []color.Color {
+	width, height := in.Bounds().Dx(), in.Bounds().Dy()
+	colors := make([]color.Color, height)
+	for y := 0; y < height; y++ {
+		row := imageColors(in.SubImage(image.Rect(0, y, width, y+1)).(*image.NRGBA))
+		if len(row) > 0 && row[0].count/float64(width) >= backgroundCoverage {
+			colors[y] = color.NRGBA{R: uint8(row[0].c[0]), G: uint8(row[0].c[1]), B: uint8(row[0].c[2]), A: 0xff}
+		}
+	}
+	// only the runs of rows with slow changes are kept
+	start := 0
+	for y := 1; y <= height; y++ {
+		if y < height && colors[y] != nil && colors[y-1] != nil &&
+			sqDistance(colors[y].(color.NRGBA), colors[y-1].(color.NRGBA)) <= backgroundStep {
+			continue
+		}
+		if y-start < backgroundRows {
+			for i := start; i < y; i++ {
+				colors[i] = nil
+			}
+		}
+		start = y
+	}
+	return colors
+}
+
+func isBackground(c color.NRGBA, background color.Color) bool {
+	return background != nil && sqDistance(c, background.(color.NRGBA)) <= backgroundThreshold
+}
+
+func hardwareValues(p color.Palette) []byte {
+	values := make([]byte, len(p))
+	for i, c := range p {
+		v, err := constants.HardwareValues(c)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error while getting the hardware values for color %v, error :%v\n", c, err)
+			v = []byte{0x54}
+		}
+		values[i] = v[0]
+	}
+	return values
+}
@@ -0,0 +1,47 @@
+package effect
+
+import (
+	"image"
+	"image/color"
+	"os"
+	"testing"
+
+	"github.com/jeromelesaux/martine/config"
+	"github.com/jeromelesaux/martine/gfx/errors"
+)
+
+func TestBackgroundRaster(t *testing.T) {
+	out, err := os.MkdirTemp("", "backraster")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(out)
+	cfg := config.NewMartineConfig("sunset.png", out)
+	cfg.Reducer = -1
+	// a sunset from blue to red with a green square
+	img := image.NewNRGBA(image.Rect(0, 0, 320, 200))
+	for y := 0; y < 200; y++ {
+		for x := 0; x < 320; x++ {
+			c := color.NRGBA{R: uint8(y * 255 / 199), B: uint8(255 - y*255/199), A: 0xff}
+			if x >= 100 && x < 200 && y >= 60 && y < 140 {
+				c = color.NRGBA{G: 0xff, A: 0xff}
+			}
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	_, table, p, err := BackgroundRaster(img, "sunset", 1, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(table) != 200 || table[0] == table[199] {
+		t.Fatalf("expected a gradient from the first to the last line")
+	}
+	if len(p) != 4 {
+		t.Fatalf("expected 4 inks and gets %d", len(p))
+	}
+
+	flat := image.NewNRGBA(image.Rect(0, 0, 320, 200))
+	if _, _, _, err := BackgroundRaster(flat, "flat", 1, cfg); err != errors.ErrorNoGradient {
+		t.Fatalf("expected no gradient and gets %v", err)
+	}
+}
@@ -0,0 +1,35 @@
+package effect
+
+import (
+	"image"
+	"path/filepath"
+
+	"github.com/jeromelesaux/martine/config"
+	"github.com/jeromelesaux/martine/export/impdraw/plusdma"
+	"github.com/jeromelesaux/martine/export/png"
+)
+
+// DoDmaRaster saves the dma list of the gradient of the pen, the gradient
+// is read from the keyframes or from the rows of the image, and a preview of
+// the frame with the gradient.
+func DoDmaRaster(in image.Image, filename string, mode uint8, cfg *config.MartineConfig) error {
+	var g plusdma.Gradient
+	if cfg.DmaKeyframes != "" {
+		var err error
+		if g, err = plusdma.ParseKeyframes(cfg.DmaPen, cfg.DmaKeyframes); err != nil {
+			return err
+		}
+	} else {
+		g = plusdma.ImageGradient(cfg.DmaPen, cfg.DmaLine, in)
+	}
+	preview := image.NewNRGBA(image.Rect(0, 0, 64, plusdma.FrameLines))
+	for i, c := range g.Colors {
+		for x := 0; x < 64; x++ {
+			preview.Set(x, g.Line+i, c)
+		}
+	}
+	if err := png.Png(filepath.Join(cfg.OutputPath, filename+"_dma.png"), preview); err != nil {
+		return err
+	}
+	return plusdma.ExportDma(filename, []plusdma.Gradient{g}, mode, cfg)
+}
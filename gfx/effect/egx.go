@@ -20,6 +20,7 @@ import (
 	"github.com/jeromelesaux/martine/export/png"
 	"github.com/jeromelesaux/martine/gfx"
 	"github.com/jeromelesaux/martine/gfx/errors"
+	"github.com/jeromelesaux/martine/gfx/filter"
 )
 
 func Egx(filepath1, filepath2 string, p color.Palette, m1, m2 int, cfg *config.MartineConfig) error {
@@ -158,7 +159,7 @@ func AutoEgx1(in image.Image,
 		os.Exit(-2)
 	}
 
-	downgraded, p = gfx.DoDithering(downgraded, p, cfg.DitheringAlgo, cfg.DitheringType, cfg.DitheringWithQuantification, cfg.DitheringMatrix, float32(cfg.DitheringMultiplier), cfg.CpcPlus, cfg.Size)
+	downgraded, p = gfx.DoDithering(downgraded, p, cfg.DitheringAlgo, cfg.DitheringType, cfg.DitheringWithQuantification, cfg.DitheringMatrix, float32(cfg.DitheringMultiplier), filter.PatternAlgorithm(cfg.DitheringPattern), cfg.CpcPlus, cfg.Size, gfx.PaletteAwareDiffusion(cfg, cfg.EgxMode1))
 
 	return ToEgx1(downgraded, downgraded, p, 0, picturePath, cfg)
 }
@@ -201,7 +202,7 @@ func AutoEgx2(in image.Image,
 		os.Exit(-2)
 	}
 
-	downgraded, p = gfx.DoDithering(downgraded, p, cfg.DitheringAlgo, cfg.DitheringType, cfg.DitheringWithQuantification, cfg.DitheringMatrix, float32(cfg.DitheringMultiplier), cfg.CpcPlus, cfg.Size)
+	downgraded, p = gfx.DoDithering(downgraded, p, cfg.DitheringAlgo, cfg.DitheringType, cfg.DitheringWithQuantification, cfg.DitheringMatrix, float32(cfg.DitheringMultiplier), filter.PatternAlgorithm(cfg.DitheringPattern), cfg.CpcPlus, cfg.Size, gfx.PaletteAwareDiffusion(cfg, cfg.EgxMode1))
 
 	return ToEgx2(downgraded, downgraded, p, 1, picturePath, cfg)
 }
@@ -3,7 +3,6 @@ package effect
 import (
 	"image"
 	"os"
-	"path"
 	"path/filepath"
 	"strings"
 
@@ -26,6 +25,9 @@ func Flash(filepath1, filepath2, palpath1, palpath2 string, m1, m2 int, cfg *con
 		if err != nil {
 			return err
 		}
+		if cfg.FlashFrames > 0 {
+			return TemporalFlash(in, cfg, filename, filepath1, uint8(m1), cfg.FlashFrames)
+		}
 		return AutoFlash(in, cfg, filename, filepath1, m1, uint8(m1))
 	}
 	filename1 := filepath.Base(filepath1)
@@ -81,14 +83,7 @@ func AutoFlash(in image.Image,
 		x++
 		y = 0
 	}
-	ext := path.Ext(filename)
-	name := strings.Replace(filename, ext, "", 1)
-	namesize := len(name)
-	if namesize > 8 {
-		namesize = 7
-	}
-	flashPaletteFilename1 := strings.ToUpper(name)[0:namesize] + "1.PAL"
-	flashPalettePath1 := filepath.Join(cfg.OutputPath, flashPaletteFilename1)
+	flashPalettePath1 := cfg.AmsdosFullPath(filepathLeft, ".PAL")
 
 	err = gfx.ApplyOneImageAndExport(leftIm,
 		cfg,
@@ -128,8 +123,7 @@ func AutoFlash(in image.Image,
 		cfg.Size = constants.Mode2
 	}
 
-	flashPaletteFilename2 := strings.ToUpper(name)[0:namesize] + "2.PAL"
-	flashPalettePath2 := filepath.Join(cfg.OutputPath, flashPaletteFilename2)
+	flashPalettePath2 := cfg.AmsdosFullPath(filepathRigth, ".PAL")
 
 	cfg.PalettePath = flashPalettePath1
 
@@ -0,0 +1,123 @@
+package effect
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"os"
+	"path/filepath"
+
+	"github.com/disintegration/imaging"
+	"github.com/jeromelesaux/martine/config"
+	"github.com/jeromelesaux/martine/constants"
+	"github.com/jeromelesaux/martine/convert/export"
+	ci "github.com/jeromelesaux/martine/convert/image"
+	"github.com/jeromelesaux/martine/export/impdraw/mixedmode"
+	"github.com/jeromelesaux/martine/export/png"
+	"github.com/jeromelesaux/martine/gfx"
+	"github.com/jeromelesaux/martine/gfx/filter"
+)
+
+// DoMixedMode converts the image with the mode and the palette of each band
+// and saves the screen, the raster routine and its loader.
+func DoMixedMode(in image.Image, bands []mixedmode.Band, filename string, cfg *config.MartineConfig) error {
+	bw, bands, err := MixedMode(in, bands, filename, cfg)
+	if err != nil {
+		return err
+	}
+	if err := export.Export(filename, bw, bands[0].Palette, bands[0].Mode, cfg); err != nil {
+		return err
+	}
+	return mixedmode.ExportMixedMode(filename, bands, cfg)
+}
+
+// MixedMode returns the screen bytes of the image converted band by band and
+// the bands with their palettes. A band without palette gets the palette
+// computed on its part of the image.
+func MixedMode(in image.Image, bands []mixedmode.Band, filename string, cfg *config.MartineConfig) ([]byte, []mixedmode.Band, error) {
+	height := constants.NewSizeMode(0, cfg.Overscan).Height
+	if err := mixedmode.CheckBands(bands, height); err != nil {
+		return nil, bands, err
+	}
+	bytesWidth := 80
+	bw := make([]byte, 0x4000)
+	if cfg.Overscan {
+		bytesWidth = 96
+		bw = make([]byte, 0x8000)
+	}
+	preview := image.NewNRGBA(image.Rect(0, 0, bytesWidth*8, height*2))
+	source := in.Bounds()
+	for i, b := range bands {
+		size := constants.NewSizeMode(b.Mode, cfg.Overscan)
+		size.Height = b.Height
+		// the part of the source image displayed by the band
+		part := imaging.Crop(in, image.Rect(
+			source.Min.X,
+			source.Min.Y+b.Line*source.Dy()/height,
+			source.Max.X,
+			source.Min.Y+(b.Line+b.Height)*source.Dy()/height))
+		resized := ci.Resize(part, size, cfg.ResizingAlgo)
+		if cfg.Reducer > -1 {
+			resized = ci.Reducer(resized, cfg.Reducer)
+		}
+		p := b.Palette
+		var downgraded *image.NRGBA
+		var err error
+		if len(p) == 0 {
+			p, downgraded, err = ci.DowngradingPalette(imaging.Clone(resized), size, cfg.CpcPlus)
+			if err != nil {
+				return nil, bands, err
+			}
+			p = bandPalette(p, size.ColorsAvailable)
+			_, downgraded = ci.DowngradingWithPalette(downgraded, p)
+		} else {
+			_, downgraded = ci.DowngradingWithPalette(imaging.Clone(resized), p)
+		}
+		if cfg.DitheringAlgo != -1 {
+			out, _ := gfx.DoDithering(resized, p, cfg.DitheringAlgo, cfg.DitheringType, cfg.DitheringWithQuantification, cfg.DitheringMatrix, float32(cfg.DitheringMultiplier), filter.PatternAlgorithm(cfg.DitheringPattern), cfg.CpcPlus, size, gfx.PaletteAwareDiffusion(cfg, b.Mode))
+			_, downgraded = ci.DowngradingWithPalette(out, p)
+		}
+		bands[i].Palette = p
+		fmt.Fprintf(os.Stdout, "Band [%d] lines %d-%d mode %d with %d colors\n", i, b.Line, b.Line+b.Height-1, b.Mode, len(p))
+
+		// the pixels are placed at the lines of the band on the screen
+		positioned := image.NewNRGBA(image.Rect(0, b.Line, size.Width, b.Line+b.Height))
+		draw.Draw(positioned, positioned.Bounds(), downgraded, image.Point{}, draw.Src)
+		firmwareColorUsed := make(map[int]int)
+		for y := b.Line; y < b.Line+b.Height; y++ {
+			for x := 0; x < size.Width; {
+				switch b.Mode {
+				case 0:
+					bw, firmwareColorUsed = setPixelMode0(positioned, positioned, p, x, y, bw, firmwareColorUsed, cfg)
+					x += 2
+				case 1:
+					bw, firmwareColorUsed = setPixelMode1(positioned, positioned, p, x, y, bw, firmwareColorUsed, cfg)
+					x += 4
+				default:
+					bw, firmwareColorUsed = setPixelMode2(positioned, positioned, p, x, y, bw, firmwareColorUsed, cfg)
+					x += 8
+				}
+			}
+		}
+		scaled := imaging.Resize(downgraded, preview.Bounds().Dx(), b.Height*2, imaging.NearestNeighbor)
+		draw.Draw(preview, image.Rect(0, b.Line*2, preview.Bounds().Dx(), (b.Line+b.Height)*2), scaled, image.Point{}, draw.Src)
+	}
+	if err := png.Png(filepath.Join(cfg.OutputPath, filename+"_mixed.png"), preview); err != nil {
+		return bw, bands, err
+	}
+	return bw, bands, nil
+}
+
+// bandPalette returns the palette with the number of colors of the mode
+// sorted by distance.
+func bandPalette(p color.Palette, colors int) color.Palette {
+	newPalette := make(color.Palette, colors)
+	copy(newPalette, p)
+	for i, v := range newPalette {
+		if v == nil {
+			newPalette[i] = color.Black
+		}
+	}
+	return constants.SortColorsByDistance(newPalette)
+}
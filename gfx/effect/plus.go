@@ -0,0 +1,271 @@
+package effect
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/jeromelesaux/martine/config"
+	"github.com/jeromelesaux/martine/constants"
+	"github.com/jeromelesaux/martine/convert/address"
+	"github.com/jeromelesaux/martine/convert/export"
+	ci "github.com/jeromelesaux/martine/convert/image"
+	"github.com/jeromelesaux/martine/convert/pixel"
+	"github.com/jeromelesaux/martine/export/impdraw/plusraster"
+	"github.com/jeromelesaux/martine/export/png"
+	"github.com/jeromelesaux/martine/gfx/errors"
+)
+
+// number of colors of a line tried as new ink of a pen
+const lineCandidates = 24
+
+// DoPlusMaxColors converts the image for the cpc plus in mode 0 with palette
+// changes on the lines and hardware sprites, then saves the screen, the
+// sprites, the dma list of the palette changes, the routine and its loader.
+func DoPlusMaxColors(in image.Image, filename string, cfg *config.MartineConfig) error {
+	if cfg.Overscan {
+		return errors.ErrorNotYetImplemented
+	}
+	cfg.CpcPlus = true
+	data, _, screen, err := PlusMaxColors(in, filename, cfg)
+	if err != nil {
+		return err
+	}
+	if err := export.Export(filename, data, screen.Palette, 0, cfg); err != nil {
+		return err
+	}
+	return plusraster.ExportPlusRaster(filename, screen, 0, cfg)
+}
+
+// PlusMaxColors returns the screen bytes in mode 0, the image displayed and
+// the palettes and sprites of the screen. At each line, at most
+// cfg.PlusChangesByLine pens get a new color of the 4096 colors, then the 16
+// hardware sprites cover the parts of the image with the biggest errors.
+func PlusMaxColors(in image.Image, filename string, cfg *config.MartineConfig) ([]byte, *image.NRGBA, plusraster.Screen, error) {
+	var screen plusraster.Screen
+	changesByLine := cfg.PlusChangesByLine
+	if changesByLine <= 0 || changesByLine > plusraster.MaxChangesByLine {
+		changesByLine = plusraster.MaxChangesByLine
+	}
+	out := ci.Resize(in, constants.Mode0, cfg.ResizingAlgo)
+	if cfg.Reducer > -1 {
+		out = ci.Reducer(out, cfg.Reducer)
+	}
+	width, height := out.Bounds().Dx(), out.Bounds().Dy()
+
+	// the palette of the first line is chosen on the first eight lines
+	p := make(color.Palette, 16)
+	first := imageColors(out.SubImage(image.Rect(0, 0, width, 8)).(*image.NRGBA))
+	candidates := plusColors(first)
+	for i := range p {
+		p[i] = color.NRGBA{A: 0xff}
+	}
+	for i, index := range selectColors(first, candidates, 16) {
+		p[i] = candidates[index]
+	}
+	screen.Palette = make(color.Palette, 16)
+	copy(screen.Palette, p)
+
+	bw := make([]byte, 0x4000)
+	displayed := image.NewNRGBA(out.Bounds())
+	for y := 0; y < height; y++ {
+		if y > 0 {
+			line := imageColors(out.SubImage(image.Rect(0, y, width, y+1)).(*image.NRGBA))
+			screen.Changes = append(screen.Changes, changeLinePens(line, p, y, changesByLine)...)
+		}
+		for x := 0; x < width; x += 2 {
+			p1 := p.Index(out.At(x, y))
+			p2 := p.Index(out.At(x+1, y))
+			displayed.Set(x, y, p[p1])
+			displayed.Set(x+1, y, p[p2])
+			bw[address.CpcScreenAddress(0, x, y, 0, false, false)] = pixel.PixelMode0(p1, p2)
+		}
+	}
+	fmt.Fprintf(os.Stdout, "%d palette changes on %d lines\n", len(screen.Changes), height)
+
+	screen.Sprites, screen.SpritePalette = spritesPatches(out, displayed)
+	fmt.Fprintf(os.Stdout, "%d hardware sprites\n", len(screen.Sprites))
+	if err := png.Png(filepath.Join(cfg.OutputPath, filename+"_plus.png"), displayed); err != nil {
+		return bw, displayed, screen, err
+	}
+	return bw, displayed, screen, nil
+}
+
+// plusColors returns the colors in the 4096 colors of the cpc plus.
+func plusColors(colors []colorCount) []color.NRGBA {
+	plus := make([]color.NRGBA, 0, len(colors))
+	known := make(map[color.NRGBA]bool)
+	for _, v := range colors {
+		c := plusColor(color.NRGBA{R: uint8(v.c[0]), G: uint8(v.c[1]), B: uint8(v.c[2]), A: 0xff})
+		if !known[c] {
+			known[c] = true
+			plus = append(plus, c)
+		}
+	}
+	return plus
+}
+
+func plusColor(c color.Color) color.NRGBA {
+	cp := constants.NewCpcPlusColor(c)
+	return color.NRGBA{R: uint8(cp.R << 4), G: uint8(cp.G << 4), B: uint8(cp.B << 4), A: 0xff}
+}
+
+// changeLinePens changes the pens of the palette which reduce the most the
+// error of the line, one pen after the other.
+func changeLinePens(line []colorCount, p color.Palette, y, changesByLine int) []plusraster.PenChange {
+	changes := make([]plusraster.PenChange, 0)
+	candidates := plusColors(line)
+	if len(candidates) > lineCandidates {
+		candidates = candidates[:lineCandidates]
+	}
+	changed := make([]bool, len(p))
+	for k := 0; k < changesByLine; k++ {
+		// best and second best pens of each color of the line
+		best := make([]int, len(line))
+		d1 := make([]float64, len(line))
+		d2 := make([]float64, len(line))
+		var current float64
+		for i, c := range line {
+			d1[i], d2[i] = math.MaxFloat64, math.MaxFloat64
+			for pen, pc := range p {
+				d := colorDistance(c, pc.(color.NRGBA))
+				if d < d1[i] {
+					d2[i] = d1[i]
+					d1[i], best[i] = d, pen
+				} else if d < d2[i] {
+					d2[i] = d
+				}
+			}
+			current += d1[i] * c.count
+		}
+		bestPen, bestCandidate := -1, -1
+		bestError := current
+		for pen := range p {
+			if changed[pen] {
+				continue
+			}
+			for j, candidate := range candidates {
+				var e float64
+				for i, c := range line {
+					d := d1[i]
+					if best[i] == pen {
+						d = d2[i]
+					}
+					if dc := colorDistance(c, candidate); dc < d {
+						d = dc
+					}
+					e += d * c.count
+					if e >= bestError {
+						break
+					}
+				}
+				if e < bestError {
+					bestError, bestPen, bestCandidate = e, pen, j
+				}
+			}
+		}
+		if bestPen == -1 {
+			break
+		}
+		changed[bestPen] = true
+		p[bestPen] = candidates[bestCandidate]
+		changes = append(changes, plusraster.PenChange{Line: y, Pen: bestPen, Color: candidates[bestCandidate]})
+	}
+	return changes
+}
+
+func sqDistance(c1, c2 color.NRGBA) float64 {
+	dr := float64(c1.R) - float64(c2.R)
+	dg := float64(c1.G) - float64(c2.G)
+	db := float64(c1.B) - float64(c2.B)
+	return dr*dr + dg*dg + db*db
+}
+
+// spritesPatches places the 16 hardware sprites (16x16 mode 0 pixels) on the
+// parts of the image with the biggest errors, a sprite pixel is displayed when
+// its color is nearer than the color of the screen.
+func spritesPatches(out, displayed *image.NRGBA) ([]plusraster.Sprite, color.Palette) {
+	width, height := out.Bounds().Dx(), out.Bounds().Dy()
+	// summed error table
+	sum := make([][]float64, height+1)
+	for y := range sum {
+		sum[y] = make([]float64, width+1)
+	}
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			e := sqDistance(out.NRGBAAt(x, y), displayed.NRGBAAt(x, y))
+			sum[y+1][x+1] = e + sum[y][x+1] + sum[y+1][x] - sum[y][x]
+		}
+	}
+	type window struct {
+		r   image.Rectangle
+		err float64
+	}
+	windows := make([]window, 0)
+	for y := 0; y+16 <= height; y += 2 {
+		for x := 0; x+16 <= width; x += 2 {
+			e := sum[y+16][x+16] - sum[y][x+16] - sum[y+16][x] + sum[y][x]
+			if e > 0 {
+				windows = append(windows, window{r: image.Rect(x, y, x+16, y+16), err: e})
+			}
+		}
+	}
+	sort.Slice(windows, func(i, j int) bool { return windows[i].err > windows[j].err })
+	chosen := make([]image.Rectangle, 0, 16)
+	for _, w := range windows {
+		if len(chosen) == 16 {
+			break
+		}
+		overlaps := false
+		for _, c := range chosen {
+			if c.Overlaps(w.r) {
+				overlaps = true
+				break
+			}
+		}
+		if !overlaps {
+			chosen = append(chosen, w.r)
+		}
+	}
+	if len(chosen) == 0 {
+		return nil, nil
+	}
+
+	// the 15 colors of the sprites are chosen on the pixels of the patches
+	patches := image.NewNRGBA(image.Rect(0, 0, 16, 16*len(chosen)))
+	for i, r := range chosen {
+		for y := 0; y < 16; y++ {
+			for x := 0; x < 16; x++ {
+				patches.Set(x, i*16+y, out.NRGBAAt(r.Min.X+x, r.Min.Y+y))
+			}
+		}
+	}
+	colors := imageColors(patches)
+	candidates := plusColors(colors)
+	sp := make(color.Palette, 0, 15)
+	for _, index := range selectColors(colors, candidates, 15) {
+		sp = append(sp, candidates[index])
+	}
+
+	sprites := make([]plusraster.Sprite, len(chosen))
+	for i, r := range chosen {
+		sprites[i] = plusraster.Sprite{X: r.Min.X, Y: r.Min.Y}
+		for y := 0; y < 16; y++ {
+			for x := 0; x < 16; x++ {
+				o := out.NRGBAAt(r.Min.X+x, r.Min.Y+y)
+				pen := sp.Index(o)
+				c := sp[pen].(color.NRGBA)
+				if sqDistance(o, c) < sqDistance(o, displayed.NRGBAAt(r.Min.X+x, r.Min.Y+y)) {
+					// pen 0 is transparent
+					sprites[i].Data.Data[y*16+x] = byte(pen + 1)
+					displayed.Set(r.Min.X+x, r.Min.Y+y, c)
+				}
+			}
+		}
+	}
+	return sprites, sp
+}
@@ -0,0 +1,282 @@
+package effect
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/jeromelesaux/martine/config"
+	"github.com/jeromelesaux/martine/constants"
+	"github.com/jeromelesaux/martine/convert/export"
+	ci "github.com/jeromelesaux/martine/convert/image"
+	"github.com/jeromelesaux/martine/export/ocpartstudio"
+	"github.com/jeromelesaux/martine/export/png"
+	"github.com/jeromelesaux/martine/gfx"
+	"github.com/jeromelesaux/martine/gfx/errors"
+	"github.com/jeromelesaux/martine/gfx/filter"
+)
+
+// DefaultMaxFlicker is the maximum luminance difference between the inks of
+// two following frames used by default.
+const DefaultMaxFlicker = 0.3
+
+// BlendedColor is the apparent color of inks displayed one after the other,
+// one ink per frame.
+type BlendedColor struct {
+	Inks    color.Palette
+	Color   color.NRGBA
+	Flicker float64
+}
+
+// NewBlendedColor returns the blended color of the inks. The inks are ordered
+// to keep the luminance difference between two following frames low.
+func NewBlendedColor(inks ...color.Color) BlendedColor {
+	sorted := make(color.Palette, len(inks))
+	copy(sorted, inks)
+	sort.SliceStable(sorted, func(i, j int) bool { return luminance(sorted[i]) < luminance(sorted[j]) })
+	// the lower inks in ascending order then the others in descending order,
+	// the cycle never jumps from the darkest to the lightest ink
+	ordered := make(color.Palette, 0, len(inks))
+	for i := 0; i < len(sorted); i += 2 {
+		ordered = append(ordered, sorted[i])
+	}
+	for i := len(sorted) - 1 - len(sorted)%2; i > 0; i -= 2 {
+		ordered = append(ordered, sorted[i])
+	}
+	var r, g, b, flicker float64
+	for i, c := range ordered {
+		cr, cg, cb, _ := c.RGBA()
+		// the colors are mixed in linear light
+		r += math.Pow(float64(cr)/0xffff, 2.2)
+		g += math.Pow(float64(cg)/0xffff, 2.2)
+		b += math.Pow(float64(cb)/0xffff, 2.2)
+		d := math.Abs(luminance(c) - luminance(ordered[(i+1)%len(ordered)]))
+		if d > flicker {
+			flicker = d
+		}
+	}
+	n := float64(len(ordered))
+	return BlendedColor{
+		Inks: ordered,
+		Color: color.NRGBA{
+			R: uint8(math.Round(math.Pow(r/n, 1/2.2) * 0xff)),
+			G: uint8(math.Round(math.Pow(g/n, 1/2.2) * 0xff)),
+			B: uint8(math.Round(math.Pow(b/n, 1/2.2) * 0xff)),
+			A: 0xff,
+		},
+		Flicker: flicker,
+	}
+}
+
+func luminance(c color.Color) float64 {
+	r, g, b, _ := c.RGBA()
+	return (0.299*float64(r) + 0.587*float64(g) + 0.114*float64(b)) / 0xffff
+}
+
+// BlendedColors returns all the blended colors of the inks on the frames with
+// a flicker lower or equal to maxFlicker.
+func BlendedColors(p color.Palette, frames int, maxFlicker float64) []BlendedColor {
+	blends := make([]BlendedColor, 0)
+	inks := make([]color.Color, frames)
+	var combine func(depth, first int)
+	combine = func(depth, first int) {
+		if depth == frames {
+			b := NewBlendedColor(inks...)
+			if b.Flicker <= maxFlicker {
+				blends = append(blends, b)
+			}
+			return
+		}
+		for i := first; i < len(p); i++ {
+			inks[depth] = p[i]
+			combine(depth+1, i)
+		}
+	}
+	combine(0, 0)
+	return blends
+}
+
+type colorCount struct {
+	c     [3]float64
+	count float64
+}
+
+// imageColors returns the colors of the image reduced to 4 bits by channel
+// with their number of pixels, the most used first.
+func imageColors(in *image.NRGBA) []colorCount {
+	buckets := make(map[int]*colorCount)
+	b := in.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := in.NRGBAAt(x, y)
+			key := int(c.R>>4)<<8 | int(c.G>>4)<<4 | int(c.B>>4)
+			v, ok := buckets[key]
+			if !ok {
+				v = &colorCount{}
+				buckets[key] = v
+			}
+			v.c[0] += float64(c.R)
+			v.c[1] += float64(c.G)
+			v.c[2] += float64(c.B)
+			v.count++
+		}
+	}
+	colors := make([]colorCount, 0, len(buckets))
+	for _, v := range buckets {
+		colors = append(colors, colorCount{
+			c:     [3]float64{v.c[0] / v.count, v.c[1] / v.count, v.c[2] / v.count},
+			count: v.count,
+		})
+	}
+	sort.Slice(colors, func(i, j int) bool { return colors[i].count > colors[j].count })
+	if len(colors) > 512 {
+		colors = colors[:512]
+	}
+	return colors
+}
+
+func colorDistance(c colorCount, col color.NRGBA) float64 {
+	dr := c.c[0] - float64(col.R)
+	dg := c.c[1] - float64(col.G)
+	db := c.c[2] - float64(col.B)
+	return dr*dr + dg*dg + db*db
+}
+
+// TemporalPalette chooses the blended colors (one by pen) which reduce the
+// most the error with the colors of the image.
+func TemporalPalette(in *image.NRGBA, blends []BlendedColor, colors int) []BlendedColor {
+	candidates := make([]color.NRGBA, len(blends))
+	for i, b := range blends {
+		candidates[i] = b.Color
+	}
+	selected := make([]BlendedColor, 0, colors)
+	for _, i := range selectColors(imageColors(in), candidates, colors) {
+		selected = append(selected, blends[i])
+	}
+	return selected
+}
+
+// selectColors returns the indexes of the candidates which reduce the most the
+// error with the colors, one candidate after the other.
+func selectColors(imgColors []colorCount, candidates []color.NRGBA, colors int) []int {
+	best := make([]float64, len(imgColors))
+	for i := range best {
+		best[i] = math.MaxFloat64
+	}
+	selected := make([]int, 0, colors)
+	used := make([]bool, len(candidates))
+	for len(selected) < colors && len(selected) < len(candidates) {
+		index := -1
+		bestError := math.MaxFloat64
+		for j, candidate := range candidates {
+			if used[j] {
+				continue
+			}
+			var e float64
+			for i, c := range imgColors {
+				d := colorDistance(c, candidate)
+				if d > best[i] {
+					d = best[i]
+				}
+				e += d * c.count
+				if e >= bestError {
+					break
+				}
+			}
+			if e < bestError {
+				bestError = e
+				index = j
+			}
+		}
+		used[index] = true
+		selected = append(selected, index)
+		for i, c := range imgColors {
+			if d := colorDistance(c, candidates[index]); d < best[i] {
+				best[i] = d
+			}
+		}
+	}
+	return selected
+}
+
+// FramePalettes returns the palette of each frame of the blended colors.
+func FramePalettes(blends []BlendedColor, frames int) []color.Palette {
+	palettes := make([]color.Palette, frames)
+	for f := 0; f < frames; f++ {
+		palettes[f] = make(color.Palette, len(blends))
+		for i, b := range blends {
+			palettes[f][i] = b.Inks[f%len(b.Inks)]
+		}
+	}
+	return palettes
+}
+
+// AveragedPalette returns the apparent colors of the blended colors.
+func AveragedPalette(blends []BlendedColor) color.Palette {
+	p := make(color.Palette, len(blends))
+	for i, b := range blends {
+		p[i] = b.Color
+	}
+	return p
+}
+
+// TemporalRaw converts the image into a screen displayed with a different
+// palette on each frame. It returns the screen bytes, the averaged image seen
+// by the eye and the blended colors of the pens.
+func TemporalRaw(in image.Image, cfg *config.MartineConfig, screenMode uint8, frames int) ([]byte, *image.NRGBA, []BlendedColor, error) {
+	if frames < 2 || frames > 4 {
+		return nil, nil, nil, errors.ErrorFlashFrames
+	}
+	maxFlicker := cfg.FlashMaxFlicker
+	if maxFlicker <= 0 {
+		maxFlicker = DefaultMaxFlicker
+	}
+	out := ci.Resize(in, cfg.Size, cfg.ResizingAlgo)
+	if cfg.Reducer > -1 {
+		out = ci.Reducer(out, cfg.Reducer)
+	}
+	blends := BlendedColors(constants.CpcOldPalette, frames, maxFlicker)
+	blends = TemporalPalette(out, blends, constants.NewSize(screenMode).ColorsAvailable)
+	fmt.Fprintf(os.Stdout, "%d blended colors on %d frames\n", len(blends), frames)
+	averaged := AveragedPalette(blends)
+	var downgraded *image.NRGBA
+	if cfg.DitheringAlgo != -1 {
+		dithered, _ := gfx.DoDithering(out, averaged, cfg.DitheringAlgo, cfg.DitheringType, false, cfg.DitheringMatrix, float32(cfg.DitheringMultiplier), filter.PatternAlgorithm(cfg.DitheringPattern), false, cfg.Size, gfx.PaletteAwareDiffusion(cfg, screenMode))
+		_, downgraded = ci.DowngradingWithPalette(dithered, averaged)
+	} else {
+		_, downgraded = ci.DowngradingWithPalette(out, averaged)
+	}
+	data, downgraded, _, err := gfx.TransformDowngraded(downgraded, averaged, cfg, screenMode)
+	return data, downgraded, blends, err
+}
+
+// TemporalFlash saves the screen with the palette of the first frame, the
+// palette of each frame and the loader which switches the palettes.
+func TemporalFlash(in image.Image, cfg *config.MartineConfig, filename, picturePath string, screenMode uint8, frames int) error {
+	if cfg.Overscan || cfg.CpcPlus {
+		return errors.ErrorNotYetImplemented
+	}
+	data, averaged, blends, err := TemporalRaw(in, cfg, screenMode, frames)
+	if err != nil {
+		return err
+	}
+	if err := png.Png(filepath.Join(cfg.OutputPath, filename+"_flash.png"), averaged); err != nil {
+		return err
+	}
+	palettes := FramePalettes(blends, frames)
+	if err := export.Export(picturePath, data, palettes[0], screenMode, cfg); err != nil {
+		return err
+	}
+	for i, p := range palettes {
+		palPath := cfg.AmsdosFullPath(picturePath, fmt.Sprintf("%d.PAL", i+1))
+		if err := ocpartstudio.SavePal(palPath, p, screenMode, cfg.NoAmsdosHeader); err != nil {
+			return err
+		}
+		cfg.AddFile(palPath)
+	}
+	return ocpartstudio.TemporalLoader(picturePath, palettes, screenMode, cfg)
+}
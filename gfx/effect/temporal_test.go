@@ -0,0 +1,57 @@
+package effect
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/jeromelesaux/martine/constants"
+)
+
+func TestBlendedColor(t *testing.T) {
+	b := NewBlendedColor(constants.BrightWhite.Color, constants.Black.Color, constants.White.Color, constants.Blue.Color)
+	// black, blue, white, bright white are cycled as black, white, bright white, blue
+	expected := color.Palette{constants.Black.Color, constants.White.Color, constants.BrightWhite.Color, constants.Blue.Color}
+	for i, c := range b.Inks {
+		if !constants.ColorsAreEquals(c, expected[i]) {
+			t.Fatalf("ink %d expected %v and gets %v", i, expected[i], c)
+		}
+	}
+	grey := NewBlendedColor(constants.Black.Color, constants.BrightWhite.Color)
+	if grey.Color.R != grey.Color.G || grey.Color.R < 0x80 || grey.Color.R > 0xD0 {
+		t.Fatalf("expected a light grey and gets %v", grey.Color)
+	}
+	if grey.Flicker < 0.99 {
+		t.Fatalf("expected the maximum flicker and gets %f", grey.Flicker)
+	}
+}
+
+func TestTemporalPalette(t *testing.T) {
+	for frames := 2; frames <= 4; frames++ {
+		blends := BlendedColors(constants.CpcOldPalette, frames, 0.2)
+		for _, b := range blends {
+			if b.Flicker > 0.2 {
+				t.Fatalf("blended color %v flickers %f", b.Color, b.Flicker)
+			}
+		}
+		if len(blends) <= len(constants.CpcOldPalette) {
+			t.Fatalf("expected more blended colors than cpc colors with %d frames", frames)
+		}
+	}
+	img := image.NewNRGBA(image.Rect(0, 0, 16, 16))
+	for y := 0; y < 16; y++ {
+		for x := 0; x < 16; x++ {
+			img.Set(x, y, color.NRGBA{R: uint8(x * 16), G: uint8(y * 16), B: 0x40, A: 0xff})
+		}
+	}
+	blends := TemporalPalette(img, BlendedColors(constants.CpcOldPalette, 2, DefaultMaxFlicker), 4)
+	if len(blends) != 4 {
+		t.Fatalf("expected 4 blended colors and gets %d", len(blends))
+	}
+	palettes := FramePalettes(blends, 2)
+	for i, b := range blends {
+		if !constants.ColorsAreEquals(palettes[0][i], b.Inks[0]) || !constants.ColorsAreEquals(palettes[1][i], b.Inks[1]) {
+			t.Fatalf("pen %d inks differ from the frame palettes", i)
+		}
+	}
+}
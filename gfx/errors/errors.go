@@ -18,4 +18,16 @@ var (
 	ErrorWidthSizeNotAccepted           = errors.New("width accepted  8 or 16 pixels")
 	ErrorCustomDimensionMustBeSet       = errors.New("you must set custom width and height")
 	ErrorCriteriaNotFound               = errors.New("criteria not found")
+	ErrorFlashFrames                    = errors.New("flash needs between 2 and 4 frames")
+	ErrorNoGradient                     = errors.New("no vertical gradient found in the background")
+	ErrorBadTopology                    = errors.New("unknown delta playback, expected single, double, pingpong or graph")
+	ErrorBadFrameGraph                  = errors.New("bad frame graph, expected at least two frames of the animation (ex 0,1,2,1)")
+	ErrorPlaybackTooLong                = errors.New("the player and its deltas overlap the screen #4000 of the double buffer")
+	ErrorBadCue                         = errors.New("bad cue point, expected frame:name (ex 0:intro,12:drop)")
+	ErrorDeltaMemoryOverflow            = errors.New("the deltas exceed the main memory and the banks of the 6128")
+	ErrorBadAnimation                   = errors.New("bad animation, expected name=frames (ex walk=0-3@5;jump=4,6@3,7)")
+	ErrorBadTiming                      = errors.New("bad frame timing, expected between 1 and 255 vbls for at most 255 frames")
+	ErrorBadHitbox                      = errors.New("bad hitbox, expected x,y,width,height in pixels (ex 2,0,12,16;4,4,8,8)")
+	ErrorBadGeometry                    = errors.New("bad screen geometry, expected the crtc registers R1,R6,R9,R12,R13 (ex 48,34,7,#0D,#00)")
+	ErrorBadColor                       = errors.New("bad color, expected #RRGGBB")
 )
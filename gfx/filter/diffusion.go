@@ -0,0 +1,179 @@
+package filter
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// DiffusionOptions are the settings of the palette aware error diffusion.
+type DiffusionOptions struct {
+	// Multiplier is applied on the quantisation error before its diffusion.
+	Multiplier float32
+	// Serpentine scans the odd lines from right to left.
+	Serpentine bool
+	// Clamp limits the error diffused by channel (0 means no limit).
+	Clamp float32
+	// Weights are the red, green and blue weights used to find the nearest ink.
+	Weights [3]float32
+	// PixelRatio is the width of a pixel for a height of 1 (2 in mode 0).
+	PixelRatio int
+}
+
+// NewDiffusionOptions returns the default options : no serpentine, no clamp
+// and the usual 3,4,2 weighting of the red, green and blue channels.
+func NewDiffusionOptions() DiffusionOptions {
+	return DiffusionOptions{
+		Multiplier: 1,
+		Weights:    [3]float32{3, 4, 2},
+		PixelRatio: 1,
+	}
+}
+
+// PixelRatio returns the width of a pixel for a height of 1 in the screen mode.
+func PixelRatio(mode uint8) int {
+	if mode == 0 {
+		return 2
+	}
+	return 1
+}
+
+// AspectMatrix returns the matrix with the horizontal weights decreased to
+// take care of the pixel ratio, the error goes farther on a wide pixel.
+// The sum of the weights is kept.
+func AspectMatrix(matrix [][]float32, pixelRatio int) [][]float32 {
+	out := make([][]float32, len(matrix))
+	var sum, newSum float32
+	for dy, row := range matrix {
+		out[dy] = make([]float32, len(row))
+		center := (len(row) - 1) / 2
+		for i, v := range row {
+			sum += v
+			dx := float64(i - center)
+			if dx == 0 || pixelRatio <= 1 {
+				out[dy][i] = v
+			} else {
+				square := math.Sqrt(dx*dx + float64(dy*dy))
+				wide := math.Sqrt(dx*dx*float64(pixelRatio*pixelRatio) + float64(dy*dy))
+				out[dy][i] = v * float32(square/wide)
+			}
+			newSum += out[dy][i]
+		}
+	}
+	if newSum == 0 {
+		return out
+	}
+	for _, row := range out {
+		for i := range row {
+			row[i] *= sum / newSum
+		}
+	}
+	return out
+}
+
+// PaletteDithering diffuses the error of the matrix while quantising each pixel
+// to the nearest ink of the palette. The output only contains the palette colors.
+func PaletteDithering(input *image.NRGBA, matrix [][]float32, p color.Palette, opts DiffusionOptions) *image.NRGBA {
+	b := input.Bounds()
+	width, height := b.Dx(), b.Dy()
+	out := image.NewNRGBA(image.Rect(0, 0, width, height))
+	if len(p) == 0 || len(matrix) == 0 {
+		return out
+	}
+	if opts.PixelRatio > 1 {
+		matrix = AspectMatrix(matrix, opts.PixelRatio)
+	}
+	weights := opts.Weights
+	if weights == [3]float32{} {
+		weights = [3]float32{1, 1, 1}
+	}
+
+	inks := make([][3]float32, len(p))
+	for i, c := range p {
+		r, g, bl, _ := c.RGBA()
+		inks[i] = [3]float32{float32(r >> 8), float32(g >> 8), float32(bl >> 8)}
+	}
+
+	buf := make([][3]float32, width*height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			c := input.NRGBAAt(b.Min.X+x, b.Min.Y+y)
+			buf[y*width+x] = [3]float32{float32(c.R), float32(c.G), float32(c.B)}
+		}
+	}
+
+	for y := 0; y < height; y++ {
+		reverse := opts.Serpentine && y%2 == 1
+		for i := 0; i < width; i++ {
+			x := i
+			if reverse {
+				x = width - 1 - i
+			}
+			v := buf[y*width+x]
+			for c := 0; c < 3; c++ {
+				v[c] = clamp(v[c], 0, 255)
+			}
+			ink := nearestInk(v, inks, weights)
+			out.Set(x, y, p[ink])
+
+			var diff [3]float32
+			for c := 0; c < 3; c++ {
+				diff[c] = (v[c] - inks[ink][c]) * opts.Multiplier
+				if opts.Clamp > 0 {
+					diff[c] = clamp(diff[c], -opts.Clamp, opts.Clamp)
+				}
+			}
+			for dy, row := range matrix {
+				center := (len(row) - 1) / 2
+				ny := y + dy
+				if ny >= height {
+					break
+				}
+				for j, w := range row {
+					if w == 0 {
+						continue
+					}
+					dx := j - center
+					if reverse {
+						dx = -dx
+					}
+					nx := x + dx
+					if nx < 0 || nx >= width {
+						continue
+					}
+					for c := 0; c < 3; c++ {
+						buf[ny*width+nx][c] += diff[c] * w
+					}
+				}
+			}
+		}
+	}
+	return out
+}
+
+func nearestInk(v [3]float32, inks [][3]float32, weights [3]float32) int {
+	index := 0
+	best := float32(math.MaxFloat32)
+	for i, ink := range inks {
+		var d float32
+		for c := 0; c < 3; c++ {
+			e := v[c] - ink[c]
+			d += weights[c] * e * e
+		}
+		if d < best {
+			best = d
+			index = i
+		}
+	}
+	return index
+}
+
+func clamp(v, min, max float32) float32 {
+	if v < min {
+		return min
+	}
+	if v > max {
+		return max
+	}
+	return v
+}
@@ -0,0 +1,73 @@
+package filter
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+var blackAndWhite = color.Palette{
+	color.NRGBA{A: 0xff},
+	color.NRGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff},
+}
+
+func grey(width, height int, v uint8) *image.NRGBA {
+	in := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			in.Set(x, y, color.NRGBA{R: v, G: v, B: v, A: 0xff})
+		}
+	}
+	return in
+}
+
+func TestPaletteDithering(t *testing.T) {
+	in := grey(32, 32, 0x80)
+	out := PaletteDithering(in, FloydSteinberg, blackAndWhite, NewDiffusionOptions())
+	var white int
+	for y := 0; y < 32; y++ {
+		for x := 0; x < 32; x++ {
+			c := out.NRGBAAt(x, y)
+			switch c {
+			case blackAndWhite[0]:
+			case blackAndWhite[1]:
+				white++
+			default:
+				t.Fatalf("expected only palette colors and gets %v at (%d,%d)", c, x, y)
+			}
+		}
+	}
+	if white < 480 || white > 544 {
+		t.Fatalf("expected half of the pixels in white and gets %d on 1024", white)
+	}
+}
+
+func TestSerpentineDithering(t *testing.T) {
+	in := grey(16, 16, 0x50)
+	opts := NewDiffusionOptions()
+	raster := PaletteDithering(in, FloydSteinberg, blackAndWhite, opts)
+	opts.Serpentine = true
+	serpentine := PaletteDithering(in, FloydSteinberg, blackAndWhite, opts)
+	if string(raster.Pix) == string(serpentine.Pix) {
+		t.Fatalf("expected a different pattern with the serpentine scanning")
+	}
+}
+
+func TestAspectMatrix(t *testing.T) {
+	m := AspectMatrix(FloydSteinberg, 2)
+	var sum float32
+	for _, row := range m {
+		for _, v := range row {
+			sum += v
+		}
+	}
+	if sum < 0.999 || sum > 1.001 {
+		t.Fatalf("expected the sum of the weights kept and gets %f", sum)
+	}
+	if m[0][2] >= FloydSteinberg[0][2] {
+		t.Fatalf("expected a lower horizontal weight and gets %f", m[0][2])
+	}
+	if m[1][1] <= FloydSteinberg[1][1] {
+		t.Fatalf("expected a higher vertical weight and gets %f", m[1][1])
+	}
+}
@@ -9,7 +9,6 @@ import (
 
 	"github.com/esimov/colorquant"
 	dither "github.com/esimov/dithergo"
-	"github.com/jeromelesaux/martine/proc"
 )
 
 var (
@@ -78,41 +77,11 @@ type MixingPlan struct {
 }
 
 // https://bisqwit.iki.fi/story/howto/dither/jy/#Algorithms
+// BayerDiphering applies the Yliluoma 1 positional dithering with the matrix
+// on the colors of the palette.
 func BayerDiphering(input *image.NRGBA, filter [][]float32, palette color.Palette) *image.NRGBA {
-	image2 := image.NewNRGBA(image.Rectangle{image.Point{0, 0}, image.Point{input.Bounds().Max.X, input.Bounds().Max.Y}})
-	height := image2.Bounds().Max.Y
-	width := image2.Bounds().Max.X
-	filterRowLenght := len(filter[0]) - 1
-	filterLenght := len(filter[0]) * len(filter[0])
 	fmt.Fprintf(os.Stdout, "Palette length used in Bayer dithering %d\n", len(palette))
-	pal := InitPalWithPalette(palette)
-
-	proc.Parallel(0, height, func(yc <-chan int) {
-		for y := range yc {
-			for x := 0; x < width; x++ {
-				temp := input.At(x, y)
-				color := qColorToint(temp)
-				plan := DeviseBestMixingPlan(color, pal, uint(filterLenght))
-				if plan.Ratio == 4.0 { // Tri-tone or quad-tone dithering
-					color := rgbToQColor(plan.Colors[((y&1)*2 + (x & 1))])
-					image2.Set(x, y, color)
-				} else {
-					//fmt.Fprintf(os.Stderr,"(%d,%d):(%d)(%d)\n",x,y,(x & filterRowLenght),((y & filterRowLenght) << 3))
-					mapValue := filter[(x & filterRowLenght)][(y&filterRowLenght)] / float32(filterLenght)
-					planIndex := 0
-					if mapValue < plan.Ratio {
-						planIndex = 1
-					}
-					color := rgbToQColor(plan.Colors[planIndex])
-					image2.Set(x, y, color)
-				}
-			}
-			//fmt.Fprintf(os.Stdout,"Analyse done for column %d\n",y)
-			fmt.Fprintf(os.Stdout, ".")
-		}
-	})
-	fmt.Fprintf(os.Stdout, "\n")
-	return image2
+	return PositionalDithering(input, Yliluoma1, filter, palette)
 }
 
 func InitPal() [216]uint {
@@ -140,74 +109,60 @@ func InitPalWithPalette(p color.Palette) []uint {
 	return pal
 }
 
+// DeviseBestMixingPlan returns the two colors of the palette and their ratio
+// (in matrixLenght steps) which give the nearest color by mixing, or three
+// colors with the ratio 4 for a tri-tone dithering (50%, 25%, 25%).
 func DeviseBestMixingPlan(color uint, pal []uint, matrixLenght uint) MixingPlan {
-	r := color >> 16
-	g := (color >> 8) & 0xFF
-	b := color & 0xFF
+	r, g, b := splitRgb(color)
 	result := MixingPlan{Colors: [4]uint{0, 0, 0, 0}, Ratio: 0.5}
-	var leastPenalty float64 = 1e99
+	leastPenalty := 1e99
+	steps := int(matrixLenght)
+	if steps < 2 {
+		steps = 2
+	}
 
 	for index1 := 0; index1 < len(pal); index1++ {
 		for index2 := index1; index2 < len(pal); index2++ {
 			// Determine the two component colors
-			color1 := pal[index1]
-			color2 := pal[index2]
-			r1 := color1 >> 16
-			g1 := (color1 >> 8) & 0xFF
-			b1 := color1 & 0xFF
-			r2 := color2 >> 16
-			g2 := (color2 >> 8) & 0xFF
-			b2 := color2 & 0xFF
-			var ratio uint = 32
-			if color1 != color2 {
+			r1, g1, b1 := splitRgb(pal[index1])
+			r2, g2, b2 := splitRgb(pal[index2])
+			ratio := steps / 2
+			if pal[index1] != pal[index2] {
 				// Determine the ratio of mixing for each channel.
-				//   solve(r1 + ratio*(r2-r1)/64 = r, ratio)
+				//   solve(r1 + ratio*(r2-r1)/steps = r, ratio)
 				// Take a weighed average of these three ratios according to the
 				// perceived luminosity of each channel (according to CCIR 601).
-				var cr0 uint
-				var cr1 uint
+				var num, den int
 				if r2 != r1 {
-					cr0 = 299 * uint(len(pal)) * (r - r1) / (r2 - r1)
-					cr1 = 299
+					num += 299 * steps * (r - r1) / (r2 - r1)
+					den += 299
 				}
-				var cg0 uint
-				var cg1 uint
 				if g2 != g1 {
-					cg0 = 587 * uint(len(pal)) * (g - g1) / (g2 - g1)
-					cg1 = 587
+					num += 587 * steps * (g - g1) / (g2 - g1)
+					den += 587
 				}
-				var cb0 uint
-				var cb1 uint
-				if b1 != b2 {
-					cb0 = 114 * uint(len(pal)) * (b - b1) / (b2 - b1)
-					cb1 = 114
+				if b2 != b1 {
+					num += 114 * steps * (b - b1) / (b2 - b1)
+					den += 114
 				}
-
-				ratio = (cr0 + cg0 + cb0) / (cr1 + cg1 + cb1)
-
-				/*ratio = ((r2 != r1 ? 299 * 64 * int(r - r1) / int(r2 - r1) : 0)
-				                            + (g2 != g1 ? 587 * 64 * int(g - g1) / int(g2 - g1) : 0)
-				                            + (b1 != b2 ? 114 * 64 * int(b - b1) / int(b2 - b1) : 0))
-				                    / ((r2 != r1 ? 299 : 0)
-				                            + (g2 != g1 ? 587 : 0)
-											+ (b2 != b1 ? 114 : 0));
-				*/
-
-				if ratio > (matrixLenght - 1) {
-					ratio = matrixLenght - 1
+				ratio = num / den
+				if ratio < 0 {
+					ratio = 0
+				}
+				if ratio > steps-1 {
+					ratio = steps - 1
 				}
-
 			}
 			// Determine what mixing them in this proportion will produce
-			r0 := r1 + ratio*(r2-r1)/matrixLenght
-			g0 := g1 + ratio*(g2-g1)/matrixLenght
-			b0 := b1 + ratio*(b2-b1)/matrixLenght
-			penalty := EvaluateMixingError(r, g, b, r0, g0, b0, r1, g1, b1, r2, g2, b2, float64(ratio)/float64(matrixLenght))
+			r0 := r1 + ratio*(r2-r1)/steps
+			g0 := g1 + ratio*(g2-g1)/steps
+			b0 := b1 + ratio*(b2-b1)/steps
+			penalty := EvaluateMixingError(r, g, b, r0, g0, b0, r1, g1, b1, r2, g2, b2, float64(ratio)/float64(steps))
 			if penalty < leastPenalty {
 				leastPenalty = penalty
 				result.Colors[0] = pal[index1]
 				result.Colors[1] = pal[index2]
-				result.Ratio = float32(ratio) / float32(matrixLenght)
+				result.Ratio = float32(ratio) / float32(steps)
 			}
 			if index1 != index2 {
 				for index3 := 0; index3 < len(pal); index3++ {
@@ -215,14 +170,11 @@ func DeviseBestMixingPlan(color uint, pal []uint, matrixLenght uint) MixingPlan
 						continue
 					}
 					// 50% index3, 25% index2, 25% index1
-					color3 := pal[index3]
-					r3 := color3 >> 16
-					g3 := (color3 >> 8) & 0xFF
-					b3 := color3 & 0xFF
+					r3, g3, b3 := splitRgb(pal[index3])
 					r0 := (r1 + r2 + r3*2) / 4
 					g0 := (g1 + g2 + g3*2) / 4
 					b0 := (b1 + b2 + b3*2) / 4
-					penalty = ColorCompare(r, g, b, r0, g0, b0) + ColorCompare(r1, g1, b1, r2, g2, b2)*0.025 + ColorCompare((r1+g1)/2, (g1+g2)/2, (b1+b2)/2, r3, g3, b3)*0.025
+					penalty = ColorCompare(r, g, b, r0, g0, b0) + ColorCompare(r1, g1, b1, r2, g2, b2)*0.025 + ColorCompare((r1+r2)/2, (g1+g2)/2, (b1+b2)/2, r3, g3, b3)*0.025
 					if penalty < leastPenalty {
 						leastPenalty = penalty
 						result.Colors[0] = pal[index3] // (0,0) index3 occurs twice
@@ -238,7 +190,7 @@ func DeviseBestMixingPlan(color uint, pal []uint, matrixLenght uint) MixingPlan
 	return result
 }
 
-func EvaluateMixingError(r, g, b, r0, g0, b0, r1, g1, b1, r2, g2, b2 uint, ratio float64) float64 {
+func EvaluateMixingError(r, g, b, r0, g0, b0, r1, g1, b1, r2, g2, b2 int, ratio float64) float64 {
 	abs := ratio - 0.5
 	if abs < 0 {
 		abs = -abs
@@ -246,16 +198,21 @@ func EvaluateMixingError(r, g, b, r0, g0, b0, r1, g1, b1, r2, g2, b2 uint, ratio
 	return ColorCompare(r, g, b, r0, g0, b0) + ColorCompare(r1, g1, b1, r2, g2, b2)*0.1*(abs+0.5)
 }
 
-func ColorCompare(r1, g1, b1, r2, g2, b2 uint) float64 {
-
-	luma1 := float64((r1*299 + g1*587 + b1*114) / (255.0 * 1000))
-	luma2 := float64((r2*299 + g2*587 + b2*114) / (255.0 * 1000))
+// ColorCompare returns the psychovisual distance between two colors.
+func ColorCompare(r1, g1, b1, r2, g2, b2 int) float64 {
+	luma1 := float64(r1*299+g1*587+b1*114) / (255.0 * 1000)
+	luma2 := float64(r2*299+g2*587+b2*114) / (255.0 * 1000)
 	lumadiff := luma1 - luma2
 	diffR := float64(r1-r2) / 255.0
 	diffG := float64(g1-g2) / 255.0
 	diffB := float64(b1-b2) / 255.0
 	return (diffR*diffR*0.299+diffG*diffG*0.587+diffB*diffB*0.114)*0.75 + lumadiff*lumadiff
 }
+
+func splitRgb(v uint) (int, int, int) {
+	return int(v >> 16), int((v >> 8) & 0xFF), int(v & 0xFF)
+}
+
 func rgbToQColor(v uint) color.Color {
 	r := v >> 16
 	g := (v >> 8) & 0xFF
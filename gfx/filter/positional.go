@@ -0,0 +1,412 @@
+package filter
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+
+	"github.com/jeromelesaux/martine/proc"
+)
+
+// PatternAlgorithm is the way a positional dithering mixes the inks.
+type PatternAlgorithm int
+
+var (
+	// Yliluoma1 mixes two inks (or three for tri-tone) with a ratio.
+	Yliluoma1 PatternAlgorithm = 0
+	// Yliluoma2 adds one by one the inks giving the nearest mean color.
+	Yliluoma2 PatternAlgorithm = 1
+	// Yliluoma3 adds the inks by groups and mixes them in linear light.
+	Yliluoma3 PatternAlgorithm = 2
+	// Knoll chooses the inks with an error accumulator (Thomas Knoll pattern dithering).
+	Knoll PatternAlgorithm = 3
+)
+
+var (
+	// BlueNoise16 is a 16x16 blue noise threshold map (best candidate points).
+	BlueNoise16 = BlueNoise(16)
+	// VoidAndCluster16 is a 16x16 void and cluster threshold map.
+	VoidAndCluster16 = VoidAndCluster(16)
+)
+
+// maxPlanSize is the maximum number of inks in a mixing plan.
+const maxPlanSize = 64
+
+// PositionalDithering dithers the image with the inks of the palette, the
+// threshold map (a rank per cell as the Bayer matrices) selects the ink of
+// the mixing plan of each pixel. A color gets always the same ink at the same
+// position, so the animations keep a stable pattern between the frames.
+func PositionalDithering(input *image.NRGBA, algo PatternAlgorithm, thresholdMap [][]float32, p color.Palette) *image.NRGBA {
+	return PositionalDitheringAt(input, algo, thresholdMap, p, image.Point{})
+}
+
+// PositionalDitheringAt dithers the image displayed at the offset (in pixels)
+// of the screen, the threshold map stays aligned on the screen.
+func PositionalDitheringAt(input *image.NRGBA, algo PatternAlgorithm, thresholdMap [][]float32, p color.Palette, offset image.Point) *image.NRGBA {
+	b := input.Bounds()
+	out := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	if len(p) == 0 || len(thresholdMap) == 0 || len(thresholdMap[0]) == 0 {
+		return out
+	}
+	thresholds := NormalizeThresholdMap(thresholdMap)
+	rows, cols := len(thresholds), len(thresholds[0])
+	size := rows * cols
+	if size > maxPlanSize {
+		size = maxPlanSize
+	}
+	inks := make([]rgb, len(p))
+	for i, c := range p {
+		inks[i] = newRgb(c)
+	}
+
+	var mu sync.Mutex
+	plans := make(map[uint][]int)
+	plan := func(c uint) []int {
+		mu.Lock()
+		v, ok := plans[c]
+		mu.Unlock()
+		if ok {
+			return v
+		}
+		v = devisePlan(algo, c, inks, size)
+		mu.Lock()
+		plans[c] = v
+		mu.Unlock()
+		return v
+	}
+
+	proc.Parallel(0, b.Dy(), func(yc <-chan int) {
+		for y := range yc {
+			for x := 0; x < b.Dx(); x++ {
+				pl := plan(qColorToint(input.NRGBAAt(b.Min.X+x, b.Min.Y+y)))
+				t := thresholds[(y+offset.Y)%rows][(x+offset.X)%cols]
+				out.Set(x, y, p[pl[int(t*float32(len(pl)))]])
+			}
+		}
+	})
+	return out
+}
+
+// NormalizeThresholdMap returns the threshold map with values in [0,1).
+func NormalizeThresholdMap(m [][]float32) [][]float32 {
+	cells := 0
+	for _, row := range m {
+		cells += len(row)
+	}
+	out := make([][]float32, len(m))
+	for y, row := range m {
+		out[y] = make([]float32, len(row))
+		for x, v := range row {
+			t := (v + 0.5) / float32(cells)
+			if t >= 1 {
+				t = float32(cells-1) / float32(cells)
+			}
+			if t < 0 {
+				t = 0
+			}
+			out[y][x] = t
+		}
+	}
+	return out
+}
+
+type rgb [3]float64
+
+func newRgb(c color.Color) rgb {
+	r, g, b, _ := c.RGBA()
+	return rgb{float64(r >> 8), float64(g >> 8), float64(b >> 8)}
+}
+
+func (c rgb) luma() float64 {
+	return c[0]*299 + c[1]*587 + c[2]*114
+}
+
+func (c rgb) compare(o rgb) float64 {
+	return ColorCompare(int(c[0]), int(c[1]), int(c[2]), int(o[0]), int(o[1]), int(o[2]))
+}
+
+func gammaToLinear(v float64) float64 {
+	return math.Pow(v/255., 2.2)
+}
+
+func linearToGamma(v float64) float64 {
+	return math.Pow(v, 1/2.2) * 255.
+}
+
+// devisePlan returns the inks of the plan sorted by luminance.
+func devisePlan(algo PatternAlgorithm, c uint, inks []rgb, size int) []int {
+	r, g, b := splitRgb(c)
+	target := rgb{float64(r), float64(g), float64(b)}
+	var plan []int
+	switch algo {
+	case Yliluoma2:
+		plan = yliluoma2Plan(target, inks, size)
+	case Yliluoma3:
+		plan = yliluoma3Plan(target, inks, size)
+	case Knoll:
+		plan = knollPlan(target, inks, size)
+	default:
+		plan = yliluoma1Plan(c, inks, size)
+	}
+	sort.SliceStable(plan, func(i, j int) bool {
+		return inks[plan[i]].luma() < inks[plan[j]].luma()
+	})
+	return plan
+}
+
+func yliluoma1Plan(c uint, inks []rgb, size int) []int {
+	pal := make([]uint, len(inks))
+	index := make(map[uint]int)
+	for i := len(inks) - 1; i >= 0; i-- {
+		pal[i] = uint(inks[i][0])<<16 | uint(inks[i][1])<<8 | uint(inks[i][2])
+		index[pal[i]] = i
+	}
+	mp := DeviseBestMixingPlan(c, pal, uint(size))
+	if mp.Ratio == 4.0 {
+		return []int{index[mp.Colors[0]], index[mp.Colors[1]], index[mp.Colors[2]], index[mp.Colors[3]]}
+	}
+	plan := make([]int, size)
+	second := int(mp.Ratio*float32(size) + 0.5)
+	for i := range plan {
+		plan[i] = index[mp.Colors[0]]
+		if i < second {
+			plan[i] = index[mp.Colors[1]]
+		}
+	}
+	return plan
+}
+
+func yliluoma2Plan(target rgb, inks []rgb, size int) []int {
+	plan := make([]int, 0, size)
+	var sum rgb
+	for len(plan) < size {
+		best, chosen := math.MaxFloat64, 0
+		n := float64(len(plan) + 1)
+		for i, ink := range inks {
+			mean := rgb{(sum[0] + ink[0]) / n, (sum[1] + ink[1]) / n, (sum[2] + ink[2]) / n}
+			if penalty := target.compare(mean); penalty < best {
+				best, chosen = penalty, i
+			}
+		}
+		plan = append(plan, chosen)
+		for c := 0; c < 3; c++ {
+			sum[c] += inks[chosen][c]
+		}
+	}
+	return plan
+}
+
+func yliluoma3Plan(target rgb, inks []rgb, size int) []int {
+	linear := make([]rgb, len(inks))
+	for i, ink := range inks {
+		linear[i] = rgb{gammaToLinear(ink[0]), gammaToLinear(ink[1]), gammaToLinear(ink[2])}
+	}
+	plan := make([]int, 0, size)
+	var sum rgb
+	for len(plan) < size {
+		maxCount := len(plan)
+		if maxCount == 0 {
+			maxCount = 1
+		}
+		best, chosen, chosenCount := math.MaxFloat64, 0, 1
+		for i, ink := range linear {
+			// the ink is tried once, twice, four times ... as long as the plan size allows it
+			for count := 1; count <= maxCount && len(plan)+count <= size; count *= 2 {
+				n := float64(len(plan) + count)
+				k := float64(count)
+				mean := rgb{
+					linearToGamma((sum[0] + ink[0]*k) / n),
+					linearToGamma((sum[1] + ink[1]*k) / n),
+					linearToGamma((sum[2] + ink[2]*k) / n),
+				}
+				if penalty := target.compare(mean); penalty < best {
+					best, chosen, chosenCount = penalty, i, count
+				}
+			}
+		}
+		for ; chosenCount > 0; chosenCount-- {
+			plan = append(plan, chosen)
+			for c := 0; c < 3; c++ {
+				sum[c] += linear[chosen][c]
+			}
+		}
+	}
+	return plan
+}
+
+func knollPlan(target rgb, inks []rgb, size int) []int {
+	plan := make([]int, size)
+	var e rgb
+	for i := range plan {
+		attempt := rgb{target[0] + e[0], target[1] + e[1], target[2] + e[2]}
+		best, chosen := math.MaxFloat64, 0
+		for j, ink := range inks {
+			if penalty := attempt.compare(ink); penalty < best {
+				best, chosen = penalty, j
+			}
+		}
+		plan[i] = chosen
+		for c := 0; c < 3; c++ {
+			e[c] += target[c] - inks[chosen][c]
+		}
+	}
+	return plan
+}
+
+// BlueNoise returns a size x size threshold map, the cells are ranked in the
+// order of a best candidate sampling (each new point is the farthest of a few
+// random candidates from the points already placed).
+func BlueNoise(size int) [][]float32 {
+	m := newThresholdMap(size)
+	r := rand.New(rand.NewSource(1))
+	placed := make([]bool, size*size)
+	points := make([]int, 0, size*size)
+	for rank := 0; rank < size*size; rank++ {
+		best, chosen := -1, -1
+		for k := 0; k < 2*len(points)+1; k++ {
+			candidate := r.Intn(size * size)
+			for placed[candidate] {
+				candidate = (candidate + 1) % (size * size)
+			}
+			d := math.MaxInt32
+			for _, p := range points {
+				if v := toroidalDistance(candidate, p, size); v < d {
+					d = v
+				}
+			}
+			if d > best {
+				best, chosen = d, candidate
+			}
+		}
+		placed[chosen] = true
+		points = append(points, chosen)
+		m[chosen/size][chosen%size] = float32(rank)
+	}
+	return m
+}
+
+// VoidAndCluster returns a size x size threshold map computed with the
+// Ulichney void and cluster algorithm.
+func VoidAndCluster(size int) [][]float32 {
+	n := size * size
+	const sigma = 1.5
+	gaussian := make([]float64, n)
+	for i := range gaussian {
+		gaussian[i] = math.Exp(-float64(toroidalDistance(0, i, size)) / (2 * sigma * sigma))
+	}
+	energy := func(pattern []bool) []float64 {
+		e := make([]float64, n)
+		for i, v := range pattern {
+			if v {
+				addEnergy(e, i, size, gaussian, 1)
+			}
+		}
+		return e
+	}
+	extreme := func(pattern []bool, e []float64, value bool, max bool) int {
+		index := -1
+		for i, v := range pattern {
+			if v != value {
+				continue
+			}
+			if index == -1 || (max && e[i] > e[index]) || (!max && e[i] < e[index]) {
+				index = i
+			}
+		}
+		return index
+	}
+
+	// initial binary pattern with 10% of the cells, then the clusters are
+	// moved in the voids until the pattern is homogeneous
+	r := rand.New(rand.NewSource(1))
+	initial := make([]bool, n)
+	ones := n / 10
+	if ones < 1 {
+		ones = 1
+	}
+	for count := 0; count < ones; {
+		i := r.Intn(n)
+		if !initial[i] {
+			initial[i] = true
+			count++
+		}
+	}
+	e := energy(initial)
+	for iteration := 0; iteration < n*n; iteration++ {
+		cluster := extreme(initial, e, true, true)
+		initial[cluster] = false
+		addEnergy(e, cluster, size, gaussian, -1)
+		void := extreme(initial, e, false, false)
+		initial[void] = true
+		addEnergy(e, void, size, gaussian, 1)
+		if void == cluster {
+			break
+		}
+	}
+
+	ranks := make([]int, n)
+	// phase 1 : the tightest clusters of the initial pattern get the lowest ranks
+	pattern := append([]bool{}, initial...)
+	e = energy(pattern)
+	for rank := ones - 1; rank >= 0; rank-- {
+		cluster := extreme(pattern, e, true, true)
+		pattern[cluster] = false
+		addEnergy(e, cluster, size, gaussian, -1)
+		ranks[cluster] = rank
+	}
+	// phase 2 and 3 : the largest voids are filled
+	pattern = append([]bool{}, initial...)
+	e = energy(pattern)
+	for rank := ones; rank < n; rank++ {
+		void := extreme(pattern, e, false, false)
+		pattern[void] = true
+		addEnergy(e, void, size, gaussian, 1)
+		ranks[void] = rank
+	}
+
+	m := newThresholdMap(size)
+	for i, rank := range ranks {
+		m[i/size][i%size] = float32(rank)
+	}
+	return m
+}
+
+func newThresholdMap(size int) [][]float32 {
+	m := make([][]float32, size)
+	for i := range m {
+		m[i] = make([]float32, size)
+	}
+	return m
+}
+
+func addEnergy(e []float64, index, size int, gaussian []float64, sign float64) {
+	x0, y0 := index%size, index/size
+	for i := range e {
+		dx := (i%size - x0 + size) % size
+		dy := (i/size - y0 + size) % size
+		e[i] += sign * gaussian[dy*size+dx]
+	}
+}
+
+// toroidalDistance returns the squared distance between two cells of a
+// size x size map wrapped on its borders.
+func toroidalDistance(a, b, size int) int {
+	dx := a%size - b%size
+	if dx < 0 {
+		dx = -dx
+	}
+	if dx > size/2 {
+		dx = size - dx
+	}
+	dy := a/size - b/size
+	if dy < 0 {
+		dy = -dy
+	}
+	if dy > size/2 {
+		dy = size - dy
+	}
+	return dx*dx + dy*dy
+}
@@ -0,0 +1,72 @@
+package filter
+
+import (
+	"image/color"
+	"testing"
+)
+
+func TestPositionalDithering(t *testing.T) {
+	in := grey(16, 16, 0x80)
+	for _, algo := range []PatternAlgorithm{Yliluoma1, Yliluoma2, Yliluoma3, Knoll} {
+		out := PositionalDithering(in, algo, Bayer4, blackAndWhite)
+		var white int
+		for y := 0; y < 16; y++ {
+			for x := 0; x < 16; x++ {
+				c := out.NRGBAAt(x, y)
+				switch c {
+				case blackAndWhite[0]:
+				case blackAndWhite[1]:
+					white++
+				default:
+					t.Fatalf("algorithm %d: expected only palette colors and gets %v at (%d,%d)", algo, c, x, y)
+				}
+			}
+		}
+		if white == 0 || white == 256 {
+			t.Fatalf("algorithm %d: expected a mix of black and white and gets %d white pixels", algo, white)
+		}
+	}
+}
+
+func TestPositionalDitheringIsStable(t *testing.T) {
+	frame0 := grey(16, 16, 0x60)
+	frame1 := grey(16, 16, 0x60)
+	frame1.Set(3, 3, color.NRGBA{R: 0xff, A: 0xff})
+	out0 := PositionalDithering(frame0, Yliluoma2, VoidAndCluster16, blackAndWhite)
+	out1 := PositionalDithering(frame1, Yliluoma2, VoidAndCluster16, blackAndWhite)
+	for y := 0; y < 16; y++ {
+		for x := 0; x < 16; x++ {
+			if x == 3 && y == 3 {
+				continue
+			}
+			if out0.NRGBAAt(x, y) != out1.NRGBAAt(x, y) {
+				t.Fatalf("expected the same pattern outside the changed pixel, differs at (%d,%d)", x, y)
+			}
+		}
+	}
+}
+
+func TestThresholdMaps(t *testing.T) {
+	for name, m := range map[string][][]float32{"BlueNoise16": BlueNoise16, "VoidAndCluster16": VoidAndCluster16} {
+		ranks := make(map[float32]bool)
+		for _, row := range m {
+			for _, v := range row {
+				ranks[v] = true
+			}
+		}
+		if len(ranks) != 256 {
+			t.Fatalf("%s: expected 256 distinct ranks and gets %d", name, len(ranks))
+		}
+	}
+}
+
+func TestDeviseBestMixingPlan(t *testing.T) {
+	pal := InitPalWithPalette(blackAndWhite)
+	plan := DeviseBestMixingPlan(0x808080, pal, 16)
+	if plan.Colors[0] == plan.Colors[1] {
+		t.Fatalf("expected a mix of two colors and gets %x", plan.Colors[0])
+	}
+	if plan.Ratio < 0.4 || plan.Ratio > 0.6 {
+		t.Fatalf("expected a ratio near 0.5 and gets %f", plan.Ratio)
+	}
+}
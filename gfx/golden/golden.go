@@ -0,0 +1,234 @@
+// Package golden compares the files produced by a conversion with a set of
+// expected files (goldens). The binary files (SCR, PAL, WIN...) are compared
+// byte by byte and the rendered PNG files with a perceptual distance, so a
+// change in the dithering or in the palette code is reported with its visual
+// impact.
+package golden
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	_ "image/png"
+
+	"github.com/jeromelesaux/martine/constants"
+)
+
+var (
+	ErrorGoldenMissing = errors.New("golden file missing")
+	ErrorFileMissing   = errors.New("expected file not generated")
+)
+
+// Diff describes the differences between a golden file and the generated one.
+type Diff struct {
+	File  string
+	Err   error
+	Size  int // size of the generated file
+	Bytes int // number of bytes differing
+	First int // offset of the first byte differing
+	// perceptual diff for the images
+	Pixels       int // number of pixels differing
+	Total        int // number of pixels in the image
+	MeanDistance float64
+	MaxDistance  float64
+}
+
+func (d Diff) String() string {
+	if d.Err != nil {
+		return fmt.Sprintf("%s : %v", d.File, d.Err)
+	}
+	if d.Total > 0 {
+		return fmt.Sprintf("%s : %d/%d pixels differ (%.2f%%), mean distance %.2f, max distance %.2f",
+			d.File, d.Pixels, d.Total, float64(d.Pixels)*100./float64(d.Total), d.MeanDistance, d.MaxDistance)
+	}
+	return fmt.Sprintf("%s : %d bytes differ (size %d), first at offset #%.4X", d.File, d.Bytes, d.Size, d.First)
+}
+
+// IsImage returns true if the file is compared with the perceptual distance.
+func IsImage(file string) bool {
+	return strings.EqualFold(filepath.Ext(file), ".png")
+}
+
+// Files returns the files of the folder (relative paths) sorted by name.
+func Files(folder string) ([]string, error) {
+	files := make([]string, 0)
+	err := filepath.Walk(folder, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(folder, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, rel)
+		return nil
+	})
+	sort.Strings(files)
+	return files, err
+}
+
+// Compare checks the files generated in the folder actual against the goldens
+// of the folder expected and returns the files which differ.
+func Compare(expected, actual string) ([]Diff, error) {
+	diffs := make([]Diff, 0)
+	goldens, err := Files(expected)
+	if err != nil {
+		return diffs, err
+	}
+	generated, err := Files(actual)
+	if err != nil {
+		return diffs, err
+	}
+	for _, v := range generated {
+		if !contains(goldens, v) {
+			diffs = append(diffs, Diff{File: v, Err: ErrorGoldenMissing})
+		}
+	}
+	for _, v := range goldens {
+		if !contains(generated, v) {
+			diffs = append(diffs, Diff{File: v, Err: ErrorFileMissing})
+			continue
+		}
+		d, err := CompareFile(filepath.Join(expected, v), filepath.Join(actual, v))
+		if err != nil {
+			return diffs, err
+		}
+		if d != nil {
+			d.File = v
+			diffs = append(diffs, *d)
+		}
+	}
+	return diffs, nil
+}
+
+// CompareFile returns nil if the both files are the same.
+func CompareFile(expected, actual string) (*Diff, error) {
+	b0, err := os.ReadFile(expected)
+	if err != nil {
+		return nil, err
+	}
+	b1, err := os.ReadFile(actual)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.Equal(b0, b1) {
+		return nil, nil
+	}
+	if IsImage(expected) {
+		img0, _, err := image.Decode(bytes.NewReader(b0))
+		if err != nil {
+			return nil, err
+		}
+		img1, _, err := image.Decode(bytes.NewReader(b1))
+		if err != nil {
+			return nil, err
+		}
+		d := ImageDiff(img0, img1)
+		if d.Pixels == 0 {
+			// same pixels, only the encoding differs
+			return nil, nil
+		}
+		return &d, nil
+	}
+	d := BytesDiff(b0, b1)
+	return &d, nil
+}
+
+// BytesDiff counts the bytes differing between the both buffers.
+func BytesDiff(b0, b1 []byte) Diff {
+	d := Diff{Size: len(b1), First: -1}
+	length := len(b0)
+	if len(b1) > length {
+		length = len(b1)
+	}
+	for i := 0; i < length; i++ {
+		if i < len(b0) && i < len(b1) && b0[i] == b1[i] {
+			continue
+		}
+		if d.First == -1 {
+			d.First = i
+		}
+		d.Bytes++
+	}
+	return d
+}
+
+// ImageDiff computes the perceptual distance (constants.ColorsDistance, in
+// percent of the maximum distance) pixel by pixel, the pixels outside one of
+// the images count as the maximum distance.
+func ImageDiff(img0, img1 image.Image) Diff {
+	var d Diff
+	r := img0.Bounds().Union(img1.Bounds())
+	d.Total = r.Dx() * r.Dy()
+	var sum float64
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			p := image.Point{X: x, Y: y}
+			var distance float64
+			if !p.In(img0.Bounds()) || !p.In(img1.Bounds()) {
+				distance = 100.
+			} else {
+				c0 := color.NRGBAModel.Convert(img0.At(x, y))
+				c1 := color.NRGBAModel.Convert(img1.At(x, y))
+				if c0 == c1 {
+					continue
+				}
+				distance = constants.ColorsDistance(c0, c1)
+			}
+			d.Pixels++
+			sum += distance
+			if distance > d.MaxDistance {
+				d.MaxDistance = distance
+			}
+		}
+	}
+	if d.Total > 0 {
+		d.MeanDistance = sum / float64(d.Total)
+	}
+	return d
+}
+
+// Update replaces the goldens of the folder expected by the files generated
+// in the folder actual.
+func Update(expected, actual string) error {
+	if err := os.RemoveAll(expected); err != nil {
+		return err
+	}
+	files, err := Files(actual)
+	if err != nil {
+		return err
+	}
+	for _, v := range files {
+		b, err := os.ReadFile(filepath.Join(actual, v))
+		if err != nil {
+			return err
+		}
+		dest := filepath.Join(expected, v)
+		if err := os.MkdirAll(filepath.Dir(dest), os.ModePerm); err != nil {
+			return err
+		}
+		if err := os.WriteFile(dest, b, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func contains(files []string, file string) bool {
+	for _, v := range files {
+		if v == file {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,232 @@
+package golden_test
+
+import (
+	"flag"
+	"image"
+	"os"
+	"path/filepath"
+	"testing"
+
+	_ "image/jpeg"
+	_ "image/png"
+
+	"github.com/disintegration/imaging"
+	"github.com/jeromelesaux/martine/config"
+	"github.com/jeromelesaux/martine/constants"
+	"github.com/jeromelesaux/martine/gfx"
+	"github.com/jeromelesaux/martine/gfx/animate"
+	"github.com/jeromelesaux/martine/gfx/effect"
+	"github.com/jeromelesaux/martine/gfx/filter"
+	"github.com/jeromelesaux/martine/gfx/golden"
+)
+
+// go test ./gfx/golden -update regenerates the goldens in testdata.
+var update = flag.Bool("update", false, "update the golden files")
+
+const (
+	batman = "../../samples/Batman-Neal-Adams.jpg"
+	sprite = "../../samples/rotate.png"
+	board  = "../../samples/mario-level1.png"
+	gif    = "../../samples/coke.gif"
+)
+
+type goldenCase struct {
+	name    string
+	input   string
+	mode    uint8
+	options func(cfg *config.MartineConfig, mode uint8)
+	run     func(in image.Image, filename string, cfg *config.MartineConfig) error
+}
+
+func newConfig(input, output string, mode uint8) *config.MartineConfig {
+	cfg := config.NewMartineConfig(input, output)
+	cfg.Size = constants.NewSizeMode(mode, false)
+	cfg.ResizingAlgo = imaging.NearestNeighbor
+	cfg.DitheringAlgo = -1
+	cfg.DitheringMultiplier = 1.18
+	cfg.Reducer = -1
+	cfg.EgxMode1 = mode
+	return cfg
+}
+
+func overscan(cfg *config.MartineConfig, mode uint8) {
+	cfg.Overscan = true
+	cfg.Scr = false
+	cfg.Kit = true
+	cfg.Size = constants.NewSizeMode(mode, true)
+}
+
+func plus(cfg *config.MartineConfig, mode uint8) {
+	cfg.CpcPlus = true
+	cfg.Kit = true
+	cfg.Pal = false
+}
+
+func customSize(width, height int) func(cfg *config.MartineConfig, mode uint8) {
+	return func(cfg *config.MartineConfig, mode uint8) {
+		cfg.CustomDimension = true
+		cfg.Win = true
+		cfg.Size.Width = width
+		cfg.Size.Height = height
+	}
+}
+
+func applyOneImage(mode uint8) func(in image.Image, filename string, cfg *config.MartineConfig) error {
+	return func(in image.Image, filename string, cfg *config.MartineConfig) error {
+		return gfx.ApplyOneImageAndExport(in, cfg, filename, cfg.InputPath, int(mode), mode)
+	}
+}
+
+var goldenCases = []goldenCase{
+	{name: "mode0", input: batman, mode: 0, run: applyOneImage(0)},
+	{name: "mode1", input: batman, mode: 1, run: applyOneImage(1)},
+	{name: "mode2", input: batman, mode: 2, run: applyOneImage(2)},
+	{
+		name: "mode0-floydsteinberg", input: batman, mode: 0,
+		options: func(cfg *config.MartineConfig, mode uint8) {
+			cfg.DitheringAlgo = 0
+			cfg.DitheringMatrix = filter.FloydSteinberg
+			cfg.DitheringType = constants.ErrorDiffusionDither
+		},
+		run: applyOneImage(0),
+	},
+	{
+		name: "mode0-floydsteinberg-paletteaware", input: batman, mode: 0,
+		options: func(cfg *config.MartineConfig, mode uint8) {
+			cfg.DitheringAlgo = 0
+			cfg.DitheringMatrix = filter.FloydSteinberg
+			cfg.DitheringType = constants.ErrorDiffusionDither
+			cfg.DitheringPaletteAware = true
+		},
+		run: applyOneImage(0),
+	},
+	{
+		name: "mode1-bayer4", input: batman, mode: 1,
+		options: func(cfg *config.MartineConfig, mode uint8) {
+			cfg.DitheringAlgo = 0
+			cfg.DitheringMatrix = filter.Bayer4
+			cfg.DitheringType = constants.OrderedDither
+		},
+		run: applyOneImage(1),
+	},
+	{
+		name: "mode0-voidandcluster-knoll", input: batman, mode: 0,
+		options: func(cfg *config.MartineConfig, mode uint8) {
+			cfg.DitheringAlgo = 0
+			cfg.DitheringMatrix = filter.VoidAndCluster16
+			cfg.DitheringType = constants.OrderedDither
+			cfg.DitheringPattern = int(filter.Knoll)
+		},
+		run: applyOneImage(0),
+	},
+	{name: "overscan-mode0", input: batman, mode: 0, options: overscan, run: applyOneImage(0)},
+	{name: "overscan-mode1", input: batman, mode: 1, options: overscan, run: applyOneImage(1)},
+	{
+		name: "plus-overscan-mode0", input: batman, mode: 0,
+		options: func(cfg *config.MartineConfig, mode uint8) { plus(cfg, mode); overscan(cfg, mode) },
+		run:     applyOneImage(0),
+	},
+	{name: "sprite-mode0", input: sprite, mode: 0, options: customSize(16, 16), run: applyOneImage(0)},
+	{
+		name: "spritehard", input: sprite, mode: 0,
+		options: func(cfg *config.MartineConfig, mode uint8) {
+			plus(cfg, mode)
+			customSize(16, 16)(cfg, mode)
+			cfg.SpriteHard = true
+		},
+		run: applyOneImage(0),
+	},
+	{
+		name: "egx1", input: batman, mode: 0,
+		options: func(cfg *config.MartineConfig, mode uint8) { cfg.EgxFormat = config.Egx1Mode; cfg.EgxMode2 = 1 },
+		run: func(in image.Image, filename string, cfg *config.MartineConfig) error {
+			return effect.AutoEgx1(in, cfg, filename, cfg.InputPath)
+		},
+	},
+	{
+		name: "flash-mode1", input: batman, mode: 1,
+		options: func(cfg *config.MartineConfig, mode uint8) { cfg.Flash = true },
+		run: func(in image.Image, filename string, cfg *config.MartineConfig) error {
+			return effect.AutoFlash(in, cfg, filename, cfg.InputPath, 1, 1)
+		},
+	},
+	{
+		name: "splitraster-mode0", input: batman, mode: 0,
+		options: func(cfg *config.MartineConfig, mode uint8) { overscan(cfg, mode); cfg.SplitRaster = true },
+		run: func(in image.Image, filename string, cfg *config.MartineConfig) error {
+			return effect.DoSpliteRaster(in, 0, filename, cfg)
+		},
+	},
+	{
+		name: "tilemap-mode0", input: board, mode: 0, options: customSize(8, 8),
+		run: func(in image.Image, filename string, cfg *config.MartineConfig) error {
+			return gfx.Tilemap(0, filename, cfg.InputPath, cfg.Size, in, cfg)
+		},
+	},
+	{
+		name: "deltapacking-mode1", input: gif, mode: 1, options: customSize(100, 100),
+		run: func(in image.Image, filename string, cfg *config.MartineConfig) error {
+			return animate.DeltaPacking(cfg.InputPath, cfg, 0xC010, 1, animate.DeltaExportV1, "")
+		},
+	},
+}
+
+func TestGolden(t *testing.T) {
+	for _, c := range goldenCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			output := t.TempDir()
+			cfg := newConfig(c.input, output, c.mode)
+			if c.options != nil {
+				c.options(cfg, c.mode)
+			}
+			f, err := os.Open(c.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			in, _, err := image.Decode(f)
+			f.Close()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := c.run(in, filepath.Base(c.input), cfg); err != nil {
+				t.Fatalf("expected no error and gets :%v", err)
+			}
+
+			expected := filepath.Join("testdata", c.name)
+			if *update {
+				if err := golden.Update(expected, output); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			diffs, err := golden.Compare(expected, output)
+			if err != nil {
+				t.Fatalf("cannot compare with the goldens (run go test -update to create them) :%v", err)
+			}
+			for _, d := range diffs {
+				t.Error(d.String())
+			}
+		})
+	}
+}
+
+func TestImageDiff(t *testing.T) {
+	img0 := image.NewNRGBA(image.Rect(0, 0, 4, 4))
+	img1 := image.NewNRGBA(image.Rect(0, 0, 4, 4))
+	img1.Set(1, 1, constants.White.Color)
+	d := golden.ImageDiff(img0, img1)
+	if d.Pixels != 1 || d.Total != 16 {
+		t.Fatalf("expected 1 pixel differing on 16 and gets %d on %d", d.Pixels, d.Total)
+	}
+	if d.MaxDistance == 0 {
+		t.Fatalf("expected a distance between black and white")
+	}
+}
+
+func TestBytesDiff(t *testing.T) {
+	d := golden.BytesDiff([]byte{1, 2, 3}, []byte{1, 4, 3, 5})
+	if d.Bytes != 2 || d.First != 1 {
+		t.Fatalf("expected 2 bytes differing from offset 1 and gets %d from %d", d.Bytes, d.First)
+	}
+}
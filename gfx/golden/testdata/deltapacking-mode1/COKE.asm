@@ -0,0 +1,1241 @@
+;--- dimensions du sprite ----
+large equ 25
+haut equ 100
+loadingaddress equ #200
+linewidth equ #c050
+nbdelta equ 16
+nbcolors equ 4
+;-----------------------------
+org loadingaddress
+run loadingaddress
+;-----------------------------
+start
+;--- selection du mode ---------
+	ld a,1
+	call #BC0E
+;-------------------------------
+
+;--- gestion de la palette ----
+	call palettefirmware
+;------------------------------
+
+call xvbl
+
+;--- affichage du sprite initiale --
+	; affichage du premier sprite
+	ld de,#c010 ; adresse de l'ecran
+	ld hl,sprite ; pointeur sur l'image en memoire
+	ld b, haut ; hauteur de l'image
+	loop
+	push bc ; sauve le compteur hauteur dans la pile
+	push de ; sauvegarde de l'adresse ecran dans la pile
+	ld bc, large ; largeur de l'image a afficher
+	ldir ; remplissage de n * largeur octets a l'adresse dans de
+	pop de ; recuperation de l'adresse d'origine
+	ex de,hl ; echange des valeurs des adresses
+	call bc26 ; calcul de l'adresse de la ligne suivante
+	ex de,hl ; echange des valeurs des adresses
+	pop bc ; retabli le compteur
+	djnz loop
+;------------------------------------
+
+mainloop    ; routine pour afficher les deltas provenant de martine
+
+;call #bb06
+
+call frame_timing
+call next_delta
+
+jp mainloop
+
+
+;--- routine de deltapacking --------------------------
+next_delta:
+table_index:
+	ld a,-1
+	inc a
+	cp nbdelta
+	jr c, table_next
+	xor a
+table_next:
+	ld (table_index+1),a
+	add a
+	ld e,a
+	ld d,0
+	ld hl,table_delta
+	add hl,de
+	ld a,(hl)
+	inc hl
+	ld h,(hl)
+	ld l,a
+delta
+	ld a,(hl) ; nombre de byte a poker
+	push af   ; stockage en mémoire
+	inc hl
+init
+	ld a,(hl) ; octet a poker
+	ld (pixel),a
+	inc hl
+	ld c,(hl) ; nbfois
+	inc hl
+	ld b,(hl)
+	inc hl
+;
+poke_octet
+	ld e,(hl)
+	inc hl
+	ld d,(hl) ; de=adresse
+	inc hl
+	ld a,(pixel)
+	ld (de),a ; poke a l'adresse dans de
+	dec bc
+	ld a,b ; test a t'on poke toutes les adresses compteur bc
+	or a
+	jr nz, poke_octet
+	ld a,c
+	or a
+	jr nz, poke_octet
+	pop af
+; reste t'il d'autres bytes a poker ?
+	dec a
+	push af
+	jr nz,init
+	pop af
+	ret
+
+;---------------------------------------------------------------
+;
+; attente de plusieurs vbl
+;
+xvbl ld e,50
+	call waitvbl
+	dec e
+	jr nz,xvbl+2
+	ret
+;-----------------------------------
+
+;---- attente vbl ----------
+waitvbl
+	ld b,#f5 ; attente vbl
+vbl
+	in a,(c)
+	rra
+	jp nc,vbl
+	ret
+;---------------------------
+
+;--- application palette firmware -------------
+palettefirmware ; hl pointe sur les valeurs de la palette
+ld e,nbcolors
+ld a,0
+ld hl,palette
+
+paletteloop
+ld b,(hl)
+ld c,b
+push af
+push de
+push hl
+call #bc32 ; af, de, hl corrupted
+pop hl
+pop de
+pop af
+inc a
+inc hl
+dec e
+jr nz,paletteloop
+ret
+;---------------------------------------------
+
+;---------------------------------------------
+
+;---- recuperation de l'adresse de la ligne en dessous ------------
+; bc26 : hl = address of a line, returns the address of the next line in hl
+bc26
+	ld a,h
+	add a,#08
+	ld h,a
+	and #38
+	ret nz
+	ld a,l
+	add a,#50
+	ld l,a
+	ld a,h
+	adc a,#C0
+	ld h,a
+	ret
+;-----------------------------------------------------------------
+
+
+;--- variables memoires -----
+pixel db 0
+;----------------------------
+nbframes equ 16
+
+;--- gestion du temps des frames ----------------------
+; attend la duree de la frame affichee, les frames sont
+; enchainees sans attente jusqu'a seek_frame (les deltas
+; sont sequentiels)
+frame_timing
+	ld a,(seek_frame)
+	cp #FF
+	jr z,timing_play
+	ld hl,current_frame
+	cp (hl)
+	jr nz,timing_next ; avance rapide
+	ld a,#FF
+	ld (seek_frame),a
+timing_play
+	ld a,(current_frame)
+	ld c,a
+	ld b,0
+	ld hl,timing_cue
+	add hl,bc
+	ld a,(hl)
+	or a
+	call nz,cue_hook
+	ld a,(current_frame)
+	ld hl,segment_last
+	cp (hl)
+	jr nz,timing_wait
+	ld a,(segment_first)
+	ld (seek_frame),a
+timing_wait
+	ld a,(current_frame)
+	ld c,a
+	ld b,0
+	ld hl,timing_vbl
+	add hl,bc
+	ld e,(hl)
+	call wait_vbls
+timing_next
+	ld a,(current_frame)
+	inc a
+	cp nbframes
+	jr c,timing_store
+	xor a
+timing_store
+	ld (current_frame),a
+	ret
+
+;--- attente de e vbl ---------------------------------
+wait_vbls
+	ld b,#F5
+wait_vbls_end
+	in a,(c)
+	rra
+	jr c,wait_vbls_end
+wait_vbls_start
+	in a,(c)
+	rra
+	jr nc,wait_vbls_start
+	dec e
+	jr nz,wait_vbls_end
+	ret
+
+;--- hooks du player ----------------------------------
+; cue_hook est appele avec a = numero du cue (cue_nom),
+; remplacer le ret par un jp vers la routine (ex synchro
+; d'une musique Arkos Tracker)
+cue_hook
+	ret
+	nop
+	nop
+
+; play_segment : d = premiere frame, e = derniere frame
+; du segment joue en boucle
+play_segment
+	ld a,d
+	ld (segment_first),a
+	ld (seek_frame),a
+	ld a,e
+	ld (segment_last),a
+	ret
+
+; stop_segment : reprend la lecture de toutes les frames
+stop_segment
+	ld a,#FF
+	ld (segment_last),a
+	ret
+
+; jump_cue : a = numero du cue, la lecture reprend a sa
+; frame
+jump_cue
+	ld l,a
+	ld h,0
+	ld de,cue_frames-1
+	add hl,de
+	ld a,(hl)
+	ld (seek_frame),a
+	ret
+;------------------------------------------------------
+timing_vbl:
+db #01, #01, #01, #01, #01, #01, #01, #01
+db #01, #01, #01, #01, #01, #01, #01, #01
+timing_cue:
+db #00, #00, #00, #00, #00, #00, #00, #00
+db #00, #00, #00, #00, #00, #00, #00, #00
+cue_frames:
+current_frame db 0
+seek_frame db #FF
+segment_first db 0
+segment_last db #FF
+sprite:
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #33
+db #f1, #ff, #ff, #f3, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #33, #f1, #ff, #ff, #f3, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #fe, #f7, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #fe, #f7, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #fc, #f3, #fe, #90, #f0, #f0
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #fc, #f3, #fe, #90, #f0
+db #f0, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #fc, #f3, #fe, #90
+db #f0, #f0, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #fc, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #fc, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #fc
+db #ff, #ff, #ff, #ff, #f0, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #fc, #ff, #ff, #ff, #ff, #f0, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #cf, #f0, #f0, #f0, #f0, #f0, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #cf, #f0, #f0, #f0, #f0, #f0
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #cf, #f0, #f0, #f0, #f0
+db #f0, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #cf, #f0, #f0, #f0
+db #f0, #f0, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #fc, #f0, #f0
+db #f0, #f0, #f0, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #fc, #f0
+db #f0, #f0, #f0, #f0, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #fc
+db #f0, #f0, #f0, #f0, #f0, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #fc, #f0, #f0, #f0, #f0, #f0, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #fc, #f0, #f0, #f0, #f0, #f0, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #fc, #f0, #f0, #f0, #f0, #f0
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #fc, #f0, #f0, #f0, #f0
+db #f0, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #fc, #f0, #f0, #f0
+db #f0, #f0, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #fc, #f0, #f0
+db #f0, #f0, #f0, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #fc, #f0
+db #f0, #f0, #f0, #f0, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #fc
+db #f0, #f0, #f0, #f0, #f0, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #fc, #f0, #f0, #f0, #f0, #f0, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #fc, #f0, #f0, #f0, #f0, #f0, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #fc, #f0, #f0, #f0, #f0, #f0
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #fc, #f0, #f0, #f0, #f0
+db #f0, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #fc, #f0, #f0, #f0
+db #f0, #f0, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #fc, #f0, #f0
+db #f0, #f0, #f0, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #fc, #f0
+db #f0, #f0, #f0, #f0, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #fc
+db #f0, #f0, #f0, #f0, #f0, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #fc, #f0, #f0, #f0, #f0, #f0, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #fc, #f0, #f0, #f0, #f0, #f0, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #fc, #f0, #f0, #f0, #f0, #f0
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #fc, #f0, #f0, #f0, #f0
+db #f0, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #fc, #f0, #f0, #f0
+db #f0, #f0, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #fc, #f0, #f0
+db #f0, #f0, #f0, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #f0
+db #f0, #f0, #f0, #f3, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #f0, #f0, #f0, #f0, #f3, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #f9, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #f9, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff, #ff, #ff, #ff, #ff
+db #ff, #ff, #ff, #ff
+delta00:
+db #0a, #ff, #29, #00, #0a, #e9, #0b, #e9
+db #0e, #e9, #0a, #f1, #0b, #f1, #0e, #f1
+db #59, #c9, #5e, #c9, #59, #d1, #5e, #d1
+db #59, #d9, #5e, #d9, #5e, #e1, #5e, #e9
+db #a9, #c1, #aa, #c1, #a9, #c9, #aa, #c9
+db #f9, #e9, #f9, #f1, #f9, #f9, #49, #c2
+db #49, #ca, #49, #d2, #49, #da, #49, #e2
+db #49, #ea, #49, #f2, #49, #fa, #99, #c2
+db #9a, #c2, #99, #ca, #9a, #ca, #99, #d2
+db #9a, #d2, #99, #da, #9a, #da, #99, #e2
+db #9a, #e2, #9a, #ea, #9a, #f2, #fc, #0d
+db #00, #09, #f9, #59, #c1, #5a, #c9, #5a
+db #d1, #5a, #d9, #5a, #e1, #5a, #e9, #4a
+db #e2, #4a, #ea, #4a, #f2, #4a, #fa, #9e
+db #ea, #9e, #f2, #f1, #06, #00, #0b, #f9
+db #5b, #c1, #50, #e2, #50, #ea, #50, #f2
+db #50, #fa, #f8, #09, #00, #0c, #f9, #5c
+db #c1, #5d, #c9, #5d, #d1, #5d, #d9, #58
+db #f1, #5c, #f1, #58, #f9, #5c, #f9, #88
+db #03, #00, #58, #c9, #58, #d1, #58, #d9
+db #f0, #12, #00, #5b, #c9, #5b, #d1, #5b
+db #d9, #5d, #e1, #5d, #e9, #5d, #f1, #5d
+db #f9, #4f, #c2, #4f, #ca, #4f, #d2, #4f
+db #da, #4f, #e2, #4f, #ea, #4f, #f2, #4f
+db #fa, #9f, #c2, #9f, #ca, #9f, #d2, #f7
+db #03, #00, #5c, #c9, #5c, #d1, #5c, #d9
+db #fe, #04, #00, #5c, #e1, #5c, #e9, #9b
+db #ea, #9b, #f2, #f3, #13, #00, #59, #f1
+db #5e, #f1, #59, #f9, #5e, #f9, #ae, #c1
+db #ae, #c9, #a9, #d1, #ae, #d1, #a9, #d9
+db #ae, #d9, #ff, #c9, #ff, #d1, #ff, #d9
+db #ff, #e1, #ff, #e9, #ff, #f1, #ff, #f9
+db #9f, #da, #9f, #e2, #3c, #05, #00, #a9
+db #e1, #a9, #e9, #a9, #f1, #a9, #f9, #f9
+db #c1
+delta01:
+db #0b, #ff, #27, #00, #09, #f9, #0d, #f9
+db #59, #c1, #5d, #c1, #58, #c9, #5a, #c9
+db #5d, #c9, #58, #d1, #5a, #d1, #5d, #d1
+db #58, #d9, #5a, #d9, #5d, #d9, #5e, #f1
+db #5e, #f9, #a9, #d1, #aa, #d1, #a9, #d9
+db #aa, #d9, #a9, #e1, #a9, #e9, #a9, #f1
+db #a9, #f9, #f9, #c1, #4a, #e2, #4a, #ea
+db #4a, #f2, #4a, #fa, #9b, #c2, #9b, #ca
+db #9b, #d2, #9b, #da, #9b, #e2, #9b, #ea
+db #9c, #ea, #9b, #f2, #9c, #f2, #9c, #fa
+db #ec, #c2, #fe, #0a, #00, #0b, #f9, #5b
+db #c1, #5b, #e1, #5b, #e9, #ab, #c1, #ab
+db #c9, #4b, #f2, #4b, #fa, #9c, #da, #9c
+db #e2, #f7, #0f, #00, #0c, #f9, #5c, #c1
+db #5d, #e1, #5d, #e9, #a8, #c1, #a8, #c9
+db #a8, #d1, #a8, #d9, #01, #da, #01, #e2
+db #51, #f2, #51, #fa, #a1, #c2, #a1, #ca
+db #a1, #d2, #f1, #0e, #00, #5c, #c9, #5c
+db #d1, #5c, #d9, #00, #ca, #00, #d2, #01
+db #ea, #01, #f2, #01, #fa, #51, #c2, #51
+db #ca, #51, #d2, #51, #da, #51, #e2, #51
+db #ea, #f0, #25, #00, #5a, #e1, #5c, #e1
+db #5a, #e9, #5c, #e9, #ae, #d1, #ae, #d9
+db #af, #f9, #ff, #c1, #f9, #c9, #ff, #c9
+db #f9, #d1, #ff, #d1, #ff, #d9, #00, #da
+db #ff, #e1, #00, #e2, #ff, #e9, #00, #ea
+db #ff, #f1, #00, #f2, #ff, #f9, #00, #fa
+db #50, #c2, #50, #ca, #50, #d2, #50, #da
+db #50, #e2, #50, #ea, #50, #f2, #50, #fa
+db #a0, #c2, #a0, #ca, #a0, #d2, #9f, #da
+db #9f, #e2, #9e, #ea, #9e, #f2, #fc, #09
+db #00, #59, #f1, #59, #f9, #a9, #c1, #a9
+db #c9, #aa, #e1, #aa, #e9, #aa, #f1, #4a
+db #d2, #4a, #da, #33, #02, #00, #5a, #f1
+db #5a, #f9, #f8, #0c, #00, #a7, #c1, #a7
+db #c9, #a7, #d1, #a7, #d9, #a8, #e1, #a8
+db #e9, #a8, #f1, #9c, #c2, #9c, #ca, #9c
+db #d2, #9d, #ea, #9d, #f2, #f3, #05, #00
+db #af, #e1, #af, #e9, #af, #f1, #9f, #ea
+db #9f, #f2, #0f, #02, #00, #f9, #d9, #f9
+db #e1, #cf, #03, #00, #f9, #e9, #f9, #f1
+db #f9, #f9
+delta02:
+db #0c, #ff, #3e, #00, #0b, #f9, #0c, #f9
+db #5b, #c1, #5c, #c1, #5b, #c9, #5c, #c9
+db #5b, #d1, #5c, #d1, #5b, #d9, #5c, #d9
+db #5d, #e1, #5d, #e9, #a7, #c1, #a8, #c1
+db #a7, #c9, #a8, #c9, #a7, #d1, #a7, #d9
+db #a8, #e1, #a8, #e9, #a8, #f1, #4a, #d2
+db #4a, #da, #4b, #e2, #4b, #ea, #4b, #f2
+db #4c, #f2, #4d, #f2, #51, #f2, #4b, #fa
+db #4c, #fa, #4d, #fa, #51, #fa, #9c, #c2
+db #9d, #c2, #9e, #c2, #a0, #c2, #a1, #c2
+db #9c, #ca, #9d, #ca, #9e, #ca, #a0, #ca
+db #a1, #ca, #9c, #d2, #9d, #d2, #9e, #d2
+db #a0, #d2, #a1, #d2, #9c, #da, #9d, #da
+db #9e, #da, #9f, #da, #9c, #e2, #9d, #e2
+db #9e, #e2, #9f, #e2, #9d, #ea, #9e, #ea
+db #9f, #ea, #9d, #f2, #9e, #f2, #9f, #f2
+db #f8, #07, #00, #58, #c9, #58, #d1, #58
+db #d9, #a8, #d1, #a8, #d9, #4c, #e2, #4c
+db #ea, #fc, #11, #00, #59, #c9, #59, #d1
+db #59, #d9, #59, #e1, #5a, #e1, #59, #e9
+db #5a, #e9, #5a, #f1, #5a, #f9, #aa, #c1
+db #aa, #c9, #aa, #d1, #aa, #d9, #f9, #c9
+db #f9, #d1, #f9, #d9, #f9, #e1, #f3, #09
+db #00, #5a, #c9, #5a, #d1, #5a, #d9, #59
+db #f1, #59, #f9, #a9, #c1, #a9, #c9, #a9
+db #d1, #a9, #d9, #f0, #1b, #00, #5b, #e1
+db #5b, #e9, #5b, #f1, #5c, #f1, #5b, #f9
+db #5c, #f9, #ab, #c1, #ae, #c1, #ab, #c9
+db #ae, #c9, #af, #d1, #af, #d9, #aa, #e1
+db #af, #e1, #b0, #e1, #aa, #e9, #af, #e9
+db #b0, #e9, #aa, #f1, #af, #f1, #b0, #f1
+db #b0, #f9, #00, #c2, #00, #ca, #01, #ca
+db #00, #d2, #01, #d2, #f7, #13, #00, #5c
+db #e1, #5c, #e9, #5d, #f1, #5d, #f9, #b1
+db #e1, #b1, #e9, #b1, #f1, #a7, #f9, #f7
+db #c1, #f8, #c9, #02, #ca, #f8, #d1, #02
+db #d2, #f8, #d9, #f8, #e1, #51, #d2, #51
+db #da, #51, #e2, #51, #ea, #f9, #03, #00
+db #a7, #e1, #a7, #e9, #a7, #f1, #fe, #06
+db #00, #a6, #f9, #f6, #c1, #f6, #c9, #f6
+db #d1, #f7, #d9, #f7, #e1, #f1, #06, #00
+db #b1, #f9, #01, #c2, #01, #da, #01, #e2
+db #50, #f2, #50, #fa, #f6, #02, #00, #f7
+db #c9, #f7, #d1, #c3, #03, #00, #f9, #e9
+db #f9, #f1, #f9, #f9, #cf, #02, #00, #49
+db #c2, #49, #ca
+delta03:
+db #0b, #ff, #3d, #00, #58, #c9, #59, #c9
+db #5a, #c9, #58, #d1, #59, #d1, #5a, #d1
+db #58, #d9, #59, #d9, #5a, #d9, #59, #e1
+db #5a, #e1, #5b, #e1, #5c, #e1, #59, #e9
+db #5a, #e9, #5b, #e9, #5c, #e9, #a9, #d1
+db #a9, #d9, #a6, #f9, #a7, #f9, #f6, #c1
+db #f7, #c1, #f6, #c9, #f7, #c9, #f9, #c9
+db #02, #ca, #f6, #d1, #f7, #d1, #f9, #d1
+db #02, #d2, #f8, #d9, #f9, #d9, #f8, #e1
+db #f9, #e1, #f9, #e9, #f9, #f1, #f9, #f9
+db #49, #c2, #49, #ca, #4c, #e2, #4d, #e2
+db #4e, #e2, #4f, #e2, #50, #e2, #51, #e2
+db #4c, #ea, #4d, #ea, #4e, #ea, #4f, #ea
+db #50, #ea, #51, #ea, #4e, #f2, #4f, #f2
+db #50, #f2, #4e, #fa, #4f, #fa, #50, #fa
+db #9f, #c2, #9f, #ca, #9f, #d2, #f0, #1b
+db #00, #59, #f1, #5a, #f1, #5d, #f1, #5e
+db #f1, #5f, #f1, #60, #f1, #59, #f9, #5a
+db #f9, #5d, #f9, #5e, #f9, #5f, #f9, #60
+db #f9, #aa, #c1, #af, #c1, #b0, #c1, #aa
+db #c9, #af, #c9, #b0, #c9, #aa, #d1, #b0
+db #d1, #aa, #d9, #b0, #d9, #a8, #e1, #a8
+db #e9, #a8, #f1, #b1, #f9, #01, #c2, #f7
+db #04, #00, #61, #f1, #61, #f9, #b2, #f9
+db #02, #c2, #f9, #04, #00, #a7, #c1, #a7
+db #c9, #f7, #d9, #f7, #e1, #f8, #08, #00
+db #a8, #c1, #a8, #c9, #f8, #c9, #f8, #d1
+db #48, #c2, #48, #ca, #48, #d2, #48, #da
+db #fc, #04, #00, #a9, #c1, #a9, #c9, #49
+db #d2, #49, #da, #f1, #09, #00, #b1, #c1
+db #b1, #c9, #b1, #d1, #b1, #d9, #b1, #e1
+db #b1, #e9, #b1, #f1, #01, #ca, #01, #d2
+db #fe, #03, #00, #a7, #e1, #a7, #e9, #a7
+db #f1, #88, #02, #00, #a8, #f9, #f8, #c1
+db #99, #02, #00, #47, #c2, #47, #ca, #0f
+db #02, #00, #4a, #d2, #4a, #da
+delta04:
+db #0b, #f0, #17, #00, #0f, #f9, #5f, #c1
+db #5e, #c9, #5f, #c9, #5e, #d1, #5f, #d1
+db #5e, #d9, #5f, #d9, #5d, #e1, #5e, #e1
+db #5f, #e1, #60, #e1, #5d, #e9, #5e, #e9
+db #5f, #e9, #60, #e9, #a9, #d1, #a9, #d9
+db #a9, #e1, #a9, #e9, #a9, #f1, #f8, #d9
+db #f8, #e1, #f1, #05, #00, #60, #c9, #60
+db #d1, #60, #d9, #61, #f1, #61, #f9, #f8
+db #05, #00, #5c, #e1, #5c, #e9, #f8, #e9
+db #f8, #f1, #f8, #f9, #f7, #0f, #00, #61
+db #e1, #61, #e9, #b1, #c1, #b1, #c9, #a8
+db #e1, #a8, #e9, #a8, #f1, #a8, #f9, #f8
+db #c1, #01, #da, #01, #e2, #48, #d2, #4d
+db #d2, #48, #da, #4d, #da, #ff, #26, #00
+db #58, #f1, #59, #f1, #5a, #f1, #58, #f9
+db #59, #f9, #5a, #f9, #a7, #c1, #a8, #c1
+db #a7, #c9, #a8, #c9, #00, #ea, #01, #ea
+db #00, #f2, #01, #f2, #00, #fa, #01, #fa
+db #47, #c2, #48, #c2, #4f, #c2, #50, #c2
+db #51, #c2, #47, #ca, #48, #ca, #4f, #ca
+db #50, #ca, #51, #ca, #49, #d2, #4a, #d2
+db #4e, #d2, #4f, #d2, #50, #d2, #51, #d2
+db #49, #da, #4a, #da, #4e, #da, #4f, #da
+db #50, #da, #51, #da, #fe, #06, #00, #a7
+db #f9, #f7, #c1, #f7, #d9, #f7, #e1, #47
+db #d2, #47, #da, #fc, #0d, #00, #a9, #f9
+db #f9, #c1, #fa, #e9, #fa, #f1, #fa, #f9
+db #4a, #c2, #4a, #ca, #49, #e2, #4a, #e2
+db #49, #ea, #4a, #ea, #49, #f2, #49, #fa
+db #f3, #03, #00, #f9, #e9, #f9, #f1, #f9
+db #f9, #0f, #02, #00, #4b, #e2, #4b, #ea
+db #7f, #02, #00, #4c, #e2, #4c, #ea, #88
+db #02, #00, #48, #f2, #48, #fa
+delta05:
+db #0c, #f8, #05, #00, #0d, #e9, #0d, #f1
+db #5c, #c9, #5c, #d1, #5c, #d9, #f0, #15
+db #00, #0e, #e9, #0e, #f1, #0d, #f9, #0e
+db #f9, #5d, #c1, #5e, #c1, #5d, #c9, #5d
+db #d1, #5d, #d9, #5c, #e1, #5c, #e9, #a9
+db #f9, #f9, #c1, #f9, #c9, #f9, #d1, #4d
+db #d2, #4d, #da, #9a, #da, #9a, #e2, #9a
+db #ea, #9a, #f2, #f3, #0d, #00, #0f, #e9
+db #0f, #f1, #f9, #d9, #f9, #e1, #ff, #e9
+db #ff, #f1, #ff, #f9, #49, #c2, #49, #ca
+db #4e, #d2, #4e, #da, #4a, #e2, #4a, #ea
+db #fe, #06, #00, #0c, #f9, #5c, #c1, #5b
+db #e1, #5b, #e9, #4b, #d2, #4b, #da, #f1
+db #09, #00, #10, #f9, #60, #c1, #b1, #c1
+db #b1, #c9, #00, #ca, #00, #d2, #9b, #c2
+db #9b, #ca, #9b, #d2, #ff, #27, #00, #a9
+db #c1, #a9, #c9, #a8, #d1, #a9, #d1, #a8
+db #d9, #a9, #d9, #a7, #e1, #a8, #e1, #a7
+db #e9, #a8, #e9, #a7, #f1, #a8, #f1, #a7
+db #f9, #a8, #f9, #b2, #f9, #f7, #c1, #f8
+db #c1, #02, #c2, #01, #ca, #01, #d2, #00
+db #da, #01, #da, #00, #e2, #01, #e2, #f9
+db #e9, #f9, #f1, #f9, #f9, #4a, #c2, #4a
+db #ca, #47, #d2, #48, #d2, #47, #da, #48
+db #da, #4b, #e2, #4b, #ea, #48, #f2, #49
+db #f2, #48, #fa, #49, #fa, #fc, #08, #00
+db #aa, #c1, #aa, #c9, #a9, #e1, #a9, #e9
+db #a9, #f1, #99, #c2, #99, #ca, #99, #d2
+db #f7, #02, #00, #b1, #f9, #01, #c2, #c0
+db #02, #00, #49, #d2, #49, #da, #e9, #04
+db #00, #4c, #e2, #4c, #ea, #4c, #f2, #4c
+db #fa, #0f, #02, #00, #4d, #e2, #4d, #ea
+db #7f, #02, #00, #4d, #f2, #4d, #fa
+delta06:
+db #0b, #f9, #03, #00, #0c, #c1, #0c, #c9
+db #0c, #d1, #fe, #04, #00, #0b, #d9, #0b
+db #e1, #9b, #ea, #9b, #f2, #f0, #24, #00
+db #0c, #d9, #0d, #d9, #0c, #e1, #0d, #e1
+db #0b, #e9, #0c, #e9, #0d, #e9, #0b, #f1
+db #0c, #f1, #0d, #f1, #0b, #f9, #0c, #f9
+db #5b, #c1, #5c, #c1, #5b, #c9, #5c, #c9
+db #5b, #d1, #5c, #d1, #5b, #d9, #5c, #d9
+db #5b, #e1, #5b, #e9, #5a, #f1, #5a, #f9
+db #aa, #c1, #aa, #c9, #f9, #d9, #f9, #e1
+db #f9, #e9, #fa, #e9, #f9, #f1, #fa, #f1
+db #f9, #f9, #fa, #f9, #4a, #e2, #4a, #ea
+db #f3, #10, #00, #0e, #d9, #0e, #e1, #af
+db #d1, #af, #d9, #af, #e1, #af, #e9, #af
+db #f1, #af, #f9, #ff, #c1, #fe, #e9, #fe
+db #f1, #fe, #f9, #4e, #c2, #4e, #ca, #49
+db #d2, #49, #da, #ff, #40, #00, #10, #f9
+db #60, #c1, #61, #e1, #61, #e9, #60, #f1
+db #61, #f1, #60, #f9, #61, #f9, #b0, #c1
+db #b1, #c1, #b0, #c9, #b1, #c9, #b0, #d1
+db #b1, #d1, #b0, #d9, #b1, #d9, #b0, #e1
+db #b1, #e1, #b0, #e9, #b1, #e9, #b0, #f1
+db #b1, #f1, #b0, #f9, #b1, #f9, #00, #c2
+db #01, #c2, #f8, #c9, #ff, #c9, #00, #ca
+db #f8, #d1, #ff, #d1, #00, #d2, #f7, #d9
+db #f8, #d9, #ff, #d9, #f7, #e1, #f8, #e1
+db #ff, #e1, #f8, #e9, #ff, #e9, #f8, #f1
+db #ff, #f1, #f8, #f9, #ff, #f9, #49, #c2
+db #49, #ca, #4b, #d2, #4b, #da, #4c, #e2
+db #4c, #ea, #4c, #f2, #4d, #f2, #4c, #fa
+db #4d, #fa, #99, #c2, #9b, #c2, #99, #ca
+db #9b, #ca, #99, #d2, #9b, #d2, #9a, #da
+db #9a, #e2, #9a, #ea, #9a, #f2, #fc, #0b
+db #00, #5a, #c9, #5a, #d1, #5a, #d9, #5a
+db #e1, #5a, #e9, #a9, #f9, #f9, #c1, #49
+db #f2, #4a, #f2, #49, #fa, #4a, #fa, #f1
+db #06, #00, #60, #e1, #60, #e9, #9b, #da
+db #9b, #e2, #9c, #ea, #9c, #f2, #f8, #0d
+db #00, #48, #c2, #48, #ca, #48, #d2, #4c
+db #d2, #48, #da, #4c, #da, #4d, #e2, #4d
+db #ea, #48, #f2, #48, #fa, #9c, #c2, #9c
+db #ca, #9c, #d2, #87, #02, #00, #4d, #d2
+db #4d, #da, #3f, #02, #00, #4e, #d2, #4e
+db #da, #f7, #03, #00, #9d, #c2, #9d, #ca
+db #9d, #d2
+delta07:
+db #0b, #fe, #05, #00, #0b, #c1, #0b, #c9
+db #0b, #d1, #9c, #da, #9c, #e2, #f7, #05
+db #00, #0c, #c1, #0c, #c9, #0c, #d1, #9d
+db #da, #9d, #e2, #f0, #13, #00, #0a, #d9
+db #0b, #d9, #0a, #e1, #0b, #e1, #0a, #e9
+db #0a, #f1, #0a, #f9, #5a, #c1, #5a, #c9
+db #5a, #d1, #5a, #d9, #5a, #e1, #5a, #e9
+db #4a, #c2, #4a, #ca, #4a, #f2, #4e, #f2
+db #4a, #fa, #4e, #fa, #fc, #1c, #00, #09
+db #e9, #09, #f1, #09, #f9, #59, #c1, #59
+db #c9, #59, #d1, #59, #d9, #59, #e1, #59
+db #e9, #59, #f1, #59, #f9, #a9, #c1, #a9
+db #c9, #a9, #d1, #a9, #d9, #f9, #c9, #f9
+db #d1, #f9, #d9, #f9, #e1, #f9, #e9, #f9
+db #f1, #f9, #f9, #49, #c2, #49, #ca, #49
+db #d2, #4e, #d2, #49, #da, #4e, #da, #ff
+db #33, #00, #0f, #e9, #0f, #f1, #0f, #f9
+db #5f, #c1, #5f, #c9, #60, #c9, #5f, #d1
+db #60, #d1, #5f, #d9, #60, #d9, #5f, #e1
+db #60, #e1, #5f, #e9, #60, #e9, #5f, #f1
+db #5f, #f9, #af, #c1, #af, #c9, #af, #d1
+db #af, #d9, #af, #e1, #af, #e9, #af, #f1
+db #af, #f9, #ff, #c1, #48, #c2, #48, #ca
+db #48, #d2, #4c, #d2, #4d, #d2, #48, #da
+db #4c, #da, #4d, #da, #4a, #e2, #4d, #e2
+db #4a, #ea, #4d, #ea, #48, #f2, #48, #fa
+db #9c, #c2, #9d, #c2, #9c, #ca, #9d, #ca
+db #9c, #d2, #9d, #d2, #9b, #da, #9b, #e2
+db #9b, #ea, #9c, #ea, #9b, #f2, #9c, #f2
+db #c3, #05, #00, #fe, #e9, #fe, #f1, #fe
+db #f9, #4e, #c2, #4e, #ca, #f3, #04, #00
+db #4a, #d2, #4a, #da, #9a, #da, #9a, #e2
+db #e0, #02, #00, #4b, #f2, #4b, #fa, #71
+db #02, #00, #4c, #f2, #4c, #fa, #f1, #03
+db #00, #9b, #c2, #9b, #ca, #9b, #d2, #33
+db #02, #00, #9e, #da, #9e, #e2
+delta08:
+db #0b, #fc, #0b, #00, #09, #d9, #0a, #d9
+db #09, #e1, #0a, #e1, #4a, #e2, #4a, #ea
+db #4a, #f2, #4a, #fa, #9e, #c2, #9e, #ca
+db #9e, #d2, #f1, #04, #00, #0c, #d9, #0c
+db #e1, #4b, #e2, #4b, #ea, #ff, #28, #00
+db #0d, #d9, #0e, #d9, #0d, #e1, #0e, #e1
+db #0e, #e9, #0e, #f1, #0e, #f9, #5e, #c1
+db #5e, #c9, #5e, #d1, #5e, #d9, #5e, #e1
+db #5e, #e9, #f9, #e9, #f9, #f1, #f9, #f9
+db #49, #c2, #4e, #c2, #49, #ca, #4e, #ca
+db #49, #d2, #4e, #d2, #49, #da, #4e, #da
+db #49, #e2, #49, #ea, #49, #f2, #4b, #f2
+db #4e, #f2, #49, #fa, #4b, #fa, #4e, #fa
+db #9a, #da, #9c, #da, #9d, #da, #9e, #da
+db #9a, #e2, #9c, #e2, #9d, #e2, #9e, #e2
+db #f0, #24, #00, #09, #e9, #09, #f1, #09
+db #f9, #59, #c1, #58, #c9, #59, #c9, #58
+db #d1, #59, #d1, #58, #d9, #59, #d9, #58
+db #e1, #59, #e1, #58, #e9, #59, #e9, #59
+db #f1, #59, #f9, #a9, #c1, #a9, #c9, #a9
+db #d1, #a9, #d9, #a9, #e1, #a9, #e9, #a9
+db #f1, #a9, #f9, #f9, #c1, #4a, #d2, #4b
+db #d2, #4f, #d2, #4a, #da, #4b, #da, #4f
+db #da, #4d, #f2, #4d, #fa, #9b, #c2, #9b
+db #ca, #9b, #d2, #f7, #0b, #00, #0d, #e9
+db #0d, #f1, #0d, #f9, #5d, #c1, #4d, #c2
+db #4d, #ca, #4c, #d2, #4c, #da, #9d, #c2
+db #9d, #ca, #9d, #d2, #f8, #0a, #00, #08
+db #f9, #58, #c1, #58, #f1, #58, #f9, #a8
+db #c1, #a8, #c9, #4d, #e2, #4d, #ea, #4c
+db #f2, #4c, #fa, #fe, #05, #00, #57, #c9
+db #57, #d1, #57, #d9, #57, #e1, #57, #e9
+db #f3, #0c, #00, #5e, #f1, #5e, #f9, #ae
+db #c1, #ae, #c9, #ae, #d1, #ae, #d9, #fe
+db #e9, #ff, #e9, #fe, #f1, #ff, #f1, #fe
+db #f9, #ff, #f9, #3f, #04, #00, #ff, #c9
+db #ff, #d1, #ff, #d9, #ff, #e1, #cc, #02
+db #00, #4f, #f2, #4f, #fa, #f6, #03, #00
+db #9c, #c2, #9c, #ca, #9c, #d2
+delta09:
+db #0b, #ff, #2e, #00, #0b, #c1, #0c, #c1
+db #0b, #c9, #0c, #c9, #0b, #d1, #0c, #d1
+db #09, #d9, #0a, #d9, #0b, #d9, #0c, #d9
+db #09, #e1, #0a, #e1, #0b, #e1, #0c, #e1
+db #0c, #e9, #0d, #e9, #0c, #f1, #0d, #f1
+db #08, #f9, #0d, #f9, #58, #c1, #5d, #c1
+db #5d, #c9, #5d, #d1, #5d, #d9, #5e, #f1
+db #5e, #f9, #ff, #d9, #ff, #e1, #ff, #e9
+db #ff, #f1, #ff, #f9, #4a, #e2, #4d, #e2
+db #4a, #ea, #4d, #ea, #4a, #f2, #4f, #f2
+db #4a, #fa, #4f, #fa, #9b, #c2, #9e, #c2
+db #9b, #ca, #9e, #ca, #9b, #d2, #9e, #d2
+db #fc, #07, #00, #09, #e9, #09, #f1, #f9
+db #e9, #f9, #f1, #f9, #f9, #4a, #d2, #4a
+db #da, #f1, #10, #00, #0b, #e9, #0b, #f1
+db #5c, #c9, #5c, #d1, #5c, #d9, #00, #ea
+db #00, #f2, #00, #fa, #51, #c2, #51, #ca
+db #51, #d2, #51, #da, #4c, #e2, #50, #e2
+db #4c, #ea, #50, #ea, #f7, #0a, #00, #0c
+db #f9, #5c, #c1, #5d, #e1, #5d, #e9, #4d
+db #d2, #4d, #da, #4d, #f2, #4d, #fa, #9d
+db #da, #9d, #e2, #f8, #0e, #00, #57, #f1
+db #57, #f9, #a7, #c1, #a7, #c9, #a7, #d1
+db #a7, #d9, #a7, #e1, #a7, #e9, #a7, #f1
+db #f8, #c9, #f8, #d1, #9c, #c2, #9c, #ca
+db #9c, #d2, #f0, #20, #00, #58, #f1, #58
+db #f9, #a8, #c1, #a8, #c9, #a8, #d1, #ae
+db #d1, #a8, #d9, #ae, #d9, #a8, #e1, #a8
+db #e9, #a8, #f1, #a8, #f9, #f8, #c1, #f9
+db #c9, #ff, #c9, #f9, #d1, #ff, #d1, #f9
+db #d9, #f9, #e1, #4d, #c2, #4d, #ca, #4c
+db #d2, #4c, #da, #4b, #e2, #4b, #ea, #4c
+db #f2, #4e, #f2, #4c, #fa, #4e, #fa, #9d
+db #c2, #9d, #ca, #9d, #d2, #3f, #03, #00
+db #af, #e1, #af, #e9, #af, #f1, #fe, #0a
+db #00, #a7, #f9, #f7, #c1, #50, #c2, #50
+db #ca, #50, #d2, #50, #da, #4b, #f2, #4b
+db #fa, #9c, #da, #9c, #e2, #0f, #02, #00
+db #af, #f9, #ff, #c1, #f3, #04, #00, #4f
+db #d2, #4f, #da, #4f, #e2, #4f, #ea, #cc
+db #02, #00, #4e, #e2, #4e, #ea
+delta10:
+db #0a, #ff, #41, #00, #09, #e9, #0a, #e9
+db #0b, #e9, #09, #f1, #0a, #f1, #0b, #f1
+db #0a, #f9, #0b, #f9, #0c, #f9, #5a, #c1
+db #5b, #c1, #5c, #c1, #5b, #c9, #5c, #c9
+db #5b, #d1, #5c, #d1, #5b, #d9, #5c, #d9
+db #5d, #e1, #5d, #e9, #af, #e1, #af, #e9
+db #af, #f1, #af, #f9, #ff, #c1, #ff, #c9
+db #ff, #d1, #fe, #e9, #00, #ea, #fe, #f1
+db #00, #f2, #fe, #f9, #00, #fa, #50, #c2
+db #51, #c2, #50, #ca, #51, #ca, #4a, #d2
+db #50, #d2, #51, #d2, #4a, #da, #50, #da
+db #51, #da, #4b, #e2, #4f, #e2, #50, #e2
+db #4b, #ea, #4f, #ea, #50, #ea, #4b, #f2
+db #4c, #f2, #4d, #f2, #4b, #fa, #4c, #fa
+db #4d, #fa, #9c, #c2, #9d, #c2, #9c, #ca
+db #9d, #ca, #9c, #d2, #9d, #d2, #9c, #da
+db #9d, #da, #9c, #e2, #9d, #e2, #f8, #0b
+db #00, #08, #f9, #58, #c1, #57, #e1, #57
+db #e9, #f7, #d9, #f7, #e1, #f8, #e9, #f8
+db #f1, #f8, #f9, #4c, #e2, #4c, #ea, #f3
+db #0d, #00, #09, #f9, #59, #c1, #5a, #c9
+db #5a, #d1, #5a, #d9, #af, #d1, #af, #d9
+db #fe, #c9, #fe, #d1, #fe, #d9, #fe, #e1
+db #4f, #c2, #4f, #ca, #f7, #06, #00, #5c
+db #e1, #5c, #e9, #5d, #f1, #5d, #f9, #01
+db #da, #01, #e2, #fe, #0b, #00, #a6, #c1
+db #a6, #c9, #a6, #d1, #a6, #d9, #a6, #e1
+db #a6, #e9, #a6, #f1, #f6, #c9, #f6, #d1
+db #00, #da, #00, #e2, #f0, #1d, #00, #a7
+db #c1, #a7, #c9, #a7, #d1, #a7, #d9, #a7
+db #e1, #a7, #e9, #a7, #f1, #a6, #f9, #a7
+db #f9, #f6, #c1, #f7, #c1, #f7, #c9, #f8
+db #c9, #f7, #d1, #f8, #d1, #f8, #d9, #f8
+db #e1, #f9, #e9, #ff, #e9, #f9, #f1, #ff
+db #f1, #f9, #f9, #ff, #f9, #4d, #d2, #4f
+db #d2, #4d, #da, #4f, #da, #4d, #e2, #4d
+db #ea, #c3, #04, #00, #ae, #c1, #ae, #c9
+db #ae, #d1, #ae, #d9, #f1, #05, #00, #b0
+db #e1, #b0, #e9, #b0, #f1, #b0, #f9, #00
+db #c2, #f9, #04, #00, #b1, #f9, #01, #c2
+db #01, #ca, #01, #d2, #fc, #06, #00, #49
+db #c2, #49, #ca, #4e, #e2, #4e, #ea, #4f
+db #f2, #4f, #fa
+delta11:
+db #0d, #ff, #30, #00, #08, #f9, #09, #f9
+db #58, #c1, #59, #c1, #57, #c9, #58, #c9
+db #59, #c9, #5a, #c9, #57, #d1, #58, #d1
+db #59, #d1, #5a, #d1, #57, #d9, #58, #d9
+db #59, #d9, #5a, #d9, #57, #e1, #58, #e1
+db #59, #e1, #5a, #e1, #5b, #e1, #5c, #e1
+db #57, #e9, #58, #e9, #59, #e9, #5a, #e9
+db #5b, #e9, #5c, #e9, #af, #d1, #af, #d9
+db #b0, #f9, #b1, #f9, #00, #c2, #01, #c2
+db #01, #ca, #01, #d2, #01, #da, #01, #e2
+db #4c, #e2, #4d, #e2, #4e, #e2, #4c, #ea
+db #4d, #ea, #4e, #ea, #4e, #f2, #4f, #f2
+db #4e, #fa, #4f, #fa, #87, #02, #00, #5d
+db #f1, #5d, #f9, #3c, #02, #00, #5e, #f1
+db #5e, #f9, #fc, #0d, #00, #5f, #f1, #5f
+db #f9, #af, #c1, #af, #c9, #af, #f9, #ff
+db #c1, #ff, #d9, #ff, #e1, #ff, #e9, #ff
+db #f1, #ff, #f9, #4f, #c2, #4f, #ca, #f3
+db #0c, #00, #ae, #c1, #ae, #c9, #ae, #d1
+db #ae, #d9, #ae, #e1, #ae, #e9, #ae, #f1
+db #ae, #f9, #fe, #c1, #fe, #e9, #fe, #f1
+db #fe, #f9, #ee, #02, #00, #b0, #c1, #b0
+db #c9, #77, #02, #00, #b1, #c1, #b1, #c9
+db #fe, #0e, #00, #b0, #e1, #b0, #e9, #b0
+db #f1, #a6, #f9, #f6, #c1, #f6, #d9, #f6
+db #e1, #f6, #e9, #f6, #f1, #f6, #f9, #46
+db #c2, #50, #c2, #46, #ca, #50, #ca, #f7
+db #05, #00, #b1, #e1, #b1, #e9, #b1, #f1
+db #51, #c2, #51, #ca, #f0, #1a, #00, #f6
+db #c9, #f6, #d1, #f7, #d9, #f7, #e1, #f7
+db #e9, #f8, #e9, #f7, #f1, #f8, #f1, #f7
+db #f9, #f8, #f9, #47, #c2, #48, #c2, #49
+db #c2, #4e, #c2, #47, #ca, #48, #ca, #49
+db #ca, #4e, #ca, #48, #d2, #49, #d2, #4a
+db #d2, #4e, #d2, #48, #da, #49, #da, #4a
+db #da, #4e, #da, #cc, #02, #00, #ff, #c9
+db #ff, #d1, #f1, #02, #00, #00, #da, #00
+db #e2, #f8, #02, #00, #47, #d2, #47, #da
+delta12:
+db #0b, #fc, #0a, #00, #5e, #c9, #5e, #d1
+db #5e, #d9, #5e, #e1, #5e, #e9, #a9, #c1
+db #a9, #c9, #af, #e1, #af, #e9, #af, #f1
+db #cc, #03, #00, #5f, #c9, #5f, #d1, #5f
+db #d9, #8f, #02, #00, #5c, #e1, #5c, #e9
+db #78, #02, #00, #5d, #e1, #5d, #e9, #ff
+db #34, #00, #57, #f1, #58, #f1, #59, #f1
+db #5a, #f1, #5e, #f1, #5f, #f1, #57, #f9
+db #58, #f9, #59, #f9, #5a, #f9, #5e, #f9
+db #5f, #f9, #a6, #c1, #a7, #c1, #a8, #c1
+db #ae, #c1, #af, #c1, #b0, #c1, #b1, #c1
+db #a6, #c9, #a7, #c9, #a8, #c9, #ae, #c9
+db #af, #c9, #b0, #c9, #b1, #c9, #a6, #d1
+db #a7, #d1, #ae, #d1, #a6, #d9, #a7, #d9
+db #ae, #d9, #a6, #e1, #b1, #e1, #a6, #e9
+db #b1, #e9, #a6, #f1, #b1, #f1, #ff, #c9
+db #ff, #d1, #46, #c2, #4f, #c2, #50, #c2
+db #51, #c2, #46, #ca, #4f, #ca, #50, #ca
+db #51, #ca, #4e, #d2, #4f, #d2, #4e, #da
+db #4f, #da, #f7, #06, #00, #5d, #f1, #5d
+db #f9, #4d, #d2, #4d, #da, #4c, #e2, #4c
+db #ea, #f1, #07, #00, #60, #f1, #60, #f9
+db #b0, #e1, #b0, #e9, #b0, #f1, #00, #ca
+db #00, #d2, #f8, #0c, #00, #a8, #d1, #a8
+db #d9, #a7, #e1, #a7, #e9, #a7, #f1, #47
+db #c2, #47, #ca, #47, #e2, #47, #ea, #98
+db #c2, #98, #ca, #98, #d2, #f0, #1a, #00
+db #af, #d1, #af, #d9, #fe, #c9, #fe, #d1
+db #fe, #d9, #fe, #e1, #fe, #e9, #ff, #e9
+db #fe, #f1, #ff, #f1, #fe, #f9, #ff, #f9
+db #47, #d2, #47, #da, #48, #e2, #49, #e2
+db #4a, #e2, #4b, #e2, #48, #ea, #49, #ea
+db #4a, #ea, #4b, #ea, #48, #f2, #49, #f2
+db #48, #fa, #49, #fa, #f3, #07, #00, #ff
+db #d9, #ff, #e1, #4a, #f2, #4a, #fa, #99
+db #c2, #99, #ca, #99, #d2, #fe, #04, #00
+db #46, #d2, #46, #da, #47, #f2, #47, #fa
+delta13:
+db #0c, #f0, #29, #00, #0e, #e9, #0e, #f1
+db #0e, #f9, #5e, #c1, #ae, #f9, #b0, #f9
+db #fe, #c1, #00, #c2, #ff, #c9, #ff, #d1
+db #ff, #d9, #ff, #e1, #4d, #d2, #4d, #da
+db #4c, #e2, #4d, #e2, #4c, #ea, #4d, #ea
+db #4a, #f2, #4b, #f2, #4c, #f2, #4a, #fa
+db #4b, #fa, #4c, #fa, #99, #c2, #9a, #c2
+db #9b, #c2, #99, #ca, #9a, #ca, #9b, #ca
+db #99, #d2, #9a, #d2, #9b, #d2, #99, #da
+db #9a, #da, #9b, #da, #99, #e2, #9a, #e2
+db #9b, #e2, #9a, #ea, #9a, #f2, #f8, #0c
+db #00, #5d, #c9, #5d, #d1, #5d, #d9, #f8
+db #c9, #f8, #d1, #f7, #e9, #f7, #f1, #f7
+db #f9, #47, #d2, #47, #da, #98, #da, #98
+db #e2, #ff, #2d, #00, #5e, #c9, #5e, #d1
+db #5e, #d9, #5d, #e1, #5e, #e1, #5d, #e9
+db #5e, #e9, #5d, #f1, #60, #f1, #5d, #f9
+db #60, #f9, #a9, #c1, #a9, #c9, #a8, #d1
+db #a9, #d1, #a8, #d9, #a9, #d9, #a7, #e1
+db #a8, #e1, #af, #e1, #a7, #e9, #a8, #e9
+db #af, #e9, #a7, #f1, #a8, #f1, #af, #f1
+db #a6, #f9, #a7, #f9, #a8, #f9, #f6, #c1
+db #f7, #c1, #f8, #c1, #f6, #c9, #f7, #c9
+db #f6, #d1, #f7, #d1, #f6, #d9, #00, #da
+db #f6, #e1, #00, #e2, #f6, #e9, #f6, #f1
+db #f6, #f9, #46, #d2, #46, #da, #f3, #0a
+db #00, #5f, #c9, #5f, #d1, #5f, #d9, #5f
+db #f1, #5f, #f9, #ff, #e9, #ff, #f1, #ff
+db #f9, #4e, #d2, #4e, #da, #ef, #02, #00
+db #5b, #e1, #5b, #e9, #79, #04, #00, #5c
+db #e1, #5c, #e9, #5c, #f1, #5c, #f9, #0f
+db #02, #00, #5b, #f1, #5b, #f9, #fc, #0b
+db #00, #5e, #f1, #5e, #f9, #aa, #c1, #aa
+db #c9, #af, #d1, #af, #d9, #a9, #e1, #a9
+db #e9, #a9, #f1, #99, #ea, #99, #f2, #f7
+db #08, #00, #ad, #c1, #ad, #c9, #b1, #f9
+db #01, #c2, #4d, #f2, #4d, #fa, #9c, #da
+db #9c, #e2, #30, #02, #00, #af, #c1, #af
+db #c9, #fe, #02, #00, #f7, #d9, #f7, #e1
+db #f1, #05, #00, #9c, #c2, #9c, #ca, #9c
+db #d2, #9b, #ea, #9b, #f2
+delta14:
+db #09, #fe, #08, #00, #0b, #d9, #0b, #e1
+db #0c, #e9, #0c, #f1, #47, #e2, #47, #ea
+db #9b, #fa, #eb, #c2, #f1, #04, #00, #0c
+db #d9, #0c, #e1, #9c, #ea, #9c, #f2, #f7
+db #0d, #00, #0d, #e9, #0d, #f1, #0c, #f9
+db #5c, #c1, #5c, #f1, #5c, #f9, #9d, #c2
+db #9d, #ca, #9d, #d2, #9d, #da, #9d, #e2
+db #9c, #fa, #ec, #c2, #ff, #2e, #00, #0e
+db #e9, #0e, #f1, #0e, #f9, #5e, #c1, #5b
+db #e1, #5c, #e1, #5b, #e9, #5c, #e9, #5e
+db #f1, #5e, #f9, #b0, #e1, #b0, #e9, #b0
+db #f1, #b0, #f9, #b1, #f9, #00, #c2, #01
+db #c2, #f8, #c9, #ff, #c9, #00, #ca, #f8
+db #d1, #ff, #d1, #00, #d2, #f7, #d9, #f8
+db #d9, #ff, #d9, #f7, #e1, #f8, #e1, #ff
+db #e1, #f7, #e9, #f8, #e9, #ff, #e9, #f7
+db #f1, #f8, #f1, #ff, #f1, #f7, #f9, #f8
+db #f9, #ff, #f9, #47, #c2, #47, #ca, #47
+db #d2, #47, #da, #98, #da, #98, #e2, #99
+db #ea, #99, #f2, #f0, #18, #00, #0b, #f9
+db #5b, #c1, #5e, #e1, #5e, #e9, #5b, #f1
+db #5f, #f1, #5b, #f9, #5f, #f9, #aa, #c1
+db #aa, #c9, #ae, #d1, #ae, #d9, #ae, #e1
+db #ae, #e9, #ae, #f1, #4d, #f2, #4d, #fa
+db #9c, #c2, #9c, #ca, #9c, #d2, #9c, #da
+db #9c, #e2, #9b, #ea, #9b, #f2, #fc, #0c
+db #00, #5e, #c9, #5f, #c9, #5e, #d1, #5f
+db #d1, #5e, #d9, #5f, #d9, #5a, #e1, #5a
+db #e9, #af, #c1, #af, #c9, #a9, #f9, #f9
+db #c1, #f8, #06, #00, #5d, #e1, #5d, #e9
+db #48, #c2, #48, #ca, #48, #d2, #48, #da
+db #0f, #02, #00, #5a, #f1, #5a, #f9, #f3
+db #0c, #00, #af, #d1, #af, #d9, #af, #e1
+db #af, #e9, #af, #f1, #af, #f9, #ff, #c1
+db #fe, #e9, #fe, #f1, #fe, #f9, #4e, #c2
+db #4e, #ca
+delta15:
+db #0b, #ff, #33, #00, #0b, #d9, #0c, #d9
+db #0b, #e1, #0c, #e1, #0c, #e9, #0d, #e9
+db #0c, #f1, #0d, #f1, #0b, #f9, #5b, #c1
+db #5f, #c9, #5f, #d1, #5f, #d9, #5a, #e1
+db #5d, #e1, #5a, #e9, #5d, #e9, #5a, #f1
+db #5b, #f1, #5c, #f1, #5f, #f1, #5a, #f9
+db #5b, #f9, #5c, #f9, #5f, #f9, #af, #c1
+db #af, #c9, #af, #d1, #af, #d9, #af, #e1
+db #af, #e9, #af, #f1, #af, #f9, #ff, #c1
+db #48, #c2, #48, #ca, #48, #d2, #48, #da
+db #47, #e2, #48, #e2, #47, #ea, #48, #ea
+db #47, #f2, #48, #f2, #47, #fa, #48, #fa
+db #98, #c2, #98, #ca, #98, #d2, #9b, #fa
+db #eb, #c2, #33, #02, #00, #0a, #e9, #0a
+db #f1, #f1, #02, #00, #0b, #e9, #0b, #f1
+db #f3, #07, #00, #0e, #e9, #0e, #f1, #5a
+db #c9, #5a, #d1, #5a, #d9, #9e, #ea, #9e
+db #f2, #fe, #05, #00, #0c, #f9, #5c, #c1
+db #5b, #c9, #5b, #d1, #5b, #d9, #f7, #02
+db #00, #0d, #f9, #5d, #c1, #fc, #1b, #00
+db #59, #c9, #59, #d1, #59, #d9, #5e, #e1
+db #5e, #e9, #59, #f1, #59, #f9, #f9, #c9
+db #f9, #d1, #f9, #d9, #f9, #e1, #f9, #e9
+db #f9, #f1, #f9, #f9, #49, #c2, #49, #ca
+db #49, #d2, #49, #da, #49, #e2, #49, #ea
+db #49, #f2, #49, #fa, #99, #c2, #99, #ca
+db #99, #d2, #99, #da, #99, #e2, #90, #03
+db #00, #5c, #c9, #5c, #d1, #5c, #d9, #f0
+db #25, #00, #5d, #c9, #5e, #c9, #5d, #d1
+db #5e, #d1, #5d, #d9, #5e, #d9, #5e, #f1
+db #5e, #f9, #ad, #c1, #ae, #c1, #ad, #c9
+db #ae, #c9, #fe, #e9, #fe, #f1, #fe, #f9
+db #4e, #c2, #4e, #ca, #4e, #d2, #4e, #da
+db #4e, #e2, #4e, #ea, #4e, #f2, #4e, #fa
+db #9d, #c2, #9e, #c2, #9d, #ca, #9e, #ca
+db #9d, #d2, #9e, #d2, #9d, #da, #9e, #da
+db #9d, #e2, #9e, #e2, #9c, #ea, #9d, #ea
+db #9c, #f2, #9d, #f2, #cf, #04, #00, #a9
+db #c1, #a9, #c9, #a9, #d1, #a9, #d9, #f9
+db #02, #00, #9c, #fa, #ec, #c2
+table_delta:
+dw delta00, delta01, delta02, delta03, delta04, delta05, delta06, delta07
+dw delta08, delta09, delta10, delta11, delta12, delta13, delta14, delta15
+palette:
+db 00, 13, 25, 26
+end
+
+save'disc.bin',#200, end - start,DSK,'delta.dsk'
\ No newline at end of file
@@ -0,0 +1,261 @@
+package sprite
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jeromelesaux/martine/export/amsdos"
+	"github.com/jeromelesaux/martine/export/ascii"
+	"github.com/jeromelesaux/martine/gfx/errors"
+)
+
+const (
+	// bankFrameHeader is the size of a frame in the header table
+	bankFrameHeader = 8
+	// bankDefaultDuration is the duration in vbls of a frame without duration
+	bankDefaultDuration = 1
+)
+
+var animationName = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// BankFrame is a frame of the bank trimmed to its non transparent bytes (pen
+// 0). The offset is the position of the trimmed frame in the cell and the
+// hotspot the position of the hotspot of the cell in the trimmed frame, in
+// bytes and lines.
+type BankFrame struct {
+	Data     []byte `json:"-"`
+	Width    int    `json:"width"`
+	Height   int    `json:"height"`
+	OffsetX  int    `json:"offsetX"`
+	OffsetY  int    `json:"offsetY"`
+	HotspotX int    `json:"hotspotX"`
+	HotspotY int    `json:"hotspotY"`
+	Address  int    `json:"address"`
+}
+
+// BankAnimation is a named sequence of frames with their durations in vbls.
+type BankAnimation struct {
+	Name      string `json:"name"`
+	Frames    []int  `json:"frames"`
+	Durations []int  `json:"durations"`
+}
+
+// Bank is an animation bank, the frames and the animations playing them.
+type Bank struct {
+	Mode       uint8           `json:"mode"`
+	CellWidth  int             `json:"cellWidth"`
+	CellHeight int             `json:"cellHeight"`
+	Frames     []BankFrame     `json:"frames"`
+	Animations []BankAnimation `json:"animations"`
+}
+
+// TrimFrame returns the frame of the sprite data (lineWidth bytes by line)
+// trimmed to its bounding box of non transparent bytes.
+func TrimFrame(data []byte, lineWidth int, hotspotX, hotspotY int) BankFrame {
+	height := 0
+	if lineWidth != 0 {
+		height = len(data) / lineWidth
+	}
+	minX, minY, maxX, maxY := lineWidth, height, -1, -1
+	for y := 0; y < height; y++ {
+		for x := 0; x < lineWidth; x++ {
+			if data[y*lineWidth+x] == 0 {
+				continue
+			}
+			if x < minX {
+				minX = x
+			}
+			if x > maxX {
+				maxX = x
+			}
+			if y < minY {
+				minY = y
+			}
+			if y > maxY {
+				maxY = y
+			}
+		}
+	}
+	f := BankFrame{Data: []byte{}}
+	if maxX == -1 {
+		// fully transparent
+		f.HotspotX, f.HotspotY = hotspotX, hotspotY
+		return f
+	}
+	f.OffsetX, f.OffsetY = minX, minY
+	f.Width, f.Height = maxX-minX+1, maxY-minY+1
+	f.HotspotX, f.HotspotY = hotspotX-minX, hotspotY-minY
+	for y := minY; y <= maxY; y++ {
+		f.Data = append(f.Data, data[y*lineWidth+minX:y*lineWidth+maxX+1]...)
+	}
+	return f
+}
+
+// NewBank trims the sprites of the cells of width bytes and height lines,
+// the hotspot is the position in bytes and lines in the cell.
+func NewBank(sprites [][]byte, mode uint8, width, height int, hotspotX, hotspotY int) Bank {
+	b := Bank{Mode: mode, CellWidth: width, CellHeight: height}
+	for _, v := range sprites {
+		b.Frames = append(b.Frames, TrimFrame(v, width, hotspotX, hotspotY))
+	}
+	return b
+}
+
+// ParseAnimations returns the animations of the list name=frames (ex
+// walk=0-3@5;jump=4,6@3,7), @ sets the duration in vbls of the frames.
+func ParseAnimations(animations string, frames int) ([]BankAnimation, error) {
+	result := make([]BankAnimation, 0)
+	if strings.TrimSpace(animations) == "" {
+		return result, nil
+	}
+	for _, v := range strings.Split(animations, ";") {
+		values := strings.Split(strings.TrimSpace(v), "=")
+		if len(values) != 2 || !animationName.MatchString(values[0]) {
+			return result, errors.ErrorBadAnimation
+		}
+		a := BankAnimation{Name: values[0]}
+		for _, item := range strings.Split(values[1], ",") {
+			duration := bankDefaultDuration
+			parts := strings.Split(strings.TrimSpace(item), "@")
+			if len(parts) > 2 {
+				return result, errors.ErrorBadAnimation
+			}
+			if len(parts) == 2 {
+				d, err := strconv.Atoi(parts[1])
+				if err != nil || d < 1 || d > 255 {
+					return result, errors.ErrorBadAnimation
+				}
+				duration = d
+			}
+			bounds := strings.Split(parts[0], "-")
+			first, err := strconv.Atoi(bounds[0])
+			if err != nil || len(bounds) > 2 {
+				return result, errors.ErrorBadAnimation
+			}
+			last := first
+			if len(bounds) == 2 {
+				if last, err = strconv.Atoi(bounds[1]); err != nil {
+					return result, errors.ErrorBadAnimation
+				}
+			}
+			step := 1
+			if last < first {
+				step = -1
+			}
+			for i := first; ; i += step {
+				if i < 0 || i >= frames {
+					return result, errors.ErrorBadAnimation
+				}
+				a.Frames = append(a.Frames, i)
+				a.Durations = append(a.Durations, duration)
+				if i == last {
+					break
+				}
+			}
+		}
+		result = append(result, a)
+	}
+	return result, nil
+}
+
+// Bytes returns the bank : the number of frames and animations, the header
+// of each frame (address of the data from the start of the bank, width,
+// height, offset and hotspot), the address of each animation, the animations
+// (number of frames, then the frame and its duration) and the frames data.
+func (b *Bank) Bytes() ([]byte, error) {
+	if len(b.Frames) > 255 || len(b.Animations) > 255 {
+		return nil, errors.ErrorBadAnimation
+	}
+	header := 2 + len(b.Frames)*bankFrameHeader + len(b.Animations)*2
+	animations := make([]byte, 0)
+	addresses := make([]int, len(b.Animations))
+	for i, a := range b.Animations {
+		if len(a.Frames) > 255 {
+			return nil, errors.ErrorBadAnimation
+		}
+		addresses[i] = header + len(animations)
+		animations = append(animations, byte(len(a.Frames)))
+		for j, f := range a.Frames {
+			animations = append(animations, byte(f), byte(a.Durations[j]))
+		}
+	}
+	address := header + len(animations)
+	out := []byte{byte(len(b.Frames)), byte(len(b.Animations))}
+	for i := range b.Frames {
+		f := &b.Frames[i]
+		f.Address = address
+		address += len(f.Data)
+		out = append(out, byte(f.Address), byte(f.Address>>8), byte(f.Width), byte(f.Height),
+			byte(f.OffsetX), byte(f.OffsetY), byte(int8(f.HotspotX)), byte(int8(f.HotspotY)))
+	}
+	for _, v := range addresses {
+		out = append(out, byte(v), byte(v>>8))
+	}
+	out = append(out, animations...)
+	for _, f := range b.Frames {
+		out = append(out, f.Data...)
+	}
+	if len(out) > 0xFFFF {
+		return nil, errors.ErrorSizeOverflow
+	}
+	return out, nil
+}
+
+// Asm returns the source code of the bank with its labels, prefixed by
+// sprite_ as bank is a directive of rasm and anim_ is used by the animations.
+func (b *Bank) Asm() string {
+	var code string
+	code += fmt.Sprintf("; animation bank mode %d, cells %d bytes x %d lines\n", b.Mode, b.CellWidth, b.CellHeight)
+	code += "sprite_bank\n"
+	code += fmt.Sprintf("\tdb %d, %d ; frames, animations\n", len(b.Frames), len(b.Animations))
+	code += "sprite_bank_frames ; data, width, height, offset x, y, hotspot x, y\n"
+	for i, f := range b.Frames {
+		code += fmt.Sprintf("\tdw frame_%.2d\n\tdb %d, %d, %d, %d, %d, %d\n", i, f.Width, f.Height, f.OffsetX, f.OffsetY, f.HotspotX, f.HotspotY)
+	}
+	code += "sprite_bank_animations\n"
+	for _, a := range b.Animations {
+		code += fmt.Sprintf("\tdw anim_%s\n", a.Name)
+	}
+	for _, a := range b.Animations {
+		code += fmt.Sprintf("anim_%s ; frame, duration\n", a.Name)
+		code += fmt.Sprintf("\tdb %d\n", len(a.Frames))
+		for j, f := range a.Frames {
+			code += fmt.Sprintf("\tdb %d, %d\n", f, a.Durations[j])
+		}
+	}
+	for i, f := range b.Frames {
+		code += fmt.Sprintf("frame_%.2d\n", i)
+		if len(f.Data) != 0 {
+			code += ascii.FormatAssemblyDatabyte(f.Data, "\n")
+		}
+	}
+	return code
+}
+
+// ExportBank saves the bank (BANK.BIN), its source code (BANK.ASM) and its
+// json descriptor (BANK.JSON) in the folder.
+func ExportBank(b *Bank, folder string) error {
+	data, err := b.Bytes()
+	if err != nil {
+		return err
+	}
+	if err := amsdos.SaveOSFile(folder+string(filepath.Separator)+"BANK.BIN", data); err != nil {
+		return err
+	}
+	if err := amsdos.SaveStringOSFile(folder+string(filepath.Separator)+"BANK.ASM", b.Asm()); err != nil {
+		return err
+	}
+	f, err := os.Create(folder + string(filepath.Separator) + "BANK.JSON")
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	return enc.Encode(b)
+}
@@ -0,0 +1,54 @@
+package sprite
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBank(t *testing.T) {
+	// 4 bytes x 3 lines, the non transparent bytes are in the box (1,1)-(2,2)
+	data := []byte{
+		0, 0, 0, 0,
+		0, 1, 0, 0,
+		0, 0, 2, 0,
+	}
+	f := TrimFrame(data, 4, 2, 2)
+	if f.Width != 2 || f.Height != 2 || f.OffsetX != 1 || f.OffsetY != 1 || f.HotspotX != 1 || f.HotspotY != 1 {
+		t.Fatalf("unexpected trimmed frame %+v", f)
+	}
+	if len(f.Data) != 4 || f.Data[0] != 1 || f.Data[3] != 2 {
+		t.Fatalf("unexpected trimmed data %v", f.Data)
+	}
+
+	animations, err := ParseAnimations("walk=0-1@5;back=1-0,0@3", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(animations) != 2 || len(animations[1].Frames) != 3 || animations[1].Frames[0] != 1 || animations[1].Durations[2] != 3 || animations[0].Durations[0] != 5 {
+		t.Fatalf("unexpected animations %+v", animations)
+	}
+	if _, err := ParseAnimations("walk=0-2", 2); err == nil {
+		t.Fatalf("expected an error for the frame 2")
+	}
+
+	b := NewBank([][]byte{data, make([]byte, 12)}, 1, 4, 3, 2, 2)
+	b.Animations = animations
+	out, err := b.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// header, animations (1+2*2 and 1+3*2 bytes) and data of the frame 0
+	size := 2 + 2*bankFrameHeader + 2*2 + 5 + 7 + 4
+	if len(out) != size || b.Frames[0].Address != size-4 || b.Frames[1].Address != size {
+		t.Fatalf("unexpected bank of %d bytes, frames %+v", len(out), b.Frames)
+	}
+	code := b.Asm()
+	for _, label := range []string{"\nsprite_bank\n", "\nsprite_bank_frames ", "\nsprite_bank_animations\n", "\nanim_walk ", "\nframe_01\n"} {
+		if !strings.Contains(code, label) {
+			t.Fatalf("expected the label %q in %s", label, code)
+		}
+	}
+	if strings.Contains(code, "\nbank\n") {
+		t.Fatalf("the label bank is a rasm directive")
+	}
+}
@@ -19,6 +19,16 @@ func Transform(in *image.NRGBA,
 	size constants.Size,
 	filepath string,
 	cfg *config.MartineConfig) error {
+	if cfg.CustomGeometry {
+		switch size.ColorsAvailable {
+		case constants.Mode0.ColorsAvailable:
+			return export.ToMode0AndExport(in, p, size, filepath, cfg)
+		case constants.Mode1.ColorsAvailable:
+			return export.ToMode1AndExport(in, p, size, filepath, cfg)
+		case constants.Mode2.ColorsAvailable:
+			return export.ToMode2AndExport(in, p, size, filepath, cfg)
+		}
+	}
 	switch size {
 	case constants.Mode0:
 		return export.ToMode0AndExport(in, p, size, filepath, cfg)
@@ -43,6 +53,16 @@ func InternalTransform(
 	size constants.Size,
 	cfg *config.MartineConfig) []byte {
 
+	if cfg.CustomGeometry {
+		switch size.ColorsAvailable {
+		case constants.Mode0.ColorsAvailable:
+			return screen.ToMode0(in, p, cfg)
+		case constants.Mode1.ColorsAvailable:
+			return screen.ToMode1(in, p, cfg)
+		case constants.Mode2.ColorsAvailable:
+			return screen.ToMode2(in, p, cfg)
+		}
+	}
 	switch size {
 	case constants.Mode0:
 		return screen.ToMode0(in, p, cfg)
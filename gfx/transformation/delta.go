@@ -405,25 +405,25 @@ func (dc *DeltaCollection) Save(filename string) error {
 	return amsdos.SaveOSFile(filename, b)
 }
 
-func DeltaAddress(x, y, lineOctetWidth int) int {
-	//return (0x50 * (y / 8)) + (x + 1)
-	// lineOctetWidth == 0x50
-	if y > (8 * 0x800 / lineOctetWidth) {
-		fmt.Fprintf(os.Stderr, "WARNING: y (%d) is superior to  (8 * 0x800 /#%x)\n", y, lineOctetWidth)
+// DeltaAddress returns the offset of the byte x of the line y from the page
+// of the screen, the offset of the line is given by the geometry.
+func DeltaAddress(x, y int, g address.Geometry) int {
+	if y >= g.Lines() {
+		fmt.Fprintf(os.Stderr, "WARNING: y (%d) is superior to the lines of the screen (%d)\n", y, g.Lines())
 	}
-	return (0x800 * (y % 8)) + (lineOctetWidth * (y / 8)) + (x)
+	return g.Offset(0, y) + x
 }
 
-func X(offset uint16, lineOctetWidth int) uint16 {
-	line := Y(offset, lineOctetWidth)
+func X(offset uint16, g address.Geometry) uint16 {
+	line := Y(offset, g)
 	//fmt.Fprintf(os.Stdout, "res:%d\n", int(offset)-DeltaAddress(0, int(line)))
-	return uint16(int(offset) - DeltaAddress(0, int(line), lineOctetWidth))
+	return uint16(int(offset) - DeltaAddress(0, int(line), g))
 }
 
-func Y(offset uint16, lineOctetWidth int) uint16 {
+func Y(offset uint16, g address.Geometry) uint16 {
 	line := 0
-	for i := 0; i < constants.Mode0.Height; i++ {
-		lineAddress := DeltaAddress(0, i, lineOctetWidth)
+	for i := 0; i < g.Lines(); i++ {
+		lineAddress := DeltaAddress(0, i, g)
 		if lineAddress > int(offset) {
 			line = i - 1
 			break
@@ -432,12 +432,12 @@ func Y(offset uint16, lineOctetWidth int) uint16 {
 	return uint16(line)
 }
 
-func CpcCoordinates(address, startingAddress uint16, lineOctetWidth int) (int, int, error) {
-	for y := 0; y < constants.Mode0.Height; y++ {
+func CpcCoordinates(screenAddress, startingAddress uint16, g address.Geometry) (int, int, error) {
+	for y := 0; y < g.Lines(); y++ {
 		for x := 0; x < constants.Mode0.Width; x++ {
-			v := uint16(DeltaAddress(x, y, lineOctetWidth))
+			v := uint16(DeltaAddress(x, y, g))
 			v += startingAddress
-			if v == address {
+			if v == screenAddress {
 				return x, y, nil
 			}
 		}
@@ -445,7 +445,7 @@ func CpcCoordinates(address, startingAddress uint16, lineOctetWidth int) (int, i
 	return 0, 0, errors.ErrorCoordinatesNotFound
 }
 
-func Delta(scr1, scr2 []byte, isSprite bool, size constants.Size, mode uint8, x0, y0 uint16, lineOctetWidth int) *DeltaCollection {
+func Delta(scr1, scr2 []byte, isSprite bool, size constants.Size, mode uint8, x0, y0 uint16, g address.Geometry) *DeltaCollection {
 	data := NewDeltaCollection()
 	//var line int
 	for offset := 0; offset < len(scr1); offset++ { // a revoir car pour un sprite ce n'est le même mode d'adressage
@@ -454,7 +454,7 @@ func Delta(scr1, scr2 []byte, isSprite bool, size constants.Size, mode uint8, x0
 
 				y := int(offset/(size.Width)) + int(y0)
 				x := ((offset + int(x0)) - ((y - int(y0)) * (size.Width)))
-				newOffset := DeltaAddress(x, y, lineOctetWidth) + 0xC000
+				newOffset := DeltaAddress(x, y, g) + g.Page()
 				//	fmt.Fprintf(os.Stdout, "X0:%d,Y0:%d,X:%d,Y:%d,byte:#%.2x,addresse:#%.4x\n", x0, y0, x, y, scr2[offset], newOffset)
 				data.Add(scr2[offset], uint16(newOffset))
 			} else {
@@ -508,8 +508,7 @@ func ProceedDelta(filespath []string, initialAddress uint16, cfg *config.Martine
 	var isSprite = false
 	var size constants.Size
 	//var x0, y0 uint16
-	lineOctetsWidth := cfg.LineWidth
-	x0, y0, err := CpcCoordinates(initialAddress, 0xC000, lineOctetsWidth)
+	x0, y0, err := CpcCoordinates(initialAddress, uint16(cfg.Geometry.Page()), cfg.Geometry)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "error while computing cpc coordinates :%v\n", err)
 	}
@@ -571,7 +570,7 @@ func ProceedDelta(filespath []string, initialAddress uint16, cfg *config.Martine
 		if len(d1) != len(d2) {
 			return errors.ErrorSizeDiffers
 		}
-		dc := Delta(d1, d2, isSprite, size, mode, uint16(x0), uint16(y0), lineOctetsWidth)
+		dc := Delta(d1, d2, isSprite, size, mode, uint16(x0), uint16(y0), cfg.Geometry)
 		fmt.Fprintf(os.Stdout, "files (%s) (%s)", filespath[i], filespath[i+1])
 		fmt.Fprintf(os.Stdout, "%d bytes differ from the both images\n", len(dc.Items))
 		fmt.Fprintf(os.Stdout, "%d screen addresses are involved\n", dc.NbAdresses())
@@ -644,7 +643,7 @@ func ProceedDelta(filespath []string, initialAddress uint16, cfg *config.Martine
 		return err
 	}
 	defer f2.Close()
-	dc := Delta(d1, d2, isSprite, size, mode, uint16(x0), uint16(y0), lineOctetsWidth)
+	dc := Delta(d1, d2, isSprite, size, mode, uint16(x0), uint16(y0), cfg.Geometry)
 	fmt.Fprintf(os.Stdout, "files (%s) (%s)", filespath[len(filespath)-1], filespath[0])
 	fmt.Fprintf(os.Stdout, "%d bytes differ from the both images\n", len(dc.Items))
 	fmt.Fprintf(os.Stdout, "%d screen addresses are involved\n", dc.NbAdresses())
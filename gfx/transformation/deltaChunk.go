@@ -0,0 +1,204 @@
+package transformation
+
+import (
+	"github.com/jeromelesaux/martine/constants"
+	"github.com/jeromelesaux/martine/convert/address"
+	zx0 "github.com/jeromelesaux/zx0/encode"
+)
+
+type ChunkType byte
+
+const (
+	// ChunkEnd ends the frame
+	ChunkEnd ChunkType = iota
+	// ChunkPatch pokes the changed bytes of a span : address, number of
+	// bytes, then the offset in the span and the value of each byte
+	ChunkPatch
+	// ChunkRun copies the span : address, length, bytes
+	ChunkRun
+	// ChunkZx0 depacks the span : address, zx0 data
+	ChunkZx0
+	// ChunkKeyframe depacks the whole screen : zx0 data
+	ChunkKeyframe
+)
+
+const (
+	// maximum gap of unchanged bytes inside a span
+	chunkSpanGap = 3
+	// maximum length of a span
+	chunkSpanLength = 255
+	// minimum length of a span packed with zx0
+	chunkZx0Length = 8
+	// estimated durations in nops of the chunks in the player
+	chunkNops        = 20
+	chunkPatchNops   = 16
+	chunkRunNops     = 6
+	chunkZx0Nops     = 30
+	chunkZx0ByteNops = 12
+)
+
+// DeltaCost weights the size in bytes and the duration in nops of a chunk,
+// the encoder keeps the chunk of the lowest Bytes*bytes + Nops*nops.
+type DeltaCost struct {
+	Bytes float64
+	Nops  float64
+}
+
+// DefaultDeltaCost favours the size.
+var DefaultDeltaCost = DeltaCost{Bytes: 1, Nops: 0.1}
+
+func (c DeltaCost) cost(bytes, nops int) float64 {
+	return c.Bytes*float64(bytes) + c.Nops*float64(nops)
+}
+
+// Chunk is an encoded part of a frame.
+type Chunk struct {
+	Type    ChunkType
+	Address uint16
+	Data    []byte
+	Nops    int
+}
+
+// Bytes returns the encoded chunk.
+func (c Chunk) Bytes() []byte {
+	b := []byte{byte(c.Type)}
+	if c.Type != ChunkKeyframe && c.Type != ChunkEnd {
+		b = append(b, byte(c.Address), byte(c.Address>>8))
+	}
+	return append(b, c.Data...)
+}
+
+// ChunkFrame is the list of the chunks which transform a frame into the next
+// one.
+type ChunkFrame []Chunk
+
+// Bytes returns the encoded frame ended by ChunkEnd.
+func (f ChunkFrame) Bytes() []byte {
+	b := make([]byte, 0)
+	for _, c := range f {
+		b = append(b, c.Bytes()...)
+	}
+	return append(b, byte(ChunkEnd))
+}
+
+// Nops returns the estimated duration of the frame in the player.
+func (f ChunkFrame) Nops() int {
+	nops := chunkNops
+	for _, c := range f {
+		nops += c.Nops
+	}
+	return nops
+}
+
+// Count returns the number of chunks by type.
+func (f ChunkFrame) Count() map[ChunkType]int {
+	count := make(map[ChunkType]int)
+	for _, c := range f {
+		count[c.Type]++
+	}
+	return count
+}
+
+// DeltaSegment is a part of the raw data contiguous in the screen memory.
+type DeltaSegment struct {
+	Offset  int
+	Length  int
+	Address uint16
+}
+
+// DeltaSegments returns the lines of the sprite at (x0,y0) or of the screen
+// of the raw data, addressed from the page of the geometry.
+func DeltaSegments(isSprite bool, size constants.Size, length, x0, y0 int, g address.Geometry) []DeltaSegment {
+	segments := make([]DeltaSegment, 0)
+	if isSprite {
+		for y := 0; y*size.Width < length; y++ {
+			segments = append(segments, DeltaSegment{
+				Offset:  y * size.Width,
+				Length:  size.Width,
+				Address: uint16(DeltaAddress(x0, y0+y, g) + g.Page()),
+			})
+		}
+		return segments
+	}
+	for block := 0; block < length; block += 0x800 {
+		for start := block; start < block+0x800 && start < length; start += g.LineWidth() {
+			l := g.LineWidth()
+			if start+l > block+0x800 {
+				l = block + 0x800 - start
+			}
+			segments = append(segments, DeltaSegment{Offset: start, Length: l, Address: uint16(g.Page() + start)})
+		}
+	}
+	return segments
+}
+
+// DeltaChunks encodes the changes between the raw data: each span of changed
+// bytes of the segments is encoded as a patch, a run or a zx0 block, the
+// cheapest for the cost. The whole frame is replaced by a keyframe (screen
+// only) if it costs less.
+func DeltaChunks(from, to []byte, segments []DeltaSegment, keyframe bool, cost DeltaCost) ChunkFrame {
+	frame := make(ChunkFrame, 0)
+	bytes, nops := 1, chunkNops
+	for _, s := range segments {
+		for _, span := range changedSpans(from[s.Offset:s.Offset+s.Length], to[s.Offset:s.Offset+s.Length]) {
+			c := cheapestChunk(from[s.Offset+span[0]:s.Offset+span[1]], to[s.Offset+span[0]:s.Offset+span[1]], s.Address+uint16(span[0]), cost)
+			frame = append(frame, c)
+			bytes += len(c.Bytes())
+			nops += c.Nops
+		}
+	}
+	if keyframe && len(frame) > 0 {
+		k := Chunk{Type: ChunkKeyframe, Data: zx0.Encode(to), Nops: chunkZx0Nops + chunkZx0ByteNops*len(to)}
+		if cost.cost(len(k.Bytes())+1, k.Nops+chunkNops) < cost.cost(bytes, nops) {
+			return ChunkFrame{k}
+		}
+	}
+	return frame
+}
+
+// changedSpans returns the [start,end) of the spans of changed bytes, the
+// gaps of less than chunkSpanGap unchanged bytes are kept in the span.
+func changedSpans(from, to []byte) [][2]int {
+	spans := make([][2]int, 0)
+	start, end := -1, -1
+	for i := range to {
+		if from[i] == to[i] {
+			continue
+		}
+		if start != -1 && (i-end > chunkSpanGap || i+1-start > chunkSpanLength) {
+			spans = append(spans, [2]int{start, end})
+			start = -1
+		}
+		if start == -1 {
+			start = i
+		}
+		end = i + 1
+	}
+	if start != -1 {
+		spans = append(spans, [2]int{start, end})
+	}
+	return spans
+}
+
+func cheapestChunk(from, to []byte, address uint16, cost DeltaCost) Chunk {
+	patch := Chunk{Type: ChunkPatch, Address: address, Data: []byte{0}}
+	for i := range to {
+		if from[i] != to[i] {
+			patch.Data = append(patch.Data, byte(i), to[i])
+			patch.Data[0]++
+		}
+	}
+	patch.Nops = chunkNops + chunkPatchNops*int(patch.Data[0])
+	run := Chunk{Type: ChunkRun, Address: address, Data: append([]byte{byte(len(to))}, to...), Nops: chunkNops + chunkRunNops*len(to)}
+	candidates := []Chunk{run}
+	if len(to) >= chunkZx0Length {
+		candidates = append(candidates, Chunk{Type: ChunkZx0, Address: address, Data: zx0.Encode(to), Nops: chunkZx0Nops + chunkZx0ByteNops*len(to)})
+	}
+	best := patch
+	for _, c := range candidates {
+		if cost.cost(len(c.Bytes()), c.Nops) < cost.cost(len(best.Bytes()), best.Nops) {
+			best = c
+		}
+	}
+	return best
+}
@@ -0,0 +1,58 @@
+package transformation
+
+import (
+	"testing"
+
+	"github.com/jeromelesaux/martine/constants"
+	"github.com/jeromelesaux/martine/convert/address"
+)
+
+func TestDeltaChunks(t *testing.T) {
+	spans := changedSpans([]byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0}, []byte{1, 0, 0, 1, 0, 0, 0, 0, 1, 1})
+	if len(spans) != 2 || spans[0] != [2]int{0, 4} || spans[1] != [2]int{8, 10} {
+		t.Fatalf("unexpected spans %v", spans)
+	}
+
+	size := constants.Size{Width: 8, Height: 4}
+	from := make([]byte, 32)
+	to := make([]byte, 32)
+	// a full line and a few sparse bytes
+	for i := 8; i < 16; i++ {
+		to[i] = byte(i)
+	}
+	to[17], to[20] = 0xAA, 0x55
+	segments := DeltaSegments(true, size, len(from), 0, 0, address.DefaultGeometry)
+	if len(segments) != 4 || segments[1].Address != 0xC800 {
+		t.Fatalf("unexpected segments %v", segments)
+	}
+	// only the duration, the runs are faster than the zx0 blocks
+	frame := DeltaChunks(from, to, segments, false, DeltaCost{Nops: 1})
+	count := frame.Count()
+	if count[ChunkZx0] != 0 || count[ChunkRun] == 0 {
+		t.Fatalf("unexpected chunks %v", count)
+	}
+	// apply the chunks on the screen
+	screen := make(map[uint16]byte)
+	for _, c := range frame {
+		switch c.Type {
+		case ChunkPatch:
+			for i := 1; i < len(c.Data); i += 2 {
+				screen[c.Address+uint16(c.Data[i])] = c.Data[i+1]
+			}
+		case ChunkRun:
+			for i, v := range c.Data[1:] {
+				screen[c.Address+uint16(i)] = v
+			}
+		}
+	}
+	for _, s := range segments {
+		for i := 0; i < s.Length; i++ {
+			if v, ok := screen[s.Address+uint16(i)]; ok && v != to[s.Offset+i] || !ok && from[s.Offset+i] != to[s.Offset+i] {
+				t.Fatalf("byte #%.4x expected #%.2x and gets #%.2x", s.Address+uint16(i), to[s.Offset+i], v)
+			}
+		}
+	}
+	if b := frame.Bytes(); b[len(b)-1] != byte(ChunkEnd) {
+		t.Fatalf("the frame must end with the end chunk")
+	}
+}
@@ -4,6 +4,9 @@ import (
 	"fmt"
 	"os"
 	"testing"
+
+	"github.com/jeromelesaux/martine/constants"
+	"github.com/jeromelesaux/martine/convert/address"
 )
 
 func TestSaveDelta(t *testing.T) {
@@ -31,23 +34,55 @@ func TestSaveDelta(t *testing.T) {
 func TestXandY(t *testing.T) {
 
 	for i := 0; i < 0x4000; i++ {
-		y := Y(uint16(i), 0x50)
-		x := X(uint16(i), 0x50)
-		addr := DeltaAddress(int(x), int(y), 0x50)
+		y := Y(uint16(i), address.DefaultGeometry)
+		x := X(uint16(i), address.DefaultGeometry)
+		addr := DeltaAddress(int(x), int(y), address.DefaultGeometry)
 		if addr != i {
 			t.Fatalf("expected #%.4x and gets #%.4x for x:%d y:%d\n", i, addr, x, y)
 		}
 	}
 
 	add := 0xe010 //- 0xC000
-	x, y, err := CpcCoordinates(0xe010, 0xC000, 0x50)
+	x, y, err := CpcCoordinates(0xe010, 0xC000, address.DefaultGeometry)
 	if err != nil {
 		t.Fatal()
 	}
-	res := DeltaAddress(x, y, 0x50) + 0xC000
-	a := DeltaAddress(16, 3, 0x50) + 0xC000
+	res := DeltaAddress(x, y, address.DefaultGeometry) + 0xC000
+	a := DeltaAddress(16, 3, address.DefaultGeometry) + 0xC000
 	fmt.Println(a)
 	if res != add {
 		t.Fatalf("does not match")
 	}
 }
+
+func TestDeltaGeometry(t *testing.T) {
+	// overscan of 96 bytes x 272 lines at #0200, the lines from the 26th
+	// character line are in the page #4000
+	g := address.Geometries["overscan"]
+	x0, y0, err := CpcCoordinates(0x0200, uint16(g.Page()), g)
+	if err != nil || x0 != 0 || y0 != 0 {
+		t.Fatalf("unexpected coordinates %d,%d of #0200 error %v", x0, y0, err)
+	}
+	size := constants.Size{Width: g.LineWidth(), Height: g.Lines()}
+	scr1 := make([]byte, size.Width*size.Height)
+	scr2 := make([]byte, size.Width*size.Height)
+	points := [][2]int{{0, 0}, {5, 40}, {7, 200}, {95, 271}}
+	for i, p := range points {
+		scr2[p[1]*size.Width+p[0]] = byte(i + 1)
+	}
+	dc := Delta(scr1, scr2, true, size, 2, 0, 0, g)
+	for i, p := range points {
+		expected := uint16(g.Address(p[0], p[1]))
+		item := dc.Items[i]
+		if item.Byte != byte(i+1) || len(item.Offsets) != 1 || item.Offsets[0] != expected {
+			t.Fatalf("expected the byte %d at #%.4x and gets %+v", i+1, expected, item)
+		}
+	}
+	if dc.Items[0].Offsets[0] != 0x0200 || dc.Items[2].Offsets[0] < 0x4000 {
+		t.Fatalf("unexpected addresses %+v", dc.Items)
+	}
+	segments := DeltaSegments(true, size, len(scr1), 0, 0, g)
+	if segments[0].Address != 0x0200 || int(segments[200].Address) != g.Address(0, 200) {
+		t.Fatalf("unexpected segments %+v %+v", segments[0], segments[200])
+	}
+}
@@ -0,0 +1,196 @@
+package transformation
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Rotation3DProjection is the rotation type of the perspective projection.
+const Rotation3DProjection = 7
+
+var (
+	ErrorBadAngleKey = errors.New("bad angle keyframe, expected frame:angle (ex 0:0,12:180)")
+	ErrorBadAxis     = errors.New("bad rotation axis, expected x,y,z (ex 0,1,0)")
+)
+
+// AngleKey is the rotation angle in degrees at the frame.
+type AngleKey struct {
+	Frame int
+	Angle float64
+}
+
+// Projection describes the rotation of the image plane around the axis seen
+// by a perspective camera.
+type Projection struct {
+	// Axis of the rotation, x to the right, y to the bottom, z to the back
+	Axis [3]float64
+	// Distance of the camera in image heights
+	Distance float64
+	// Bilinear sampling of the texture, nearest otherwise
+	Bilinear bool
+	// Keys of the angle curve, a full turn over the frames without keys
+	Keys []AngleKey
+}
+
+// DefaultProjection rotates around the vertical axis with the camera at two
+// image heights.
+var DefaultProjection = Projection{Axis: [3]float64{0, 1, 0}, Distance: 2}
+
+// ParseAxis returns the axis "x,y,z".
+func ParseAxis(s string) ([3]float64, error) {
+	var axis [3]float64
+	parts := strings.Split(s, ",")
+	if len(parts) != 3 {
+		return axis, ErrorBadAxis
+	}
+	var norm float64
+	for i, v := range parts {
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return axis, ErrorBadAxis
+		}
+		axis[i] = f
+		norm += f * f
+	}
+	if norm == 0 {
+		return axis, ErrorBadAxis
+	}
+	return axis, nil
+}
+
+// ParseAngleKeys returns the keyframes "frame:angle,...".
+func ParseAngleKeys(s string) ([]AngleKey, error) {
+	keys := make([]AngleKey, 0)
+	for _, v := range strings.Split(s, ",") {
+		parts := strings.Split(strings.TrimSpace(v), ":")
+		if len(parts) != 2 {
+			return nil, ErrorBadAngleKey
+		}
+		frame, err := strconv.Atoi(parts[0])
+		if err != nil || frame < 0 {
+			return nil, ErrorBadAngleKey
+		}
+		angle, err := strconv.ParseFloat(parts[1], 64)
+		if err != nil {
+			return nil, ErrorBadAngleKey
+		}
+		keys = append(keys, AngleKey{Frame: frame, Angle: angle})
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Frame < keys[j].Frame })
+	return keys, nil
+}
+
+// AngleAt returns the angle of the frame, interpolated between the keys.
+func AngleAt(keys []AngleKey, frame, frames int) float64 {
+	if len(keys) == 0 {
+		return 360. * float64(frame) / float64(frames)
+	}
+	if frame <= keys[0].Frame {
+		return keys[0].Angle
+	}
+	for i := 1; i < len(keys); i++ {
+		if frame <= keys[i].Frame {
+			k0, k1 := keys[i-1], keys[i]
+			t := float64(frame-k0.Frame) / float64(k1.Frame-k0.Frame)
+			return k0.Angle + (k1.Angle-k0.Angle)*t
+		}
+	}
+	return keys[len(keys)-1].Angle
+}
+
+// pixelAspect returns the width of a pixel of the mode in mode 1 pixels.
+func pixelAspect(mode uint8) float64 {
+	switch mode {
+	case 0:
+		return 2
+	case 2:
+		return .5
+	}
+	return 1
+}
+
+// rotation returns the matrix of the rotation around the axis (Rodrigues).
+func rotation(axis [3]float64, angle float64) [3][3]float64 {
+	n := math.Sqrt(axis[0]*axis[0] + axis[1]*axis[1] + axis[2]*axis[2])
+	x, y, z := axis[0]/n, axis[1]/n, axis[2]/n
+	theta := angle * math.Pi / 180.
+	c, s := math.Cos(theta), math.Sin(theta)
+	t := 1 - c
+	return [3][3]float64{
+		{t*x*x + c, t*x*y - s*z, t*x*z + s*y},
+		{t*x*y + s*z, t*y*y + c, t*y*z - s*x},
+		{t*x*z - s*y, t*y*z + s*x, t*z*z + c},
+	}
+}
+
+// Project returns the image rotated of the angle around the axis of the
+// projection through the center (xc, yc), seen by a camera in front of the
+// image. Each pixel of the result is traced back to the image plane, the
+// pixels of the mode are kept with their width.
+func Project(in *image.NRGBA, background color.Color, angle float64, mode uint8, xc, yc int, pr Projection) *image.NRGBA {
+	b := in.Bounds()
+	out := image.NewNRGBA(b)
+	aspect := pixelAspect(mode)
+	distance := pr.Distance
+	if distance <= 0 {
+		distance = DefaultProjection.Distance
+	}
+	distance *= float64(b.Dy())
+	r := rotation(pr.Axis, angle)
+	// normal of the rotated plane, third column of the matrix
+	normal := [3]float64{r[0][2], r[1][2], r[2][2]}
+	bg := color.NRGBAModel.Convert(background).(color.NRGBA)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			// ray from the camera (0,0,-distance) through the pixel
+			dir := [3]float64{(float64(x-xc) + .5) * aspect, float64(y-yc) + .5, distance}
+			dn := dir[0]*normal[0] + dir[1]*normal[1] + dir[2]*normal[2]
+			if math.Abs(dn) < 1e-9 {
+				out.SetNRGBA(x, y, bg)
+				continue
+			}
+			t := distance * normal[2] / dn
+			hit := [3]float64{dir[0] * t, dir[1] * t, dir[2]*t - distance}
+			// back in the image plane with the inverse (transposed) rotation
+			px := r[0][0]*hit[0] + r[1][0]*hit[1] + r[2][0]*hit[2]
+			py := r[0][1]*hit[0] + r[1][1]*hit[1] + r[2][1]*hit[2]
+			sx := px/aspect + float64(xc) - .5
+			sy := py + float64(yc) - .5
+			if pr.Bilinear {
+				out.SetNRGBA(x, y, bilinear(in, sx, sy, bg))
+			} else {
+				out.SetNRGBA(x, y, texel(in, int(math.Floor(sx+.5)), int(math.Floor(sy+.5)), bg))
+			}
+		}
+	}
+	return out
+}
+
+func texel(in *image.NRGBA, x, y int, bg color.NRGBA) color.NRGBA {
+	if !(image.Point{X: x, Y: y}).In(in.Bounds()) {
+		return bg
+	}
+	return in.NRGBAAt(x, y)
+}
+
+func bilinear(in *image.NRGBA, x, y float64, bg color.NRGBA) color.NRGBA {
+	x0, y0 := int(math.Floor(x)), int(math.Floor(y))
+	fx, fy := x-float64(x0), y-float64(y0)
+	if !(image.Point{X: x0, Y: y0}).In(in.Bounds().Inset(-1)) {
+		return bg
+	}
+	var c [4]float64
+	for i, w := range [4]float64{(1 - fx) * (1 - fy), fx * (1 - fy), (1 - fx) * fy, fx * fy} {
+		t := texel(in, x0+i%2, y0+i/2, bg)
+		c[0] += float64(t.R) * w
+		c[1] += float64(t.G) * w
+		c[2] += float64(t.B) * w
+		c[3] += float64(t.A) * w
+	}
+	return color.NRGBA{R: uint8(c[0] + .5), G: uint8(c[1] + .5), B: uint8(c[2] + .5), A: uint8(c[3] + .5)}
+}
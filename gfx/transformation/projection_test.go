@@ -0,0 +1,50 @@
+package transformation
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestProject(t *testing.T) {
+	in := image.NewNRGBA(image.Rect(0, 0, 16, 16))
+	for y := 0; y < 16; y++ {
+		for x := 0; x < 16; x++ {
+			in.SetNRGBA(x, y, color.NRGBA{R: uint8(x * 16), G: uint8(y * 16), A: 0xff})
+		}
+	}
+	for _, mode := range []uint8{0, 1, 2} {
+		out := Project(in, color.Black, 0, mode, 8, 8, DefaultProjection)
+		for y := 0; y < 16; y++ {
+			for x := 0; x < 16; x++ {
+				if out.NRGBAAt(x, y) != in.NRGBAAt(x, y) {
+					t.Fatalf("mode %d: expected the same image without rotation at (%d,%d)", mode, x, y)
+				}
+			}
+		}
+	}
+	// half a turn around the vertical axis mirrors the image
+	out := Project(in, color.Black, 180, 1, 8, 8, DefaultProjection)
+	if out.NRGBAAt(3, 5) != in.NRGBAAt(12, 5) {
+		t.Fatalf("expected a mirrored image and gets %v", out.NRGBAAt(3, 5))
+	}
+	// the image is narrower after a rotation of 60 degrees
+	out = Project(in, color.Black, 60, 1, 8, 8, Projection{Axis: [3]float64{0, 1, 0}, Distance: 2, Bilinear: true})
+	if out.NRGBAAt(0, 8) != (color.NRGBA{A: 0xff}) || out.NRGBAAt(8, 8) == (color.NRGBA{A: 0xff}) {
+		t.Fatalf("expected the background on the side and the image in the center")
+	}
+
+	keys, err := ParseAngleKeys("10:360,0:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a := AngleAt(keys, 5, 20); a != 180 {
+		t.Fatalf("expected 180 degrees at the frame 5 and gets %f", a)
+	}
+	if a := AngleAt(keys, 15, 20); a != 360 {
+		t.Fatalf("expected 360 degrees after the last key and gets %f", a)
+	}
+	if _, err := ParseAxis("0,0,0"); err != ErrorBadAxis {
+		t.Fatalf("expected a bad axis error and gets %v", err)
+	}
+}
@@ -22,11 +22,27 @@ func Rotate3d(in *image.NRGBA,
 	rotation3DX0,
 	rotation3DY0 int,
 	rotation3DType int,
+	projection Projection,
 ) ([]*image.NRGBA, error) {
 	images := make([]*image.NRGBA, 0)
 	if rollIteration == -1 {
 		return images, errors.ErrorMissingNumberOfImageToGenerate
 	}
+	if rotation3DType == Rotation3DProjection {
+		xc, yc := rotation3DX0, rotation3DY0
+		if xc == -1 {
+			xc = in.Bounds().Dx() / 2
+		}
+		if yc == -1 {
+			yc = in.Bounds().Dy() / 2
+		}
+		for i := 0; i < rollIteration; i++ {
+			rin := Project(in, p[0], AngleAt(projection.Keys, i, rollIteration), mode, xc, yc, projection)
+			_, rin = ci.DowngradingWithPalette(rin, p)
+			images = append(images, rin)
+		}
+		return images, nil
+	}
 
 	var indice int
 	angle := 360. / float64(rollIteration)
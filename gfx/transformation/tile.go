@@ -90,22 +90,8 @@ func TileMode(ex *config.MartineConfig, mode uint8, iterationX, iterationY int)
 	return ex.Tiles.Save(ex.Fullpath(".json"))
 }
 
+// Zigzag reverses the odd lines of the image, it is kept for the callers of
+// the package, the implementation is ci.Zigzag.
 func Zigzag(in *image.NRGBA) *image.NRGBA {
-	zizagImg := image.NewNRGBA(image.Rectangle{
-		image.Point{X: 0, Y: 0},
-		image.Point{X: in.Bounds().Max.X, Y: in.Bounds().Max.Y}})
-	for y := 1; y < in.Bounds().Max.Y; y += 2 {
-		xZigZag := 0
-		for x := in.Bounds().Max.X - 1; x >= 0; x-- {
-			zizagImg.Set(xZigZag, y, in.At(x, y))
-			xZigZag++
-		}
-	}
-	for y := 0; y < in.Bounds().Max.Y; y += 2 {
-		for x := 0; x < in.Bounds().Max.X; x++ {
-			zizagImg.Set(x, y, in.At(x, y))
-		}
-	}
-	in = zizagImg
-	return in
+	return ci.Zigzag(in)
 }
@@ -58,7 +58,7 @@ func (m *MartineUI) exportAnimationDialog(a *menu.AnimateMenu, w fyne.Window) {
 					fmt.Println(m.animateExport.ExportFolderPath)
 					pi := custom_widget.NewProgressInfinite("Exporting, please wait.", m.window)
 					pi.Show()
-					code, err := animate.ExportDeltaAnimate(
+					code, loader, err := animate.ExportDeltaAnimate(
 						a.RawImages[0],
 						a.DeltaCollection,
 						a.Palette(),
@@ -67,6 +67,7 @@ func (m *MartineUI) exportAnimationDialog(a *menu.AnimateMenu, w fyne.Window) {
 						uint16(address),
 						uint8(a.Mode),
 						a.ExportVersion,
+						a.Timings,
 					)
 					pi.Hide()
 					if err != nil {
@@ -78,6 +79,13 @@ func (m *MartineUI) exportAnimationDialog(a *menu.AnimateMenu, w fyne.Window) {
 						dialog.ShowError(err, m.window)
 						return
 					}
+					if loader != "" {
+						err = amsdos.SaveStringOSFile(m.animateExport.ExportFolderPath+string(filepath.Separator)+"DELTA.BAS", loader)
+						if err != nil {
+							dialog.ShowError(err, m.window)
+							return
+						}
+					}
 					dialog.ShowInformation("Save", "Your files are save in folder \n"+m.animateExport.ExportFolderPath, m.window)
 				}, m.window)
 				fo.Resize(savingDialogSize)
@@ -102,6 +110,52 @@ func (m *MartineUI) exportAnimationDialog(a *menu.AnimateMenu, w fyne.Window) {
 	d.Show()
 }
 
+// animateTimingsDialog edits the duration in vbls and the cue point of each
+// frame of the computed animation.
+func (m *MartineUI) animateTimingsDialog(a *menu.AnimateMenu) {
+	if len(a.DeltaCollection) == 0 || len(a.Timings) != len(a.DeltaCollection) {
+		dialog.ShowError(fmt.Errorf("compute the animation before editing the timings"), m.window)
+		return
+	}
+	vbls := make([]*widget.Entry, len(a.Timings))
+	cues := make([]*widget.Entry, len(a.Timings))
+	rows := container.NewVBox()
+	for i, t := range a.Timings {
+		vbls[i] = widget.NewEntry()
+		vbls[i].SetText(strconv.Itoa(t.Vbl))
+		vbls[i].Validator = validation.NewRegexp("^\\d+$", "Must contain a number")
+		cues[i] = widget.NewEntry()
+		cues[i].SetPlaceHolder("cue")
+		cues[i].SetText(t.Cue)
+		rows.Add(container.NewGridWithColumns(3, widget.NewLabel(fmt.Sprintf("frame %d", i)), vbls[i], cues[i]))
+	}
+	d := dialog.NewCustomConfirm("Frame timings (vbls)", "Ok", "Cancel", container.NewVScroll(rows), func(b bool) {
+		if !b {
+			return
+		}
+		timings := make([]animate.FrameTiming, len(vbls))
+		var frameCues []string
+		for i := range vbls {
+			vbl, err := strconv.Atoi(vbls[i].Text)
+			if err != nil || vbl < 1 || vbl > 255 {
+				dialog.ShowError(fmt.Errorf("frame %d : expected between 1 and 255 vbls", i), m.window)
+				return
+			}
+			timings[i].Vbl = vbl
+			if cues[i].Text != "" {
+				frameCues = append(frameCues, fmt.Sprintf("%d:%s", i, cues[i].Text))
+			}
+		}
+		if err := animate.ParseCues(timings, strings.Join(frameCues, ",")); err != nil {
+			dialog.ShowError(err, m.window)
+			return
+		}
+		a.Timings = timings
+	}, m.window)
+	d.Resize(savingDialogSize)
+	d.Show()
+}
+
 func (m *MartineUI) refreshAnimatePalette() {
 	m.animate.SetPaletteImage(png.PalToImage(m.animate.Palette()))
 }
@@ -127,6 +181,8 @@ func (m *MartineUI) AnimateApply(a *menu.AnimateMenu) {
 		return
 	}
 	cfg.Compression = m.animateExport.ExportCompression
+	cfg.StableDithering = a.StableDithering
+	cfg.StableThreshold = 8
 	pi := custom_widget.NewProgressInfinite("Computing, Please wait.", m.window)
 	pi.Show()
 	address, err := strconv.ParseUint(a.InitialAddress.Text, 16, 64)
@@ -152,6 +208,10 @@ func (m *MartineUI) AnimateApply(a *menu.AnimateMenu) {
 		return
 	}
 	a.DeltaCollection = deltaCollection
+	if len(a.Timings) != len(deltaCollection) {
+		fmt.Fprintf(os.Stdout, "%d timings for %d frames, use the default timings\n", len(a.Timings), len(deltaCollection))
+		a.Timings = animate.DefaultTimings(len(deltaCollection))
+	}
 	a.SetPalette(palette)
 	a.RawImages = rawImages
 	a.SetPaletteImage(png.PalToImage(a.Palette()))
@@ -191,8 +251,10 @@ func (m *MartineUI) newAnimateTab(a *menu.AnimateMenu) fyne.CanvasObject {
 				}
 				if a.IsEmpty {
 					a.AnimateImages.SubstitueImage(0, 0, canvas.NewImageFromImage(img))
+					a.Timings = animate.DefaultTimings(1)
 				} else {
 					a.AnimateImages.AppendImage(0, canvas.NewImageFromImage(img))
+					a.Timings = append(a.Timings, animate.DefaultTimings(1)...)
 				}
 				a.IsEmpty = false
 				pi.Hide()
@@ -224,6 +286,7 @@ func (m *MartineUI) newAnimateTab(a *menu.AnimateMenu) fyne.CanvasObject {
 					return
 				}
 				imgs := animate.ConvertToImage(*gifImages)
+				a.Timings = animate.GifTimings(gifImages)
 				for index, img := range imgs {
 					if index == 0 {
 						a.AnimateImages.SubstitueImage(0, 0, canvas.NewImageFromImage(img))
@@ -243,6 +306,7 @@ func (m *MartineUI) newAnimateTab(a *menu.AnimateMenu) fyne.CanvasObject {
 
 	resetButton := widget.NewButtonWithIcon("Reset", theme.CancelIcon(), func() {
 		a.AnimateImages.Reset()
+		a.Timings = nil
 		a.IsEmpty = true
 	})
 
@@ -250,6 +314,10 @@ func (m *MartineUI) newAnimateTab(a *menu.AnimateMenu) fyne.CanvasObject {
 		m.exportAnimationDialog(a, m.window)
 	})
 
+	timingsButton := widget.NewButtonWithIcon("Timings", theme.HistoryIcon(), func() {
+		m.animateTimingsDialog(a)
+	})
+
 	applyButton := widget.NewButtonWithIcon("Compute", theme.VisibilityIcon(), func() {
 		fmt.Println("compute.")
 		m.AnimateApply(a)
@@ -262,6 +330,9 @@ func (m *MartineUI) newAnimateTab(a *menu.AnimateMenu) fyne.CanvasObject {
 			return
 		}
 		images[0] = append(images[0][:a.ImageToRemoveIndex], images[0][a.ImageToRemoveIndex+1:]...)
+		if a.ImageToRemoveIndex < len(a.Timings) {
+			a.Timings = append(a.Timings[:a.ImageToRemoveIndex], a.Timings[a.ImageToRemoveIndex+1:]...)
+		}
 		canvasImages := custom_widget.NewImageTableCache(len(images), len(images[0]), fyne.NewSize(50, 50))
 		for x := 0; x < len(images); x++ {
 			for y := 0; y < len(images[x]); y++ {
@@ -314,6 +385,10 @@ func (m *MartineUI) newAnimateTab(a *menu.AnimateMenu) fyne.CanvasObject {
 		}
 	})
 
+	stableDithering := widget.NewCheck("Stable dithering", func(b bool) {
+		a.StableDithering = b
+	})
+
 	oneLine := widget.NewCheck("Every other line", func(b bool) {
 		a.ImageMenu.OneLine = b
 	})
@@ -337,6 +412,7 @@ func (m *MartineUI) newAnimateTab(a *menu.AnimateMenu) fyne.CanvasObject {
 				removeButton,
 				paletteOpen,
 				applyButton,
+				timingsButton,
 				exportButton,
 				importOpen,
 			),
@@ -357,6 +433,7 @@ func (m *MartineUI) newAnimateTab(a *menu.AnimateMenu) fyne.CanvasObject {
 						layout.NewVBoxLayout(),
 						isSprite,
 						compressData,
+						stableDithering,
 					),
 					container.New(
 						layout.NewVBoxLayout(),
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/container"
@@ -23,6 +25,7 @@ import (
 	"github.com/jeromelesaux/martine/export/ocpartstudio/window"
 	"github.com/jeromelesaux/martine/export/spritehard"
 	"github.com/jeromelesaux/martine/gfx/animate"
+	"github.com/jeromelesaux/martine/gfx/sprite"
 	"github.com/jeromelesaux/martine/ui/martine-ui/menu"
 )
 
@@ -34,6 +37,7 @@ func (m *MartineUI) exportSpriteBoard(s *menu.SpriteMenu, w fyne.Window) {
 			string(menu.SpriteFilesExport),
 			string(menu.SpriteCompiled),
 			string(menu.SpriteHard),
+			string(menu.SpriteBank),
 		}, func(v string) {
 			switch menu.SpriteExportFormat(v) {
 			case menu.SpriteFlatExport:
@@ -46,14 +50,41 @@ func (m *MartineUI) exportSpriteBoard(s *menu.SpriteMenu, w fyne.Window) {
 				s.ExportFormat = menu.SpriteCompiled
 			case menu.SpriteHard:
 				s.ExportFormat = menu.SpriteHard
+			case menu.SpriteBank:
+				s.ExportFormat = menu.SpriteBank
 			default:
 				fmt.Fprintf(os.Stderr, "error while getting sprite export format %s\n", v)
 			}
 		})
+	animations := widget.NewEntry()
+	animations.SetPlaceHolder("walk=0-3@5;jump=4,6@3,7")
+	animations.SetText(s.BankAnimations)
+	animations.OnChanged = func(v string) {
+		s.BankAnimations = v
+	}
+	hotspot := widget.NewEntry()
+	hotspot.SetPlaceHolder("x,y (bytes, lines)")
+	hotspot.OnChanged = func(v string) {
+		values := strings.Split(v, ",")
+		if len(values) != 2 {
+			return
+		}
+		x, errX := strconv.Atoi(strings.TrimSpace(values[0]))
+		y, errY := strconv.Atoi(strings.TrimSpace(values[1]))
+		if errX != nil || errY != nil {
+			fmt.Fprintf(os.Stderr, "Error %s cannot be cast in hotspot\n", v)
+			return
+		}
+		s.BankHotspotX, s.BankHotspotY = x, y
+	}
 	cont := container.New(
 		layout.NewVBoxLayout(),
 		widget.NewLabel("export type:"),
 		formatSelect,
+		widget.NewLabel("animations of the animation bank:"),
+		animations,
+		widget.NewLabel("hotspot of the cells of the animation bank:"),
+		hotspot,
 		widget.NewCheck("import all file in Dsk", func(b bool) {
 			s.ExportDsk = b
 		}),
@@ -225,6 +256,31 @@ func (m *MartineUI) ExportSpriteBoard(s *menu.SpriteMenu) {
 			}
 		}
 		pi.Hide()
+	case menu.SpriteBank:
+		sprites := make([][]byte, 0)
+		for _, v := range s.SpritesData {
+			sprites = append(sprites, v...)
+		}
+		if len(sprites) == 0 || s.SpriteHeight == 0 {
+			pi.Hide()
+			dialog.NewError(errors.New("apply the sprite board before exporting the bank"), m.window).Show()
+			return
+		}
+		bank := sprite.NewBank(sprites, uint8(s.Mode), len(sprites[0])/s.SpriteHeight, s.SpriteHeight, s.BankHotspotX, s.BankHotspotY)
+		var err error
+		bank.Animations, err = sprite.ParseAnimations(s.BankAnimations, len(sprites))
+		if err != nil {
+			pi.Hide()
+			dialog.NewError(err, m.window).Show()
+			return
+		}
+		if err := sprite.ExportBank(&bank, s.ExportFolderPath); err != nil {
+			pi.Hide()
+			dialog.NewError(err, m.window).Show()
+			fmt.Fprintf(os.Stderr, "Cannot export the animation bank error %v\n", err)
+			return
+		}
+		pi.Hide()
 	case menu.SpriteHard:
 		data := spritehard.SprImpdraw{}
 		for _, v := range s.SpritesData {
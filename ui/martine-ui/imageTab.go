@@ -28,6 +28,8 @@ import (
 	"github.com/jeromelesaux/martine/export/png"
 	"github.com/jeromelesaux/martine/export/snapshot"
 	"github.com/jeromelesaux/martine/gfx"
+	"github.com/jeromelesaux/martine/gfx/editor"
+	"github.com/jeromelesaux/martine/gfx/effect"
 	"github.com/jeromelesaux/martine/ui/martine-ui/menu"
 	w2 "github.com/jeromelesaux/martine/ui/martine-ui/widget"
 )
@@ -50,6 +52,11 @@ func (m *MartineUI) ExportOneImage(me *menu.ImageMenu) {
 			dialog.ShowError(err, m.window)
 			return
 		}
+		if me.Edited() {
+			// keep the pixels touched up in the editor
+			out = me.Data
+			palette = me.Editor.Palette
+		}
 		code := ascii.FormatAssemblyDatabyte(out, "\n")
 		palCode := ascii.FormatAssemblyCPCPalette(palette, "\n")
 		content := fmt.Sprintf("; Generated by martine\n; from file %s\nImage:\n%s\n\n; palette\npalette: \n%s\n ",
@@ -74,7 +81,27 @@ func (m *MartineUI) ExportOneImage(me *menu.ImageMenu) {
 		}
 		cfg.KitPath = "temporary_palette.kit"
 		filename := filepath.Base(me.OriginalImagePath())
-		if err := gfx.ApplyOneImageAndExport(
+		if me.Edited() {
+			// export the inks of the editor without converting the image again
+			cfg.SpriteHard = me.IsHardSprite
+			if err := me.Editor.Export(m.imageExport.ExportFolderPath+string(filepath.Separator)+filename, cfg); err != nil {
+				pi.Hide()
+				dialog.NewError(err, m.window).Show()
+				return
+			}
+		} else if me.FlashFrames > 1 {
+			if err := effect.TemporalFlash(
+				me.OriginalImage().Image,
+				cfg,
+				filename,
+				m.imageExport.ExportFolderPath+string(filepath.Separator)+filename,
+				uint8(me.Mode),
+				me.FlashFrames); err != nil {
+				pi.Hide()
+				dialog.NewError(err, m.window).Show()
+				return
+			}
+		} else if err := gfx.ApplyOneImageAndExport(
 			me.OriginalImage().Image,
 			cfg,
 			filename,
@@ -149,6 +176,21 @@ func (m *MartineUI) ApplyOneImage(me *menu.ImageMenu) {
 	}
 	pi := custom_widget.NewProgressInfinite("Computing, Please wait.", m.window)
 	pi.Show()
+	if me.FlashFrames > 1 {
+		// the preview shows the averaged colors of the frames
+		out, averaged, blends, err := effect.TemporalRaw(me.OriginalImage().Image, cfg, uint8(me.Mode), me.FlashFrames)
+		pi.Hide()
+		if err != nil {
+			dialog.NewError(err, m.window).Show()
+			return
+		}
+		me.Data = out
+		me.Downgraded = averaged
+		me.SetPalette(effect.AveragedPalette(blends))
+		me.SetCpcImage(me.Downgraded)
+		me.SetPaletteImage(png.PalToImage(me.Palette()))
+		return
+	}
 	out, downgraded, palette, _, err := gfx.ApplyOneImage(me.OriginalImage().Image, cfg, me.Mode, inPalette, uint8(me.Mode))
 	pi.Hide()
 	if err != nil {
@@ -157,6 +199,7 @@ func (m *MartineUI) ApplyOneImage(me *menu.ImageMenu) {
 	}
 	me.Data = out
 	me.Downgraded = downgraded
+	me.Editor = editor.NewEditor(downgraded, palette, uint8(me.Mode))
 	if !me.UsePalette {
 		me.SetPalette(palette)
 	}
@@ -206,6 +249,10 @@ func (m *MartineUI) newImageTransfertTab(me *menu.ImageMenu) fyne.CanvasObject {
 		m.exportDialog(m.imageExport, m.window)
 	})
 
+	editButton := widget.NewButtonWithIcon("Edit", theme.DocumentCreateIcon(), func() {
+		m.newPixelEditor(me)
+	})
+
 	applyButton := widget.NewButtonWithIcon("Apply", theme.VisibilityIcon(), func() {
 		fmt.Println("apply.")
 		m.ApplyOneImage(me)
@@ -240,11 +287,20 @@ func (m *MartineUI) newImageTransfertTab(me *menu.ImageMenu) fyne.CanvasObject {
 		me.DitheringMultiplier = f
 	}
 	dithering := w2.NewDitheringSelect(me)
+	ditheringPattern := w2.NewPatternSelect(me)
 
 	ditheringWithQuantification := widget.NewCheck("With quantification", func(b bool) {
 		me.WithQuantification = b
 	})
 
+	ditheringPaletteAware := widget.NewCheck("On the inks", func(b bool) {
+		me.PaletteAware = b
+	})
+
+	ditheringSerpentine := widget.NewCheck("Serpentine", func(b bool) {
+		me.Serpentine = b
+	})
+
 	enableDithering := widget.NewCheck("Enable dithering", func(b bool) {
 		me.ApplyDithering = b
 	})
@@ -258,6 +314,15 @@ func (m *MartineUI) newImageTransfertTab(me *menu.ImageMenu) fyne.CanvasObject {
 	oneRow := widget.NewCheck("Every other row", func(b bool) {
 		me.OneRow = b
 	})
+	flashFramesLabel := widget.NewLabel("Flash frames")
+	flashFrames := widget.NewSelect([]string{"none", "2", "3", "4"}, func(s string) {
+		frames, err := strconv.Atoi(s)
+		if err != nil {
+			frames = 0
+		}
+		me.FlashFrames = frames
+	})
+	flashFrames.SetSelected("none")
 	modes := widget.NewSelect([]string{"0", "1", "2"}, func(s string) {
 		mode, err := strconv.Atoi(s)
 		if err != nil {
@@ -275,6 +340,9 @@ func (m *MartineUI) newImageTransfertTab(me *menu.ImageMenu) fyne.CanvasObject {
 	heightLabel := widget.NewLabel("Height")
 	me.Height().Validator = validation.NewRegexp("\\d+", "Must contain a number")
 
+	crtcLabel := widget.NewLabel("Crtc")
+	me.Crtc().SetPlaceHolder("R1,R6,R9,R12,R13 or overscan, square, r9-3")
+
 	brightness := widget.NewSlider(0.0, 1.0)
 	brightness.SetValue(1.)
 	brightness.Step = .01
@@ -309,6 +377,7 @@ func (m *MartineUI) newImageTransfertTab(me *menu.ImageMenu) fyne.CanvasObject {
 				openFileWidget,
 				paletteOpen,
 				applyButton,
+				editButton,
 				exportButton,
 				importOpen,
 			),
@@ -334,6 +403,11 @@ func (m *MartineUI) newImageTransfertTab(me *menu.ImageMenu) fyne.CanvasObject {
 						heightLabel,
 						me.Height(),
 					),
+					container.New(
+						layout.NewHBoxLayout(),
+						crtcLabel,
+						me.Crtc(),
+					),
 				),
 			),
 			container.New(
@@ -346,17 +420,25 @@ func (m *MartineUI) newImageTransfertTab(me *menu.ImageMenu) fyne.CanvasObject {
 						resize,
 					),
 					container.New(
-						layout.NewGridLayoutWithColumns(4),
+						layout.NewGridLayoutWithColumns(7),
 						enableDithering,
 						dithering,
+						ditheringPattern,
 						ditheringMultiplier,
 						ditheringWithQuantification,
+						ditheringPaletteAware,
+						ditheringSerpentine,
 					),
 				),
 				container.New(
-					layout.NewGridLayoutWithRows(2),
+					layout.NewGridLayoutWithRows(3),
 					oneLine,
 					oneRow,
+					container.New(
+						layout.NewGridLayoutWithColumns(2),
+						flashFramesLabel,
+						flashFrames,
+					),
 				),
 				container.New(
 					layout.NewGridLayoutWithRows(2),
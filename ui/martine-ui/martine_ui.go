@@ -105,6 +105,17 @@ func (m *MartineUI) NewConfig(me *menu.ImageMenu, checkOriginalImage bool) *conf
 	}
 	cfg.Reducer = me.Reducer
 	cfg.Size = constants.NewSizeMode(uint8(me.Mode), me.IsFullScreen)
+	if me.IsCrtc {
+		g, err := me.GetGeometry()
+		if err == nil {
+			err = cfg.SetGeometry(g)
+		}
+		if err != nil {
+			dialog.NewError(err, m.window).Show()
+			return nil
+		}
+		cfg.Size = g.Size(uint8(me.Mode))
+	}
 	if me.IsSprite {
 		width, _, err := me.GetWidth()
 		if err != nil {
@@ -133,6 +144,9 @@ func (m *MartineUI) NewConfig(me *menu.ImageMenu, checkOriginalImage bool) *conf
 		cfg.DitheringAlgo = -1
 	}
 	cfg.DitheringWithQuantification = me.WithQuantification
+	cfg.DitheringPaletteAware = me.PaletteAware
+	cfg.DitheringSerpentine = me.Serpentine
+	cfg.DitheringPattern = me.DitheringPattern
 	cfg.OutputPath = m.imageExport.ExportFolderPath
 	if checkOriginalImage {
 		cfg.InputPath = me.OriginalImagePath()
@@ -146,6 +160,7 @@ func (m *MartineUI) NewConfig(me *menu.ImageMenu, checkOriginalImage bool) *conf
 	cfg.ExportAsGoFile = m.imageExport.ExportAsGoFiles
 	cfg.OneLine = me.OneLine
 	cfg.OneRow = me.OneRow
+	cfg.FlashFrames = me.FlashFrames
 	return cfg
 }
 
@@ -27,6 +27,8 @@ type AnimateMenu struct {
 	OneRow             bool
 	ImageToRemoveIndex int
 	ExportVersion      animate.DeltaExportFormat
+	StableDithering    bool
+	Timings            []animate.FrameTiming
 }
 
 func NewAnimateMenu() *AnimateMenu {
@@ -79,6 +81,9 @@ func (i *AnimateMenu) CmdLine() string {
 		exec += " -dithering " + strconv.Itoa(i.DitheringAlgoNumber)
 		// stockage du numéro d'algo
 	}
+	if i.StableDithering {
+		exec += " -stable"
+	}
 	exec += " -mode " + strconv.Itoa(i.Mode)
 	if i.Reducer != 0 {
 		exec += " -reducer " + strconv.Itoa(i.Reducer)
@@ -16,11 +16,13 @@ import (
 	"fyne.io/fyne/v2/widget"
 	"github.com/disintegration/imaging"
 	"github.com/jeromelesaux/martine/constants"
+	"github.com/jeromelesaux/martine/convert/address"
 	"github.com/jeromelesaux/martine/convert/screen"
 	ovs "github.com/jeromelesaux/martine/convert/screen/overscan"
 	"github.com/jeromelesaux/martine/convert/sprite"
 	"github.com/jeromelesaux/martine/export/impdraw/overscan"
 	"github.com/jeromelesaux/martine/export/png"
+	"github.com/jeromelesaux/martine/gfx/editor"
 )
 
 type ImageMenu struct {
@@ -31,15 +33,18 @@ type ImageMenu struct {
 	IsFullScreen        bool
 	IsSprite            bool
 	IsHardSprite        bool
+	IsCrtc              bool
 	Mode                int
 	width               *widget.Entry
 	height              *widget.Entry
+	crtc                *widget.Entry
 	palette             color.Palette
 	Data                []byte
 	Downgraded          *image.NRGBA
 	DitheringMatrix     [][]float32
 	DitheringType       constants.DitheringType
 	DitheringAlgoNumber int
+	DitheringPattern    int
 	ApplyDithering      bool
 	ResizeAlgo          imaging.ResampleFilter
 	ResizeAlgoNumber    int
@@ -47,12 +52,16 @@ type ImageMenu struct {
 	UsePalette          bool
 	DitheringMultiplier float64
 	WithQuantification  bool
+	PaletteAware        bool
+	Serpentine          bool
 	Brightness          float64
 	Saturation          float64
 	Reducer             int
 	OneLine             bool
 	OneRow              bool
+	FlashFrames         int
 	CmdLineGenerate     string
+	Editor              *editor.Editor
 }
 
 func NewImageMenu() *ImageMenu {
@@ -62,6 +71,7 @@ func NewImageMenu() *ImageMenu {
 		paletteImage:  &canvas.Image{},
 		width:         widget.NewEntry(),
 		height:        widget.NewEntry(),
+		crtc:          widget.NewEntry(),
 		Downgraded:    &image.NRGBA{},
 	}
 }
@@ -122,6 +132,16 @@ func (i *ImageMenu) GetHeight() (int, string, error) {
 	return v, i.height.Text, err
 }
 
+func (i *ImageMenu) Crtc() *widget.Entry {
+	return i.crtc
+}
+
+// GetGeometry returns the screen geometry of the crtc registers or of the
+// name of the entry.
+func (i *ImageMenu) GetGeometry() (address.Geometry, error) {
+	return address.ParseGeometry(i.crtc.Text)
+}
+
 func (i *ImageMenu) CmdLine() string {
 	exec, err := os.Executable()
 	if err != nil {
@@ -154,16 +174,31 @@ func (i *ImageMenu) CmdLine() string {
 	if i.IsHardSprite {
 		exec += " -spritehard"
 	}
+	if i.IsCrtc {
+		exec += " -crtc \"" + i.crtc.Text + "\""
+	}
 	if i.ApplyDithering {
 		if i.WithQuantification {
 			exec += " -quantization"
 		} else {
 			exec += " -multiplier " + fmt.Sprintf("%.2f", i.DitheringMultiplier)
 		}
+		if i.PaletteAware {
+			exec += " -paletteaware"
+		}
+		if i.Serpentine {
+			exec += " -serpentine"
+		}
+		if i.DitheringPattern != 0 {
+			exec += " -pattern " + strconv.Itoa(i.DitheringPattern)
+		}
 		exec += " -dithering " + strconv.Itoa(i.DitheringAlgoNumber)
 		// stockage du numéro d'algo
 	}
 	exec += " -mode " + strconv.Itoa(i.Mode)
+	if i.FlashFrames > 1 {
+		exec += " -flash -flashframes " + strconv.Itoa(i.FlashFrames)
+	}
 	if i.Reducer != 0 {
 		exec += " -reducer " + strconv.Itoa(i.Reducer)
 	}
@@ -269,3 +304,9 @@ func (me *ImageMenu) NewImportButton(dialogSize fyne.Size, modeSelection *widget
 		d.Show()
 	})
 }
+
+// Edited returns true if the pixels of the converted image were touched up
+// in the pixel editor.
+func (me *ImageMenu) Edited() bool {
+	return me.Editor != nil && me.Editor.CanUndo()
+}
@@ -24,6 +24,7 @@ var (
 	SpriteImpCatcher  SpriteExportFormat = "Impcatcher"
 	SpriteCompiled    SpriteExportFormat = "Compiled"
 	SpriteHard        SpriteExportFormat = "Sprite Hard"
+	SpriteBank        SpriteExportFormat = "Animation bank"
 )
 
 type SpriteMenu struct {
@@ -51,6 +52,9 @@ type SpriteMenu struct {
 	ExportJson             bool
 	ExportCompression      compression.CompressionMethod
 	ExportFolderPath       string
+	BankAnimations         string
+	BankHotspotX           int
+	BankHotspotY           int
 }
 
 func (s *SpriteMenu) SetPalette(p color.Palette) {
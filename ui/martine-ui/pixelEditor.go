@@ -0,0 +1,147 @@
+package ui
+
+import (
+	"errors"
+	"image/color"
+	"strconv"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/jeromelesaux/martine/constants"
+	ci "github.com/jeromelesaux/martine/convert/image"
+	"github.com/jeromelesaux/martine/gfx/editor"
+	"github.com/jeromelesaux/martine/ui/martine-ui/menu"
+	w2 "github.com/jeromelesaux/martine/ui/martine-ui/widget"
+)
+
+var editorTools = map[string]editor.Tool{
+	"Pen":       editor.PenTool,
+	"Fill":      editor.FillTool,
+	"Line":      editor.LineTool,
+	"Rectangle": editor.RectangleTool,
+	"Picker":    editor.PickerTool,
+}
+
+// refreshEditedImage updates the cpc image and the data of the image menu
+// with the pixels of the editor.
+func (m *MartineUI) refreshEditedImage(me *menu.ImageMenu) {
+	e := me.Editor
+	if e == nil {
+		return
+	}
+	if me.IsSprite || me.IsHardSprite {
+		me.Data, _ = e.Sprite()
+		if me.IsHardSprite {
+			me.Data = e.SpriteHard()
+		}
+		newSize := constants.Size{Width: e.Width * 50, Height: e.Height * 50}
+		me.Downgraded = ci.Resize(e.Image(), newSize, me.ResizeAlgo)
+	} else {
+		me.Data = e.Screen(me.IsFullScreen)
+		me.Downgraded = e.Image()
+	}
+	me.SetCpcImage(me.Downgraded)
+}
+
+func (m *MartineUI) newPixelEditor(me *menu.ImageMenu) {
+	if me.Editor == nil {
+		dialog.ShowError(errors.New("apply the conversion before editing the image"), m.window)
+		return
+	}
+	e := me.Editor
+	w := fyne.CurrentApp().NewWindow("Pixel editor")
+	pe := w2.NewPixelEditor(e)
+
+	current := canvas.NewRectangle(e.Palette[0])
+	current.SetMinSize(fyne.NewSize(32, 32))
+	inkLabel := widget.NewLabel("Ink 0")
+	setInk := func(ink int) {
+		pe.Ink = ink
+		current.FillColor = e.Palette[ink]
+		current.Refresh()
+		inkLabel.SetText("Ink " + strconv.Itoa(ink))
+	}
+	pe.OnPicked = setInk
+	pe.OnChanged = func() {
+		m.refreshEditedImage(me)
+	}
+
+	inks := container.New(layout.NewHBoxLayout())
+	for i, c := range e.Palette {
+		ink := i
+		if c == nil {
+			c = color.Black
+		}
+		r := canvas.NewRectangle(c)
+		r.SetMinSize(fyne.NewSize(24, 24))
+		inks.Add(container.New(
+			layout.NewVBoxLayout(),
+			r,
+			widget.NewButton(strconv.Itoa(ink), func() {
+				setInk(ink)
+			}),
+		))
+	}
+
+	toolSelect := widget.NewRadioGroup([]string{"Pen", "Fill", "Line", "Rectangle", "Picker"}, func(s string) {
+		pe.Tool = editorTools[s]
+	})
+	toolSelect.Horizontal = true
+	toolSelect.SetSelected("Pen")
+
+	zoomLabel := widget.NewLabel("Zoom")
+	zoom := widget.NewSlider(1, 8)
+	zoom.Step = 1
+	zoom.SetValue(2)
+	zoom.OnChanged = func(f float64) {
+		pe.SetZoom(int(f))
+	}
+	grid := widget.NewCheck("Grid", pe.SetGrid)
+	grid.SetChecked(true)
+
+	undo := widget.NewButtonWithIcon("Undo", theme.ContentUndoIcon(), func() {
+		if e.Undo() {
+			pe.Refresh()
+			m.refreshEditedImage(me)
+		}
+	})
+	redo := widget.NewButtonWithIcon("Redo", theme.ContentRedoIcon(), func() {
+		if e.Redo() {
+			pe.Refresh()
+			m.refreshEditedImage(me)
+		}
+	})
+
+	w.SetContent(container.NewBorder(
+		container.New(
+			layout.NewVBoxLayout(),
+			container.New(
+				layout.NewHBoxLayout(),
+				toolSelect,
+				undo,
+				redo,
+				grid,
+			),
+			container.New(
+				layout.NewHBoxLayout(),
+				current,
+				inkLabel,
+				inks,
+			),
+			container.New(
+				layout.NewGridLayoutWithColumns(2),
+				zoomLabel,
+				zoom,
+			),
+		),
+		nil, nil, nil,
+		container.NewScroll(pe),
+	))
+	w.Resize(fyne.NewSize(1000, 800))
+	w.Show()
+}
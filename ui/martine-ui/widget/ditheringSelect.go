@@ -19,6 +19,8 @@ func NewDitheringSelect(me *menu.ImageMenu) *widget.Select {
 		"Bayer3",
 		"Bayer4",
 		"Bayer8",
+		"BlueNoise16",
+		"VoidAndCluster16",
 	}, func(s string) {
 		switch s {
 		case "FloydSteinberg":
@@ -65,8 +67,32 @@ func NewDitheringSelect(me *menu.ImageMenu) *widget.Select {
 			me.DitheringAlgoNumber = 10
 			me.DitheringMatrix = filter.Bayer8
 			me.DitheringType = constants.OrderedDither
+		case "BlueNoise16":
+			me.DitheringAlgoNumber = 11
+			me.DitheringMatrix = filter.BlueNoise16
+			me.DitheringType = constants.OrderedDither
+		case "VoidAndCluster16":
+			me.DitheringAlgoNumber = 12
+			me.DitheringMatrix = filter.VoidAndCluster16
+			me.DitheringType = constants.OrderedDither
 		}
 	})
 	dithering.SetSelected("FloydSteinberg")
 	return dithering
 }
+
+// NewPatternSelect selects the positional dithering algorithm used with the
+// ordered dithering matrices.
+func NewPatternSelect(me *menu.ImageMenu) *widget.Select {
+	patterns := map[string]filter.PatternAlgorithm{
+		"Yliluoma1": filter.Yliluoma1,
+		"Yliluoma2": filter.Yliluoma2,
+		"Yliluoma3": filter.Yliluoma3,
+		"Knoll":     filter.Knoll,
+	}
+	pattern := widget.NewSelect([]string{"Yliluoma1", "Yliluoma2", "Yliluoma3", "Knoll"}, func(s string) {
+		me.DitheringPattern = int(patterns[s])
+	})
+	pattern.SetSelected("Yliluoma1")
+	return pattern
+}
@@ -0,0 +1,137 @@
+package widget
+
+import (
+	"fmt"
+	"os"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/widget"
+	"github.com/jeromelesaux/martine/gfx/editor"
+)
+
+// PixelEditor displays the editor image zoomed and applies the selected tool
+// with the mouse (tap for the pen, the fill and the picker, drag for the pen
+// strokes, the lines and the rectangles).
+type PixelEditor struct {
+	widget.BaseWidget
+	Editor    *editor.Editor
+	Tool      editor.Tool
+	Ink       int
+	OnChanged func()
+	OnPicked  func(ink int)
+	zoom      int
+	grid      bool
+	image     *canvas.Image
+	start     fyne.Position
+	last      fyne.Position
+	dragging  bool
+}
+
+func NewPixelEditor(e *editor.Editor) *PixelEditor {
+	p := &PixelEditor{
+		Editor: e,
+		Tool:   editor.PenTool,
+		zoom:   2,
+		grid:   true,
+		image:  &canvas.Image{FillMode: canvas.ImageFillStretch},
+	}
+	p.ExtendBaseWidget(p)
+	p.Refresh()
+	return p
+}
+
+func (p *PixelEditor) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(p.image)
+}
+
+func (p *PixelEditor) SetZoom(zoom int) {
+	p.zoom = zoom
+	p.Refresh()
+}
+
+func (p *PixelEditor) SetGrid(grid bool) {
+	p.grid = grid
+	p.Refresh()
+}
+
+func (p *PixelEditor) Refresh() {
+	img := p.Editor.Render(p.zoom, p.grid)
+	p.image.Image = img
+	p.image.SetMinSize(fyne.NewSize(float32(img.Bounds().Dx()), float32(img.Bounds().Dy())))
+	p.image.Refresh()
+	p.BaseWidget.Refresh()
+}
+
+func (p *PixelEditor) MinSize() fyne.Size {
+	return p.image.MinSize()
+}
+
+// position returns the CPC pixel under the mouse.
+func (p *PixelEditor) position(pos fyne.Position) (int, int) {
+	size := p.Size()
+	if size.Width == 0 || size.Height == 0 {
+		return -1, -1
+	}
+	x := int(pos.X / size.Width * float32(p.Editor.Width))
+	y := int(pos.Y / size.Height * float32(p.Editor.Height))
+	return x, y
+}
+
+func (p *PixelEditor) apply(x0, y0, x1, y1 int) {
+	ink, err := p.Editor.Apply(p.Tool, x0, y0, x1, y1, p.Ink)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error while editing the pixel (%d,%d) :%v\n", x0, y0, err)
+		return
+	}
+	if p.Tool == editor.PickerTool {
+		p.Ink = ink
+		if p.OnPicked != nil {
+			p.OnPicked(ink)
+		}
+		return
+	}
+	p.changed()
+}
+
+func (p *PixelEditor) changed() {
+	p.Refresh()
+	if p.OnChanged != nil {
+		p.OnChanged()
+	}
+}
+
+func (p *PixelEditor) Tapped(e *fyne.PointEvent) {
+	x, y := p.position(e.Position)
+	p.apply(x, y, x, y)
+}
+
+func (p *PixelEditor) Dragged(e *fyne.DragEvent) {
+	if !p.dragging {
+		p.dragging = true
+		p.start = e.Position.Subtract(e.Dragged)
+	}
+	p.last = e.Position
+	if p.Tool == editor.PenTool {
+		x, y := p.position(e.Position)
+		if err := p.Editor.Stroke(x, y, p.Ink); err == nil {
+			p.Refresh()
+		}
+	}
+}
+
+func (p *PixelEditor) DragEnd() {
+	if !p.dragging {
+		return
+	}
+	p.dragging = false
+	switch p.Tool {
+	case editor.PenTool:
+		p.Editor.EndStroke()
+		p.changed()
+	case editor.LineTool, editor.RectangleTool:
+		x0, y0 := p.position(p.start)
+		x1, y1 := p.position(p.last)
+		p.apply(x0, y0, x1, y1)
+	}
+}
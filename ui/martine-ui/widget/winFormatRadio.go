@@ -23,24 +23,33 @@ func NewScreenFormatRadio(me *menu.ImageMenu) *widget.RadioGroup {
 }
 
 func NewWinFormatRadio(me *menu.ImageMenu) *widget.RadioGroup {
-	winFormat := widget.NewRadioGroup([]string{"Normal", "Fullscreen", "Sprite", "Sprite Hard"}, func(s string) {
+	winFormat := widget.NewRadioGroup([]string{"Normal", "Fullscreen", "Sprite", "Sprite Hard", "Crtc"}, func(s string) {
 		switch s {
 		case "Normal":
 			me.IsFullScreen = false
 			me.IsSprite = false
 			me.IsHardSprite = false
+			me.IsCrtc = false
 		case "Fullscreen":
 			me.IsFullScreen = true
 			me.IsSprite = false
 			me.IsHardSprite = false
+			me.IsCrtc = false
 		case "Sprite":
 			me.IsFullScreen = false
 			me.IsSprite = true
 			me.IsHardSprite = false
+			me.IsCrtc = false
 		case "Sprite Hard":
 			me.IsFullScreen = false
 			me.IsSprite = false
 			me.IsHardSprite = true
+			me.IsCrtc = false
+		case "Crtc":
+			me.IsFullScreen = false
+			me.IsSprite = false
+			me.IsHardSprite = false
+			me.IsCrtc = true
 		}
 	})
 	winFormat.SetSelected("Normal")